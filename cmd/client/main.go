@@ -25,13 +25,14 @@ var (
 )
 
 const (
+	StateLogin        = "Login"
 	StateMainMenu     = "MainMenu"
 	StateInQueue      = "InQueue"
 	StateInTradeQueue = "InTradeQueue"
 	StateInMatch      = "InMatch"
 )
 
-var clientState = StateMainMenu
+var clientState = StateLogin
 
 func main() {
 	interrupt := make(chan os.Signal, 1)
@@ -170,6 +171,8 @@ func handleUserInput(conn *websocket.Conn, scanner *bufio.Scanner, userInput str
 		printPrompt()
 	} else {
 		switch clientState {
+		case StateLogin:
+			handleLoginInput(conn, scanner, userInput)
 		case StateMainMenu:
 			handleMainMenuInput(conn, scanner, userInput)
 		case StateInQueue:
@@ -177,13 +180,15 @@ func handleUserInput(conn *websocket.Conn, scanner *bufio.Scanner, userInput str
 		case StateInTradeQueue:
 			handleInTradeQueueInput(conn, userInput)
 		case StateInMatch:
-			handleInMatchInput(conn, userInput)
+			handleInMatchInput(conn, scanner, userInput)
 		}
 	}
 }
 
 func updateClientState(newState string) {
 	switch newState {
+	case "awaiting-login":
+		clientState = StateLogin
 	case "lobby":
 		clientState = StateMainMenu
 	case "in-match-queue":
@@ -198,12 +203,46 @@ func updateClientState(newState string) {
 	}
 }
 
+func handleLoginInput(conn *websocket.Conn, scanner *bufio.Scanner, choice string) {
+	var msgType string
+	switch choice {
+	case "1":
+		msgType = "LOGIN"
+	case "2":
+		msgType = "REGISTER"
+	default:
+		fmt.Println("Opção inválida.")
+		printPrompt()
+		return
+	}
+	username := promptForString(scanner, "Usuário: ")
+	password := promptForString(scanner, "Senha: ")
+	payload, _ := json.Marshal(map[string]string{"username": username, "password": password})
+	if err := conn.WriteJSON(network.Message{Type: msgType, Payload: payload}); err != nil {
+		log.Printf("Erro ao enviar mensagem: %v", err)
+	}
+}
+
 func handleMainMenuInput(conn *websocket.Conn, scanner *bufio.Scanner, choice string) {
 	var msg network.Message
 	shouldSend := true
 	switch choice {
 	case "1":
-		msg.Type = "FIND_MATCH"
+		// Modo de jogo (ruleset). Vazio = modo clássico.
+		ruleset := promptForString(scanner, "Modo de jogo (Enter = classic, ou color-advantage): ")
+		// Formato da série. Vazio = partida única.
+		bestOf := 0
+		if raw := strings.TrimSpace(promptForString(scanner, "Formato (Enter = partida única, 3 = melhor de 3, 5 = melhor de 5): ")); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil {
+				fmt.Println("Formato inválido. Digite um número ímpar.")
+				shouldSend = false
+				break
+			}
+			bestOf = n
+		}
+		payload, _ := json.Marshal(map[string]interface{}{"ruleset": strings.TrimSpace(ruleset), "bestOf": bestOf})
+		msg = network.Message{Type: "FIND_MATCH", Payload: payload}
 	case "2":
 		cardKey := promptForString(scanner, "Digite a chave da carta que você quer trocar (ex: rock:5:red): ")
 		if cardKey == "" {
@@ -255,6 +294,47 @@ func handleMainMenuInput(conn *websocket.Conn, scanner *bufio.Scanner, choice st
 	case "10":
 		// --- MUDANÇA: NOVA OPÇÃO BLOCKCHAIN ---
 		msg.Type = "VIEW_AUDIT"
+	case "11":
+		msg.Type = "SYNC_FROM_LEDGER"
+	case "12":
+		msg.Type = "VIEW_RATING"
+	case "13":
+		offer := promptForString(scanner, "Digite a chave da carta que você oferece: ")
+		want := splitCardKeys(promptForString(scanner, "Cartas que aceita receber, separadas por vírgula (vazio = qualquer uma): "))
+		payload, _ := json.Marshal(map[string]interface{}{"offer": offer, "want": want})
+		msg = network.Message{Type: "LIST_TRADE", Payload: payload}
+	case "14":
+		msg.Type = "VIEW_LISTINGS"
+	case "15", "16", "17":
+		index, err := promptForInt(scanner, "Digite o número da sua oferta (ver opção 14): ")
+		if err != nil {
+			fmt.Println(err)
+			shouldSend = false
+			break
+		}
+		payload, _ := json.Marshal(map[string]int{"listing": index})
+		listingCommands := map[string]string{"15": "CANCEL_LISTING", "16": "ACCEPT_LISTING", "17": "DECLINE_LISTING"}
+		msg = network.Message{Type: listingCommands[choice], Payload: payload}
+	case "18":
+		target := promptForString(scanner, "ID do jogador: ")
+		give := splitCardKeys(promptForString(scanner, "Cartas que você dá, separadas por vírgula: "))
+		receive := splitCardKeys(promptForString(scanner, "Cartas que você quer receber, separadas por vírgula: "))
+		payload, _ := json.Marshal(map[string]interface{}{"targetPlayerId": strings.TrimSpace(target), "give": give, "receive": receive})
+		msg = network.Message{Type: "PROPOSE_TRADE", Payload: payload}
+	case "19":
+		msg.Type = "VIEW_TRADES"
+	case "20", "21":
+		index, err := promptForInt(scanner, "Digite o número da proposta (ver opção 19): ")
+		if err != nil {
+			fmt.Println(err)
+			shouldSend = false
+			break
+		}
+		payload, _ := json.Marshal(map[string]int{"proposal": index})
+		tradeCommands := map[string]string{"20": "ACCEPT_TRADE", "21": "REJECT_TRADE"}
+		msg = network.Message{Type: tradeCommands[choice], Payload: payload}
+	case "22":
+		msg.Type = "REQUEST_REMATCH"
 	default:
 		fmt.Println("Opção inválida.")
 		shouldSend = false
@@ -269,6 +349,17 @@ func handleMainMenuInput(conn *websocket.Conn, scanner *bufio.Scanner, choice st
 	}
 }
 
+// splitCardKeys transforma "rock:1:red, paper:2:blue" em uma lista de chaves.
+func splitCardKeys(input string) []string {
+	keys := []string{}
+	for _, key := range strings.Split(input, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
 func handleInQueueInput(conn *websocket.Conn, choice string) {
 	if choice != "0" {
 		fmt.Println("Opção inválida.")
@@ -283,7 +374,23 @@ func handleInTradeQueueInput(conn *websocket.Conn, choice string) {
 	}
 }
 
-func handleInMatchInput(conn *websocket.Conn, choice string) {
+func handleInMatchInput(conn *websocket.Conn, scanner *bufio.Scanner, choice string) {
+	// Sideboard entre os jogos de uma série: o deck inteiro para o próximo jogo.
+	if strings.ToUpper(choice) == "B" {
+		keys := splitCardKeys(promptForString(scanner, "Deck para o próximo jogo (chaves separadas por vírgula): "))
+		payload, _ := json.Marshal(map[string][]string{"deck": keys})
+		if err := conn.WriteJSON(network.Message{Type: "SIDEBOARD", Payload: payload}); err != nil {
+			log.Printf("Erro ao enviar mensagem: %v", err)
+		}
+		return
+	}
+	matchCommands := map[string]string{"V": "VIEW_MATCH", "S": "SURRENDER", "E": "OFFER_DRAW", "A": "ACCEPT_DRAW", "R": "REQUEST_REMATCH"}
+	if msgType, ok := matchCommands[strings.ToUpper(choice)]; ok {
+		if err := conn.WriteJSON(network.Message{Type: msgType}); err != nil {
+			log.Printf("Erro ao enviar mensagem: %v", err)
+		}
+		return
+	}
 	index, err := strconv.Atoi(choice)
 	if err != nil {
 		fmt.Println("Entrada inválida. Por favor, digite um número.")
@@ -333,6 +440,14 @@ func printPrompt() {
 	var prompt string
 	time.Sleep(100 * time.Millisecond)
 	switch clientState {
+	case StateLogin:
+		prompt = `
+--- Jokenpo Card Game ---
+1. Entrar (Login)
+2. Criar Conta
+-------------------------
+
+Digite uma opção: `
 	case StateMainMenu:
 		prompt = `
 --- Jokenpo Card Game (Lobby) ---
@@ -346,6 +461,18 @@ func printPrompt() {
 8. Substituir Carta no Deck
 9. Medir Ping (WebSocket)
 10. [BLOCKCHAIN] Ver Livro Razão (Auditoria)
+11. [BLOCKCHAIN] Sincronizar Coleção com o Livro Razão
+12. Ver Rating
+13. [MERCADO] Publicar Oferta de Troca
+14. [MERCADO] Ver Ofertas
+15. [MERCADO] Cancelar Oferta
+16. [MERCADO] Aceitar Troca Encontrada
+17. [MERCADO] Recusar Troca Encontrada
+18. [TROCA DIRETA] Propor Troca a um Jogador
+19. [TROCA DIRETA] Ver Propostas
+20. [TROCA DIRETA] Aceitar Proposta
+21. [TROCA DIRETA] Recusar Proposta
+22. Pedir Revanche da Última Partida
 ---------------------------------
 
 (Lobby) Digite uma opção: `
@@ -354,7 +481,7 @@ func printPrompt() {
 	case StateInTradeQueue:
 		prompt = "\n(Na Fila de Troca) Digite 0 para sair: "
 	case StateInMatch:
-		prompt = "\n(Em Jogo) Digite o índice da carta para jogar: "
+		prompt = "\n(Em Jogo) Digite o índice da carta para jogar (V: ver partida, S: desistir, E: oferecer empate, A: aceitar empate, R: pedir revanche, B: sideboard entre jogos): "
 	}
 	fmt.Print(prompt)
 }
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"jokenpo/internal/ledger" // Seu pacote gerado
+	"jokenpo/internal/services/blockchain"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
@@ -60,6 +61,11 @@ func main() {
 	}
 	log.Println("[Deployer] Contrato minerado e confirmado!")
 
+	// Não publica no Consul um contrato que não bate com os bindings.
+	if err := blockchain.VerifyContractCode(context.Background(), client, addr); err != nil {
+		log.Fatalf("Fatal: %v", err)
+	}
+
 	// 5. Salvar no Consul
 	log.Println("[Deployer] Conectando ao Consul...")
 	consulConfig := api.DefaultConfig()
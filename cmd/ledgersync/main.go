@@ -0,0 +1,160 @@
+//START OF FILE jokenpo/cmd/ledgersync/main.go
+package main
+
+// ledgersync é o job de operador que compara os inventários salvos pelo Session
+// com os tokens do contrato. Por padrão só relata o drift; com -apply grava a
+// coleção do ledger nos inventários divergentes.
+//
+// Uma sessão aberta regrava o inventário que tem em memória na próxima mutação,
+// então com -apply o job adquire a presença de cada jogador no Consul (a mesma
+// trava do LOGIN) antes de gravar: jogadores online são pulados e relatados, e
+// quem tentar logar durante a gravação é recusado até a presença ser liberada.
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"jokenpo/internal/game/card"
+	"jokenpo/internal/services/blockchain"
+	"jokenpo/internal/services/cluster"
+	"jokenpo/internal/services/presence"
+	"jokenpo/internal/session"
+	"jokenpo/internal/session/account"
+	"jokenpo/internal/session/storage"
+
+	"github.com/hashicorp/consul/api"
+)
+
+const (
+	ConsulKey         = "jokenpo/config/contract_address"
+	defaultConsulAddr = "consul-1:8500"
+)
+
+func main() {
+	dataDir := flag.String("data", account.DataDir(), "diretório de dados do Session (SESSION_DATA_DIR)")
+	contractAddr := flag.String("contract", "", "endereço do contrato (padrão: lido do Consul)")
+	playerID := flag.String("player", "", "sincroniza só este jogador")
+	apply := flag.Bool("apply", false, "grava a coleção do ledger nos inventários divergentes")
+	flag.Parse()
+
+	if err := card.InitGlobalCatalog(); err != nil {
+		log.Fatalf("Fatal: Falha ao inicializar o catálogo de cartas: %v", err)
+	}
+
+	if *contractAddr == "" {
+		*contractAddr = contractFromConsul()
+	}
+	bc, _, err := blockchain.InitBlockchain(*contractAddr)
+	if err != nil {
+		log.Fatalf("Fatal: Falha ao conectar no contrato %s: %v", *contractAddr, err)
+	}
+
+	repo, err := storage.NewLogRepository(*dataDir)
+	if err != nil {
+		log.Fatalf("Fatal: %v", err)
+	}
+	players := []string{*playerID}
+	if *playerID == "" {
+		if players, err = repo.PlayerIDs(); err != nil {
+			log.Fatalf("Fatal: Falha ao listar inventários: %v", err)
+		}
+	}
+
+	var lock *presence.Store
+	lockURL := jobURL()
+	if *apply {
+		manager, err := cluster.NewConsulManager(consulAddrs())
+		if err != nil {
+			log.Fatalf("Fatal: Falha ao conectar no Consul (necessário para -apply): %v", err)
+		}
+		lock = presence.NewStore(manager)
+	}
+
+	var drifted, failed int
+	var online []string
+	for _, id := range players {
+		if lock != nil {
+			if err := lock.Claim(id, lockURL); err != nil {
+				if errors.Is(err, presence.ErrAlreadyConnected) {
+					online = append(online, id)
+					continue
+				}
+				log.Printf("[LedgerSync] %s: ERRO: presença: %v", id, err)
+				failed++
+				continue
+			}
+		}
+		drift, dropped, err := session.SyncStoredInventory(bc, repo, id, *apply)
+		if lock != nil {
+			if err := lock.Withdraw(id, lockURL); err != nil {
+				log.Printf("[LedgerSync] %s: AVISO: falha ao liberar a presença (expira com a session do job): %v", id, err)
+			}
+		}
+		if err != nil {
+			log.Printf("[LedgerSync] %s: ERRO: %v", id, err)
+			failed++
+			continue
+		}
+		if drift.InSync() {
+			continue
+		}
+		drifted++
+		log.Printf("[LedgerSync] %s:\n%s", id, strings.TrimRight(drift.String(), "\n"))
+		if len(dropped) > 0 {
+			log.Printf("[LedgerSync] %s: removidas do deck: %s", id, strings.Join(dropped, ", "))
+		}
+	}
+
+	action := "relatados"
+	if *apply {
+		action = "corrigidos"
+	}
+	if len(online) > 0 {
+		log.Printf("[LedgerSync] %d jogadores online pulados (rode de novo depois): %s", len(online), strings.Join(online, ", "))
+	}
+	log.Printf("[LedgerSync] %d jogadores verificados, %d com drift (%s), %d online, %d com erro.", len(players)-len(online), drifted, action, len(online), failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// consulAddrs devolve os nós do Consul (CONSUL_HTTP_ADDR, separados por vírgula).
+func consulAddrs() string {
+	if addrs := os.Getenv("CONSUL_HTTP_ADDR"); addrs != "" {
+		return addrs
+	}
+	return defaultConsulAddr
+}
+
+// jobURL é o valor gravado na presença enquanto o job segura um jogador. Não é
+// a URL de nenhum Session, então o Withdraw de um nó nunca apaga a trava do job.
+func jobURL() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("ledgersync://%s/%d", host, os.Getpid())
+}
+
+func contractFromConsul() string {
+	consulConfig := api.DefaultConfig()
+	consulConfig.Address, _, _ = strings.Cut(consulAddrs(), ",")
+	consulClient, err := api.NewClient(consulConfig)
+	if err != nil {
+		log.Fatalf("Fatal: Erro cliente Consul: %v", err)
+	}
+	for i := 0; i < 30; i++ {
+		pair, _, err := consulClient.KV().Get(ConsulKey, nil)
+		if err == nil && pair != nil {
+			return string(pair.Value)
+		}
+		log.Printf("[LedgerSync] Aguardando endereço do contrato no Consul... (%v)", err)
+		time.Sleep(1 * time.Second)
+	}
+	log.Fatal("Fatal: Timeout lendo o endereço do contrato no Consul")
+	return ""
+}
+
+//END OF FILE jokenpo/cmd/ledgersync/main.go
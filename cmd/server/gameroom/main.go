@@ -99,6 +99,7 @@ func main() {
 		log.Fatalf("Fatal: Falha ao criar o Service Registrar: %v", err)
 	}
 
+	registrar.SetMeta(cluster.CatalogMetaKey, card.Fingerprint())
 	consulManager.OnReconnect(registrar.Register)
 	registrar.Register()
 	// --- FIM DA LÓGICA DE REGISTRO RESILIENTE ---
@@ -112,7 +113,8 @@ func main() {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/health", cluster.NewBasicHealthHandler())
 
-	gameroom.RegisterHandlers(mux, roomManager, cfg.ServicePort)
+	catalogGuard := cluster.NewCatalogGuard(card.Fingerprint())
+	gameroom.RegisterHandlers(mux, roomManager, cfg.ServicePort, catalogGuard, consulManager)
 	log.Println("[Main] Handlers HTTP registrados para /rooms e /health.")
 
 	listenAddress := fmt.Sprintf(":%d", cfg.ServicePort)
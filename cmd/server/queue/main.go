@@ -3,6 +3,7 @@ package main
 
 import (
 	"fmt"
+	"jokenpo/internal/game/card"
 	"jokenpo/internal/services/cluster"
 	"jokenpo/internal/services/queue"
 	"log"
@@ -58,20 +59,6 @@ func loadConfig() (*Config, error) {
 	}, nil
 }
 
-type SimpleLeaderFollower struct {
-	Queue *queue.QueueMaster
-}
-
-func (s *SimpleLeaderFollower) GetState() interface{}      { return nil }
-func (s *SimpleLeaderFollower) SetState(state []byte) error { return nil }
-func (s *SimpleLeaderFollower) OnBecomeLeader() {
-	log.Println("[Main] This node became the leader. Starting QueueMaster actor...")
-	go s.Queue.Run()
-}
-func (s *SimpleLeaderFollower) OnBecomeFollower() {
-	log.Println("[Main] This node became a follower. QueueMaster is idle.")
-}
-
 func main() {
 	log.Println("Iniciando instância do serviço Jokenpo Queue...")
 
@@ -82,6 +69,11 @@ func main() {
 	log.Printf("[Main] Configuração carregada: ServiceName=%s, Port=%d, HealthPort=%d, ConsulAddrs=%s",
 		cfg.ServiceName, cfg.ServicePort, cfg.HealthPort, cfg.ConsulAddrs)
 
+	// O Queue não joga, mas repassa decks ao GameRoom: precisa do catálogo para o fingerprint.
+	if err := card.InitGlobalCatalog(); err != nil {
+		log.Fatalf("Falha fatal ao inicializar o catálogo de cartas: %v", err)
+	}
+
 	// 1. Cria o ConsulManager
 	consulManager, err := cluster.NewConsulManager(cfg.ConsulAddrs)
 	if err != nil {
@@ -107,26 +99,30 @@ func main() {
 		log.Fatalf("Fatal: Falha ao criar o Service Registrar: %v", err)
 	}
 
+	registrar.SetMeta(cluster.CatalogMetaKey, card.Fingerprint())
 	consulManager.OnReconnect(registrar.Register)
 	registrar.Register()
 
-    // --- MUDANÇA CRUCIAL AQUI ---
-    // Passamos o consulManager para o QueueMaster poder descobrir a Blockchain
-	queueMaster := queue.NewQueueMaster(consulManager)
-	
     elector, err := cluster.NewLeaderElector(cfg.ServiceName, consulManager, advertisedHost)
 	if err != nil {
 		log.Fatalf("Fatal: Falha ao criar eleitor de líder: %v", err)
 	}
+
+    // --- MUDANÇA CRUCIAL AQUI ---
+    // Passamos o consulManager para o QueueMaster poder descobrir a Blockchain,
+    // e o elector para ele persistir as filas a cada mutação.
+	queueMaster := queue.NewQueueMaster(consulManager, elector)
 	log.Println("[Main] Componentes QueueMaster e LeaderElector criados.")
 
-	leaderFollowerHandler := &SimpleLeaderFollower{Queue: queueMaster}
-	go elector.RunForLeadership(leaderFollowerHandler)
+	// O QueueMaster é o próprio StatefulService: o elector restaura as filas
+	// salvas no Consul antes de chamar OnBecomeLeader.
+	go elector.RunForLeadership(queueMaster)
 	log.Println("[Main] Campanha pela liderança iniciada em background.")
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/health", cluster.NewBasicHealthHandler())
-	queue.RegisterQueueHandlers(mux, queueMaster, elector)
+	catalogGuard := cluster.NewCatalogGuard(card.Fingerprint())
+	queue.RegisterQueueHandlers(mux, queueMaster, elector, catalogGuard)
 	log.Println("[Main] Handlers HTTP registrados para /queue/* e /health.")
 
 	listenAddress := fmt.Sprintf(":%d", cfg.ServicePort)
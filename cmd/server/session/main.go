@@ -6,11 +6,13 @@ import (
 	"jokenpo/internal/game/card"
 	"jokenpo/internal/network"
 	"jokenpo/internal/services/cluster"
+	"jokenpo/internal/services/outbox"
 	"jokenpo/internal/session"
 	"log"
 	"net/http"
 	"os"
 	"strconv"
+	"time"
 )
 
 const (
@@ -18,6 +20,7 @@ const (
 	defaultServicePort = 8080
 	defaultHealthPort  = 8080
 	defaultConsulAddr  = "consul-1:8500,consul-2:8500,consul-3:8500"
+	callbackDedupeTTL  = 10 * time.Minute // Bem acima do tempo total de repetições do outbox
 )
 
 type Config struct {
@@ -103,6 +106,9 @@ func main() {
 		log.Fatalf("Fatal: Falha ao criar o Service Registrar: %v", err)
 	}
 
+	// Publica o fingerprint do catálogo junto do registro.
+	registrar.SetMeta(cluster.CatalogMetaKey, card.Fingerprint())
+
 	// 3. Conecta os dois: toda vez que o manager se reconectar, ele tentará registrar o serviço novamente.
 	consulManager.OnReconnect(registrar.Register)
 
@@ -120,9 +126,12 @@ func main() {
 	log.Println("[Main] Servidor de rede criado.")
 
 	http.HandleFunc("/health", cluster.NewBasicHealthHandler())
-	http.HandleFunc("/match-found", gameHandler.CallbackMatchFound)
-	http.HandleFunc("/trade-found", gameHandler.CallbackTradeFound)
-	http.HandleFunc("/game-event", gameHandler.CallbackGameEvent)
+	// Os callbacks do outbox podem chegar repetidos: o mesmo Idempotency-Key só é processado uma vez.
+	dedupe := outbox.NewDeduplicator(callbackDedupeTTL)
+	http.HandleFunc("/match-found", dedupe.Middleware(gameHandler.CallbackMatchFound))
+	http.HandleFunc("/trade-found", dedupe.Middleware(gameHandler.CallbackTradeFound))
+	http.HandleFunc("/market-event", dedupe.Middleware(gameHandler.CallbackMarketEvent))
+	http.HandleFunc("/trade-event", dedupe.Middleware(gameHandler.CallbackTradeEvent))
 	log.Printf("[Main] Handlers de Health Check e Callback registrados.")
 
 	// A chamada antiga e única ao RegisterServiceInConsul foi removida.
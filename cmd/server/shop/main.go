@@ -3,6 +3,7 @@ package main
 
 import (
 	"fmt"
+	"jokenpo/internal/game/card"
 	"jokenpo/internal/services/cluster"
 	"jokenpo/internal/services/shop"
 	"log"
@@ -52,6 +53,10 @@ func main() {
 	cfg, err := loadConfig()
 	if err != nil { log.Fatalf("Fatal: %v", err) }
 
+	if err := card.InitGlobalCatalog(); err != nil {
+		log.Fatalf("Falha fatal ao inicializar o catálogo de cartas: %v", err)
+	}
+
 	consulManager, err := cluster.NewConsulManager(cfg.ConsulAddrs)
 	if err != nil { log.Fatalf("Fatal: %v", err) }
 
@@ -69,11 +74,16 @@ func main() {
 	)
 	if err != nil { log.Fatalf("Fatal: %v", err) }
 
+	// Publica o fingerprint do catálogo junto do registro, para os outros serviços compararem.
+	registrar.SetMeta(cluster.CatalogMetaKey, card.Fingerprint())
 	consulManager.OnReconnect(registrar.Register)
 	registrar.Register()
 
+	templates, err := shop.LoadPackTemplates()
+	if err != nil { log.Fatalf("Fatal: templates de pacote inválidos: %v", err) }
+
     // --- MUDANÇA: Passa o consulManager para o serviço ---
-	shopService := shop.NewShopService(consulManager)
+	shopService := shop.NewShopService(consulManager, templates)
 	log.Println("[Main] Ator do ShopService criado.")
 
 	elector, err := cluster.NewLeaderElector(cfg.ServiceName, consulManager, advertisedHost)
@@ -82,8 +92,10 @@ func main() {
 	go elector.RunForLeadership(shopService)
 
 	shopHandler := shop.CreateShopHandler(shopService, elector)
+	catalogGuard := cluster.NewCatalogGuard(card.Fingerprint())
 	http.HandleFunc("/health", cluster.NewBasicHealthHandler())
-	http.HandleFunc("/Purchase", shopHandler)
+	http.Handle("/Purchase", catalogGuard.Middleware(shopHandler))
+	http.HandleFunc("/packs/verify", shop.CreateVerifyPacksHandler(shopService))
 
 	listenAddress := fmt.Sprintf(":%d", cfg.ServicePort)
 	log.Printf("[Main] Servidor HTTP iniciando em %s.", listenAddress)
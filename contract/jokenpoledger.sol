@@ -26,6 +26,20 @@ contract JokenpoLedger {
     
     // Mapeia UUID do Jogador -> Lista de UUIDs das Cartas que ele possui
     mapping(string => string[]) private ownerAssets;
+
+    // Compromisso (sha256) da semente que o Shop vai usar no próximo pacote.
+    // A semente só é revelada no AuditPackOpened, quando o compromisso é conferido.
+    bytes32 public packSeedCommitment;
+
+    // Entradas da derivação de um pacote. Com elas qualquer um recalcula as cartas.
+    struct PackDerivation {
+        bytes32 seed;           // Semente revelada (sha256(seed) == compromisso anterior)
+        uint256 packageCounter; // Contador global do Shop no primeiro pacote da compra
+        uint256 pity;           // Contador de pity do jogador antes da compra
+        string template;        // Template de pacote usado
+        string catalog;         // Fingerprint do catálogo de cartas
+        bytes32 nextSeedCommitment; // Compromisso da semente do próximo pacote
+    }
     
     // ============================================================
     // LOGS DE AUDITORIA (Eventos)
@@ -33,33 +47,55 @@ contract JokenpoLedger {
     // ============================================================
 
     // Log: Jogador abriu pacote (Entrada de ativos)
-    event AuditPackOpened(uint256 timestamp, string playerId, string[] cardIds);
+    event AuditPackOpened(uint256 timestamp, string playerId, string[] cardIds, PackDerivation derivation);
+
+    // Log: Shop publicou (ou republicou) o compromisso de uma semente
+    event AuditSeedCommitted(uint256 timestamp, bytes32 commitment);
 
     // Log: Troca realizada (Transferência de ativos)
     event AuditTrade(uint256 timestamp, string fromPlayer, string toPlayer, string cardId);
 
+    // Log: Troca de uma carta por outra entre dois jogadores, numa única transação
+    event AuditSwap(uint256 timestamp, string playerA, string playerB, string cardA, string cardB);
+
+    // Log: Troca de pacotes de cartas entre dois jogadores, numa única transação
+    event AuditBundleSwap(uint256 timestamp, string playerA, string playerB, string[] cardsA, string[] cardsB);
+
     // Log: Resultado de Partida (Registro histórico)
-    event AuditMatch(uint256 timestamp, string roomId, string winnerId, string loserId);
+    // winnerId e loserId vazios = empate; reason diz como a partida terminou (vitória, desistência, empate combinado...)
+    event AuditMatch(uint256 timestamp, string roomId, string winnerId, string loserId, string reason);
 
     // ============================================================
     // TRANSAÇÕES (Escrita no Livro Razão)
     // ============================================================
 
+    // 0. Publicar o compromisso da semente dos pacotes
+    // Usado quando o Shop ainda não tem um compromisso na cadeia (primeiro pacote ou
+    // estado perdido). Cada republicação fica registrada para a auditoria.
+    function commitPackSeed(bytes32 _commitment) public onlyAuthority {
+        packSeedCommitment = _commitment;
+        emit AuditSeedCommitted(block.timestamp, _commitment);
+    }
+
     // 1. Registrar Abertura de Pacote
     // Ex: "As 1h jogador A comprou um pacote com as cartas XYZ"
-    function logPackOpening(string memory _playerId, string[] memory _cardIds) public onlyAuthority {
+    // A semente revelada precisa bater com o compromisso publicado antes da compra.
+    function logPackOpening(string memory _playerId, string[] memory _cardIds, PackDerivation memory _derivation) public onlyAuthority {
+        require(sha256(abi.encodePacked(_derivation.seed)) == packSeedCommitment, "Erro de Auditoria: semente nao corresponde ao compromisso.");
+        packSeedCommitment = _derivation.nextSeedCommitment;
+
         // Adiciona as cartas ao "inventário blockchain" do jogador
         for (uint i = 0; i < _cardIds.length; i++) {
             ownerAssets[_playerId].push(_cardIds[i]);
         }
         
         // Emite o log com o timestamp atual do bloco
-        emit AuditPackOpened(block.timestamp, _playerId, _cardIds);
+        emit AuditPackOpened(block.timestamp, _playerId, _cardIds, _derivation);
     }
 
     // 2. Registrar Troca
     // Ex: "As 3h o jogador A trocou a carta X pela carta A do jogador B"
-    // Nota: Para fazer troca dupla, o servidor deve chamar essa função duas vezes (A->B e B->A)
+    // Nota: Para troca dupla, use logSwap (as duas transferências numa transação só)
     function logTrade(string memory _fromPlayer, string memory _toPlayer, string memory _cardId) public onlyAuthority {
         require(hasAsset(_fromPlayer, _cardId), "Erro de Auditoria: O jogador de origem nao possui o ativo.");
 
@@ -71,11 +107,48 @@ contract JokenpoLedger {
         emit AuditTrade(block.timestamp, _fromPlayer, _toPlayer, _cardId);
     }
 
+    // 2b. Registrar Troca Dupla (atômica)
+    // Ex: "As 3h o jogador A trocou a carta X pela carta Y do jogador B"
+    // Confere as duas posses antes de transferir: se uma falhar, a transação inteira reverte.
+    function logSwap(string memory _playerA, string memory _playerB, string memory _cardA, string memory _cardB) public onlyAuthority {
+        require(hasAsset(_playerA, _cardA), "Erro de Auditoria: O jogador A nao possui o ativo.");
+        require(hasAsset(_playerB, _cardB), "Erro de Auditoria: O jogador B nao possui o ativo.");
+
+        removeAsset(_playerA, _cardA);
+        removeAsset(_playerB, _cardB);
+        ownerAssets[_playerB].push(_cardA);
+        ownerAssets[_playerA].push(_cardB);
+
+        emit AuditSwap(block.timestamp, _playerA, _playerB, _cardA, _cardB);
+    }
+
+    // 2c. Registrar Troca de Pacotes (atômica)
+    // Ex: "As 3h o jogador A deu as cartas X e Y ao jogador B em troca da carta Z"
+    // Confere a posse de todas as cartas; se qualquer uma faltar, nada é transferido.
+    function logBundleSwap(string memory _playerA, string memory _playerB, string[] memory _cardsA, string[] memory _cardsB) public onlyAuthority {
+        for (uint i = 0; i < _cardsA.length; i++) {
+            require(hasAsset(_playerA, _cardsA[i]), "Erro de Auditoria: O jogador A nao possui o ativo.");
+            removeAsset(_playerA, _cardsA[i]);
+        }
+        for (uint i = 0; i < _cardsB.length; i++) {
+            require(hasAsset(_playerB, _cardsB[i]), "Erro de Auditoria: O jogador B nao possui o ativo.");
+            removeAsset(_playerB, _cardsB[i]);
+        }
+        for (uint i = 0; i < _cardsA.length; i++) {
+            ownerAssets[_playerB].push(_cardsA[i]);
+        }
+        for (uint i = 0; i < _cardsB.length; i++) {
+            ownerAssets[_playerA].push(_cardsB[i]);
+        }
+
+        emit AuditBundleSwap(block.timestamp, _playerA, _playerB, _cardsA, _cardsB);
+    }
+
     // 3. Registrar Partida
-    // Ex: "As 4h o jogador B ganhou uma partida do jogador A"
-    function logMatchResult(string memory _roomId, string memory _winnerId, string memory _loserId) public onlyAuthority {
+    // Ex: "As 4h o jogador B ganhou uma partida do jogador A porque A desistiu"
+    function logMatchResult(string memory _roomId, string memory _winnerId, string memory _loserId, string memory _reason) public onlyAuthority {
         // Aqui não mudamos posse de cartas, apenas registramos o fato histórico.
-        emit AuditMatch(block.timestamp, _roomId, _winnerId, _loserId);
+        emit AuditMatch(block.timestamp, _roomId, _winnerId, _loserId, _reason);
     }
 
     // ============================================================
@@ -0,0 +1,94 @@
+//START OF FILE jokenpo/internal/game/card/ability.go
+package card
+
+import "fmt"
+
+// Momentos em que uma habilidade é disparada durante a rodada.
+const (
+	TriggerOnPlay = "on_play" // Ao ser revelada, antes da comparação
+	TriggerOnWin  = "on_win"  // Quando a carta vence a rodada
+	TriggerOnLose = "on_lose" // Quando a carta perde a rodada
+)
+
+// Efeitos conhecidos.
+const (
+	EffectValueBonus = "value_bonus" // Soma 'amount' ao valor (opcionalmente só contra a cor 'color')
+	EffectDraw       = "draw"        // O dono compra 'amount' cartas
+	EffectBanish     = "banish"      // A carta jogada pelo oponente vai para OUT em vez de WIN
+)
+
+// AbilityDefinition descreve uma habilidade no arquivo de catálogo.
+type AbilityDefinition struct {
+	Trigger string `json:"trigger"`
+	Effect  string `json:"effect"`
+	Amount  int    `json:"amount,omitempty"`
+	Color   string `json:"color,omitempty"`
+}
+
+// Ability é a versão validada e imutável de uma AbilityDefinition.
+type Ability struct {
+	trigger string
+	effect  string
+	amount  int
+	color   string
+}
+
+func (a Ability) Trigger() string { return a.trigger }
+func (a Ability) Effect() string  { return a.effect }
+func (a Ability) Amount() int     { return a.amount }
+func (a Ability) Color() string   { return a.color }
+
+func newAbility(def AbilityDefinition, rules catalogRules) (Ability, error) {
+	a := Ability{trigger: def.Trigger, effect: def.Effect, amount: def.Amount, color: def.Color}
+
+	switch a.trigger {
+	case TriggerOnPlay, TriggerOnWin, TriggerOnLose:
+	default:
+		return Ability{}, fmt.Errorf("invalid ability trigger: %s", a.trigger)
+	}
+
+	switch a.effect {
+	case EffectValueBonus:
+		// O bônus altera a comparação, então só faz sentido antes dela.
+		if a.trigger != TriggerOnPlay {
+			return Ability{}, fmt.Errorf("ability '%s' must use trigger '%s'", a.effect, TriggerOnPlay)
+		}
+		if a.amount == 0 {
+			return Ability{}, fmt.Errorf("ability '%s' requires a non-zero amount", a.effect)
+		}
+		if a.color != "" {
+			if _, ok := rules.colors[a.color]; !ok {
+				return Ability{}, fmt.Errorf("ability '%s' uses unknown color: %s", a.effect, a.color)
+			}
+		}
+	case EffectDraw:
+		if a.amount < 1 {
+			return Ability{}, fmt.Errorf("ability '%s' requires amount >= 1", a.effect)
+		}
+	case EffectBanish:
+		if a.amount != 0 || a.color != "" {
+			return Ability{}, fmt.Errorf("ability '%s' takes no amount or color", a.effect)
+		}
+	default:
+		return Ability{}, fmt.Errorf("invalid ability effect: %s", a.effect)
+	}
+	return a, nil
+}
+
+// String descreve a habilidade em texto curto (ex: "on_play: +2 value vs red").
+func (a Ability) String() string {
+	switch a.effect {
+	case EffectValueBonus:
+		if a.color != "" {
+			return fmt.Sprintf("%s: %+d value vs %s", a.trigger, a.amount, a.color)
+		}
+		return fmt.Sprintf("%s: %+d value", a.trigger, a.amount)
+	case EffectDraw:
+		return fmt.Sprintf("%s: draw %d", a.trigger, a.amount)
+	case EffectBanish:
+		return fmt.Sprintf("%s: opponent's card goes to OUT", a.trigger)
+	}
+	return fmt.Sprintf("%s: %s", a.trigger, a.effect)
+}
+
+//END OF FILE jokenpo/internal/game/card/ability.go
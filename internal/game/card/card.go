@@ -2,13 +2,18 @@
 package card
 
 import (
-	
+	"fmt"
 )
 
 type Card struct {
-	typo string
-	value uint8
-	color string
+	id        string
+	typo      string
+	value     uint8
+	color     string
+	rarity    string
+	set       string
+	name      string
+	abilities []Ability
 }
 
 
@@ -17,32 +22,59 @@ func (c *Card) Typo() string  { return c.typo }
 func (c *Card) Value() uint8  { return c.value }
 func (c *Card) Color() string { return c.color }
 
-func (c *Card) Key() string { return CardKey(c.typo,c.value,c.color) }
+func (c *Card) Rarity() string      { return c.rarity }
+func (c *Card) Set() string         { return c.set }
+func (c *Card) DisplayName() string { return c.name }
+
+// Abilities retorna as habilidades da carta, na ordem do catálogo.
+// A ordem importa: é ela que define a ordem de resolução na pilha de efeitos.
+func (c *Card) Abilities() []Ability { return c.abilities }
+
+// Key retorna o identificador da carta no catálogo (ex: "rock:5:red").
+// É essa chave que trafega entre os serviços e na blockchain.
+func (c *Card) Key() string { return c.id }
 
 
 
 // ---- Construtor ----
 
-func newCard(typo string, value uint8, color string) (*Card, error) {
-	card := &Card{typo: typo, value: value, color: color}
+func newCard(def CardDefinition, rules catalogRules) (*Card, error) {
+	card := &Card{
+		id:     def.ID,
+		typo:   def.Type,
+		value:  def.Value,
+		color:  def.Color,
+		rarity: def.Rarity,
+		set:    def.Set,
+		name:   def.DisplayName,
+	}
 
 	validators := []cardValidator{
 		validateTypo,
 		validateValue,
 		validateColor,
+		validateRarity,
 	}
 
 	for _, v := range validators {
-		if err := v(card); err != nil {
+		if err := v(card, rules); err != nil {
 			return nil, err
 		}
 	}
 
+	for i, abilityDef := range def.Abilities {
+		ability, err := newAbility(abilityDef, rules)
+		if err != nil {
+			return nil, fmt.Errorf("ability %d: %w", i, err)
+		}
+		card.abilities = append(card.abilities, ability)
+	}
+
 	return card, nil
 }
 
 func (c *Card) String() string {
-	return CardKey(c.typo,c.value,c.color)
+	return c.id
 }
 
-//END OF FILE jokenpo/internal/game/card/card.go
\ No newline at end of file
+//END OF FILE jokenpo/internal/game/card/card.go
@@ -2,35 +2,171 @@
 package card
 
 import (
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"log"
+	"os"
+	"sort"
 )
 
-var allCards map[string]*Card
+// CatalogPathEnv é a variável de ambiente que aponta para um arquivo de catálogo.
+// Se não estiver definida, usamos o catálogo base embutido no binário.
+const CatalogPathEnv = "CARD_CATALOG_PATH"
 
+//go:embed catalog_default.json
+var defaultCatalogData []byte
+
+// Catalog é o conjunto imutável de cartas carregado de um arquivo de definição.
+type Catalog struct {
+	name          string
+	schemaVersion int
+	types         []string
+	colors        []string
+	cards         map[string]*Card
+	ordered       []*Card
+	fingerprint   string
+}
+
+var globalCatalog *Catalog
+
+// InitGlobalCatalog carrega o catálogo global. Usa o arquivo indicado por
+// CARD_CATALOG_PATH ou, na falta dele, o catálogo base embutido.
 func InitGlobalCatalog() error {
-	allCards = make(map[string]*Card)
-
-	for t := range allowedTypes {
-		for c := range allowedColors {
-			for v := 1; v <= 10; v++ {
-				card, err := newCard(t, uint8(v), c)
-				if err != nil {
-					return err
-				}
-				allCards[CardKey(t, uint8(v), c)] = card
-			}
-		}
+	var (
+		catalog *Catalog
+		err     error
+	)
+	if path := os.Getenv(CatalogPathEnv); path != "" {
+		catalog, err = LoadCatalogFile(path)
+	} else {
+		catalog, err = NewCatalogFromJSON(defaultCatalogData)
+	}
+	if err != nil {
+		return err
 	}
+	globalCatalog = catalog
+	log.Printf("[Catalog] Loaded catalog '%s' (schema v%d) with %d cards. Fingerprint: %s", catalog.name, catalog.schemaVersion, len(catalog.ordered), catalog.fingerprint)
 	return nil
 }
 
-// acesso público ao catálogo
-func GetCard(key string) (*Card, error) {
-	//key := CardKey(typo, value, color) typo string, value uint8, color string
-	if card, ok := allCards[key]; ok {
+// LoadCatalogFile lê e valida um arquivo de catálogo do disco.
+func LoadCatalogFile(path string) (*Catalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalog file '%s': %w", path, err)
+	}
+	catalog, err := NewCatalogFromJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid catalog file '%s': %w", path, err)
+	}
+	return catalog, nil
+}
+
+// NewCatalogFromJSON constrói um catálogo a partir do conteúdo JSON de uma definição.
+func NewCatalogFromJSON(data []byte) (*Catalog, error) {
+	def, err := ParseCatalogDefinition(data)
+	if err != nil {
+		return nil, err
+	}
+	return NewCatalog(def)
+}
+
+// NewCatalog constrói um catálogo a partir de uma definição já validada.
+func NewCatalog(def *CatalogDefinition) (*Catalog, error) {
+	rules := newCatalogRules(def.Types, def.Colors)
+	catalog := &Catalog{
+		name:          def.Name,
+		schemaVersion: def.SchemaVersion,
+		types:         append([]string(nil), def.Types...),
+		colors:        append([]string(nil), def.Colors...),
+		cards:         make(map[string]*Card, len(def.Cards)),
+		ordered:       make([]*Card, 0, len(def.Cards)),
+	}
+	for i, cardDef := range def.Cards {
+		c, err := newCard(cardDef, rules)
+		if err != nil {
+			return nil, fmt.Errorf("card %d ('%s'): %w", i, cardDef.ID, err)
+		}
+		catalog.cards[c.Key()] = c
+		catalog.ordered = append(catalog.ordered, c)
+	}
+
+	fingerprint, err := computeFingerprint(def)
+	if err != nil {
+		return nil, err
+	}
+	catalog.fingerprint = fingerprint
+	return catalog, nil
+}
+
+// computeFingerprint gera um hash estável do conteúdo do catálogo.
+// As cartas são ordenadas pelo ID para que reordenar o arquivo não mude o resultado;
+// qualquer mudança de conteúdo (nova carta, valor, raridade...) muda o fingerprint.
+func computeFingerprint(def *CatalogDefinition) (string, error) {
+	canonical := *def
+	canonical.Cards = append([]CardDefinition(nil), def.Cards...)
+	sort.Slice(canonical.Cards, func(i, j int) bool {
+		return canonical.Cards[i].ID < canonical.Cards[j].ID
+	})
+
+	data, err := json.Marshal(canonical)
+	if err != nil {
+		return "", fmt.Errorf("failed to compute catalog fingerprint: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+func (cat *Catalog) Name() string       { return cat.name }
+func (cat *Catalog) SchemaVersion() int { return cat.schemaVersion }
+func (cat *Catalog) Types() []string    { return cat.types }
+func (cat *Catalog) Colors() []string   { return cat.colors }
+
+// Fingerprint identifica o conteúdo do catálogo. Dois serviços só falam
+// a mesma "língua" de chaves de carta se os fingerprints forem iguais.
+func (cat *Catalog) Fingerprint() string { return cat.fingerprint }
+
+// Cards retorna todas as cartas na ordem em que aparecem no arquivo.
+func (cat *Catalog) Cards() []*Card { return cat.ordered }
+
+// GetCard busca uma carta pela chave neste catálogo.
+func (cat *Catalog) GetCard(key string) (*Card, error) {
+	if card, ok := cat.cards[key]; ok {
 		return card, nil
 	}
 	return nil, fmt.Errorf("card not found: %s", key)
 }
 
-//END OF FILE jokenpo/internal/game/card/catalog.go
\ No newline at end of file
+// Global retorna o catálogo global carregado por InitGlobalCatalog.
+func Global() *Catalog {
+	return globalCatalog
+}
+
+// Fingerprint retorna o fingerprint do catálogo global ("" se não inicializado).
+func Fingerprint() string {
+	if globalCatalog == nil {
+		return ""
+	}
+	return globalCatalog.fingerprint
+}
+
+// acesso público ao catálogo
+func GetCard(key string) (*Card, error) {
+	if globalCatalog == nil {
+		return nil, fmt.Errorf("card catalog not initialized")
+	}
+	return globalCatalog.GetCard(key)
+}
+
+// AllCards retorna todas as cartas do catálogo global.
+func AllCards() []*Card {
+	if globalCatalog == nil {
+		return nil
+	}
+	return globalCatalog.ordered
+}
+
+//END OF FILE jokenpo/internal/game/card/catalog.go
@@ -22,6 +22,9 @@ func (ci *CardInstance) String() string {
         return "<nil card instance>"
     }
     // Retorna algo como: "[x2] Rock:5:Red"
+    if abilities := ci.card.Abilities(); len(abilities) > 0 {
+        return fmt.Sprintf("[x%d] %s %v", ci.count, ci.card, abilities)
+    }
     return fmt.Sprintf("[x%d] %s", ci.count, ci.card)
 }
 
@@ -124,6 +127,16 @@ func (pc *PlayerCollection) GetCard(key string) (*Card, error) {
 	return instance.Card(), nil
 }
 
+// Counts retorna uma cópia da coleção no formato chave -> número de cópias.
+// Usado para persistir a coleção fora da memória.
+func (pc *PlayerCollection) Counts() map[string]uint {
+	out := make(map[string]uint, len(pc.collection))
+	for key, instance := range pc.collection {
+		out[key] = instance.count
+	}
+	return out
+}
+
 // String implementa a interface fmt.Stringer para PlayerCollection.
 func (pc *PlayerCollection) String() string {
 	if len(pc.collection) == 0 {
@@ -0,0 +1,128 @@
+//START OF FILE jokenpo/internal/game/card/definition.go
+package card
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// CatalogSchemaVersion é a versão do formato de arquivo que este binário sabe ler.
+// Arquivos com outra versão são rejeitados para evitar interpretações erradas.
+const CatalogSchemaVersion = 1
+
+// Raridades conhecidas, da mais comum para a mais rara.
+const (
+	RarityCommon    = "common"
+	RarityUncommon  = "uncommon"
+	RarityRare      = "rare"
+	RarityLegendary = "legendary"
+)
+
+var rarityRank = map[string]int{
+	RarityCommon:    0,
+	RarityUncommon:  1,
+	RarityRare:      2,
+	RarityLegendary: 3,
+}
+
+// CatalogDefinition é o formato do arquivo de catálogo (JSON).
+type CatalogDefinition struct {
+	SchemaVersion int              `json:"schemaVersion"`
+	Name          string           `json:"name"`
+	Types         []string         `json:"types"`
+	Colors        []string         `json:"colors"`
+	Cards         []CardDefinition `json:"cards"`
+}
+
+// CardDefinition descreve uma única carta do catálogo.
+// Se o ID for omitido, usamos a chave clássica "tipo:valor:cor".
+type CardDefinition struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"`
+	Value       uint8  `json:"value"`
+	Color       string `json:"color"`
+	Rarity      string `json:"rarity"`
+	Set         string `json:"set"`
+	DisplayName string `json:"displayName"`
+	// Abilities é opcional: cartas sem habilidades são "vanilla".
+	Abilities []AbilityDefinition `json:"abilities,omitempty"`
+}
+
+// ParseCatalogDefinition decodifica e valida o conteúdo de um arquivo de catálogo.
+func ParseCatalogDefinition(data []byte) (*CatalogDefinition, error) {
+	var def CatalogDefinition
+	if err := json.Unmarshal(data, &def); err != nil {
+		return nil, fmt.Errorf("failed to parse catalog definition: %w", err)
+	}
+	if err := def.validate(); err != nil {
+		return nil, err
+	}
+	return &def, nil
+}
+
+func (d *CatalogDefinition) validate() error {
+	if d.SchemaVersion != CatalogSchemaVersion {
+		return fmt.Errorf("unsupported catalog schema version %d (supported: %d)", d.SchemaVersion, CatalogSchemaVersion)
+	}
+	if err := validateNameList("types", d.Types); err != nil {
+		return err
+	}
+	if err := validateNameList("colors", d.Colors); err != nil {
+		return err
+	}
+	if len(d.Cards) == 0 {
+		return fmt.Errorf("catalog '%s' has no cards", d.Name)
+	}
+
+	seen := make(map[string]int, len(d.Cards))
+	for i := range d.Cards {
+		c := &d.Cards[i]
+		if c.ID == "" {
+			c.ID = CardKey(c.Type, c.Value, c.Color)
+		}
+		// O '#' separa a chave da carta do UUID do token na blockchain.
+		if strings.ContainsAny(c.ID, "# ") {
+			return fmt.Errorf("card %d: id '%s' must not contain '#' or spaces", i, c.ID)
+		}
+		if first, dup := seen[c.ID]; dup {
+			return fmt.Errorf("card %d: duplicated id '%s' (first defined at card %d)", i, c.ID, first)
+		}
+		seen[c.ID] = i
+		if c.Set == "" {
+			c.Set = d.Name
+		}
+		if c.DisplayName == "" {
+			c.DisplayName = c.ID
+		}
+	}
+	return nil
+}
+
+func validateNameList(field string, names []string) error {
+	if len(names) == 0 {
+		return fmt.Errorf("catalog field '%s' must not be empty", field)
+	}
+	seen := make(map[string]struct{}, len(names))
+	for _, n := range names {
+		if n == "" {
+			return fmt.Errorf("catalog field '%s' contains an empty name", field)
+		}
+		if _, dup := seen[n]; dup {
+			return fmt.Errorf("catalog field '%s' contains duplicated name '%s'", field, n)
+		}
+		seen[n] = struct{}{}
+	}
+	return nil
+}
+
+// RarityRank retorna a posição da raridade na escala (0 = comum).
+// Raridades desconhecidas retornam -1.
+func RarityRank(rarity string) int {
+	if rank, ok := rarityRank[rarity]; ok {
+		return rank
+	}
+	return -1
+}
+
+//END OF FILE jokenpo/internal/game/card/definition.go
@@ -11,50 +11,59 @@ func CardKey (typo string, value uint8, color string) string{
 }
 
 // Tipo para funções de validação
-type cardValidator func(*Card) error
+type cardValidator func(*Card, catalogRules) error
 
-// Lista de tipos permitidos
-var allowedTypes = map[string]struct{}{
-	"rock":    {},
-	"paper":   {},
-	"scissor": {},
+// catalogRules guarda os tipos e cores declarados pelo arquivo de catálogo.
+// As cartas só são válidas se usarem valores presentes nessas listas.
+type catalogRules struct {
+	types  map[string]struct{}
+	colors map[string]struct{}
 }
 
-// Lista de cores permitidas
-var allowedColors = map[string]struct{}{
-	"red":   {},
-	"blue":  {},
-	"green": {},
+func newCatalogRules(types, colors []string) catalogRules {
+	rules := catalogRules{
+		types:  make(map[string]struct{}, len(types)),
+		colors: make(map[string]struct{}, len(colors)),
+	}
+	for _, t := range types {
+		rules.types[t] = struct{}{}
+	}
+	for _, c := range colors {
+		rules.colors[c] = struct{}{}
+	}
+	return rules
 }
 
-var (
-	CardTypes  = []string{"rock", "paper", "scissor"}
-	CardColors = []string{"red", "green", "blue"}
-)
-
 // ---- Funções de validação ----
 
-func validateTypo(c *Card) error {
-	if _, ok := allowedTypes[c.typo]; !ok {
+func validateTypo(c *Card, rules catalogRules) error {
+	if _, ok := rules.types[c.typo]; !ok {
 		return fmt.Errorf("invalid card type: %s", c.typo)
 	}
 	return nil
 }
 
-func validateValue(c *Card) error {
+func validateValue(c *Card, _ catalogRules) error {
 	if c.value == 0 || c.value > 10 {
 		return fmt.Errorf("invalid card value: %d (must be 1–10)", c.value)
 	}
 	return nil
 }
 
-func validateColor(c *Card) error {
-	if _, ok := allowedColors[c.color]; !ok {
+func validateColor(c *Card, rules catalogRules) error {
+	if _, ok := rules.colors[c.color]; !ok {
 		return fmt.Errorf("invalid card color: %s", c.color)
 	}
 	return nil
 }
 
+func validateRarity(c *Card, _ catalogRules) error {
+	if _, ok := rarityRank[c.rarity]; !ok {
+		return fmt.Errorf("invalid card rarity: %s", c.rarity)
+	}
+	return nil
+}
+
 func SliceOfCardsToString(cards []*Card) string {
 
 	if cards == nil || len(cards) == 0 {
@@ -71,4 +80,4 @@ func SliceOfCardsToString(cards []*Card) string {
 	return strings.TrimSuffix(sb.String(), "\n")
 }
 
-//END OF FILE jokenpo/internal/game/card/utils.go
\ No newline at end of file
+//END OF FILE jokenpo/internal/game/card/utils.go
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"jokenpo/internal/game/card"
+	"jokenpo/internal/game/rules"
 	"math/rand/v2"
 	"strings"
 )
@@ -128,40 +129,14 @@ func (d *Deck) ResolvePlay(won bool) (string, error) {
 	return string, nil
 }
 
-func (d *Deck) WinCondition() bool {
+// WinCondition verifica se a pilha de vitórias satisfaz a condição de vitória do ruleset da partida.
+func (d *Deck) WinCondition(rs rules.Ruleset) bool {
 	win := d.zones["win"]
 
 	if win == nil || len(*win) == 0 {
 		return false
 	}
-
-	// Contadores para cores e tipos
-	colorCount := map[string]int{}
-	typeCount := map[string]int{}
-
-	for _, c := range *win {
-		colorCount[c.Color()]++
-		typeCount[c.Typo()]++
-	}
-
-	// Verifica cores: 3 de qualquer cor
-	for _, count := range colorCount {
-		if count >= 3 {
-			return true
-		}
-	}
-
-	// Verifica tipos: 1 rock+1 paper+1 scissor ou 3 de qualquer tipo
-	if typeCount["rock"] >= 1 && typeCount["paper"] >= 1 && typeCount["scissor"] >= 1 {
-		return true
-	}
-	for _, count := range typeCount {
-		if count >= 3 {
-			return true
-		}
-	}
-
-	return false
+	return rs.HasWon(*win)
 }
 
 // AddCardToZone adiciona uma única carta a uma zona específica.
@@ -220,12 +195,7 @@ func (d *Deck) String() string {
 	var sb strings.Builder
 	sb.WriteString("\n================== DECK ==================\n")
 	
-	cards, _ :=d.GetCardsInZone(DECK)
-	var deckPower int
-	for i := 0; i < len(cards); i++ {
-		deckPower += int(cards[i].Value())
-	}
-	deckPowerString := fmt.Sprintf("\nDeck Power = %d\n", deckPower)
+	deckPowerString := fmt.Sprintf("\nDeck Power = %d\n", d.Power())
 
 	sb.WriteString(deckPowerString)
 
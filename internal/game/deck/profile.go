@@ -0,0 +1,52 @@
+// START OF FILE jokenpo/internal/game/deck/profile.go
+package deck
+
+import (
+	"jokenpo/internal/game/card"
+)
+
+// Profile resume a força de um deck para o matchmaking. Power é a soma dos
+// valores das cartas (o mesmo "Deck Power" do Deck.String, limitado a 80 pelo inventário).
+type Profile struct {
+	Power    int            `json:"power"`
+	Cards    int            `json:"cards"`
+	Types    map[string]int `json:"types,omitempty"`    // Cartas por tipo (rock, paper, scissors)
+	Rarities map[string]int `json:"rarities,omitempty"` // Cartas por raridade
+}
+
+// NewProfile calcula o perfil de uma lista de cartas.
+func NewProfile(cards []*card.Card) Profile {
+	p := Profile{
+		Cards:    len(cards),
+		Types:    make(map[string]int),
+		Rarities: make(map[string]int),
+	}
+	for _, c := range cards {
+		p.Power += int(c.Value())
+		p.Types[c.Typo()]++
+		p.Rarities[c.Rarity()]++
+	}
+	return p
+}
+
+// ProfileFromKeys calcula o perfil a partir das chaves das cartas, como elas
+// trafegam entre os serviços. Falha se alguma chave não existir no catálogo.
+func ProfileFromKeys(keys []string) (Profile, error) {
+	cards := make([]*card.Card, 0, len(keys))
+	for _, key := range keys {
+		c, err := card.GetCard(key)
+		if err != nil {
+			return Profile{}, err
+		}
+		cards = append(cards, c)
+	}
+	return NewProfile(cards), nil
+}
+
+// Power é a soma dos valores das cartas na zona DECK.
+func (d *Deck) Power() int {
+	cards, _ := d.GetCardsInZone(DECK)
+	return NewProfile(cards).Power
+}
+
+//END OF FILE jokenpo/internal/game/deck/profile.go
@@ -0,0 +1,165 @@
+// START OF FILE jokenpo/internal/game/deck/shuffle.go
+package deck
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"jokenpo/internal/game/card"
+	mrand "math/rand/v2"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// EntropySize é o tamanho (em bytes) da contribuição de cada jogador para a semente.
+const EntropySize = 32
+
+// ShuffleSeed é a semente combinada de uma partida. A sala publica o hash dela
+// no GAME_START e a revela no GAME_OVER, junto das contribuições dos jogadores.
+type ShuffleSeed [32]byte
+
+// ShuffleProof é o que a sala revela no fim da partida para provar o embaralhamento.
+type ShuffleProof struct {
+	RoomID     string   `json:"roomId"`
+	Entropies  []string `json:"entropies"` // Contribuições em hex, na ordem dos assentos
+	Seed       string   `json:"seed"`
+	Commitment string   `json:"commitment"`
+}
+
+// NewEntropy gera uma contribuição aleatória (hex) para a semente da partida.
+func NewEntropy() (string, error) {
+	var b [EntropySize]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate shuffle entropy: %w", err)
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+// CombineEntropy junta as contribuições (na ordem dos assentos) com o ID da sala.
+// Nenhum dos lados escolhe a semente sozinho: basta uma contribuição honesta.
+// O ID da sala é fixado antes de a sala ver as contribuições (o Queue o manda no
+// pedido de criação; na revanche ele vem de RematchRoomID), senão a sala poderia
+// sortear IDs até achar uma semente que a agradasse.
+func CombineEntropy(roomID string, entropies []string) (ShuffleSeed, error) {
+	h := sha256.New()
+	h.Write([]byte(roomID))
+	h.Write([]byte{0}) // separa o ID (tamanho variável) das contribuições (tamanho fixo)
+	for i, e := range entropies {
+		raw, err := hex.DecodeString(e)
+		if err != nil || len(raw) != EntropySize {
+			return ShuffleSeed{}, fmt.Errorf("entropy %d must be %d bytes in hex", i, EntropySize)
+		}
+		h.Write(raw)
+	}
+	var seed ShuffleSeed
+	copy(seed[:], h.Sum(nil))
+	return seed, nil
+}
+
+// RematchRoomID é o ID da sala da revanche de roomID. É derivado do ID anterior,
+// então já está fixado quando os pedidos de revanche trazem a entropia nova.
+func RematchRoomID(roomID string) string {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte("jokenpo:rematch:"+roomID)).String()
+}
+
+func (s ShuffleSeed) String() string { return hex.EncodeToString(s[:]) }
+
+// Commitment é o hash publicado antes do jogo começar.
+func (s ShuffleSeed) Commitment() string {
+	sum := sha256.Sum256(s[:])
+	return hex.EncodeToString(sum[:])
+}
+
+// RNG deriva um gerador independente para um fluxo (ex: o ID de um jogador).
+// Cada deck usa o próprio fluxo, então a ordem dos assentos não muda o resultado.
+func (s ShuffleSeed) RNG(stream string) *mrand.Rand {
+	h := sha256.New()
+	h.Write(s[:])
+	h.Write([]byte(stream))
+	var chachaSeed [32]byte
+	copy(chachaSeed[:], h.Sum(nil))
+	return mrand.New(mrand.NewChaCha8(chachaSeed))
+}
+
+// ShuffleWithSeed embaralha a zona DECK com o fluxo do jogador.
+func (d *Deck) ShuffleWithSeed(seed ShuffleSeed, playerID string) {
+	d.Shuffle(DECK, seed.RNG(playerID))
+}
+
+// SeriesStream é o fluxo do embaralhamento de um jogador no jogo 'game' de uma
+// série. O primeiro jogo usa o próprio ID, como numa partida única; os seguintes
+// ganham um fluxo próprio, senão repetiriam a ordem do primeiro.
+func SeriesStream(playerID string, game int) string {
+	if game <= 1 {
+		return playerID
+	}
+	return fmt.Sprintf("%s#%d", playerID, game)
+}
+
+// ResetForNextGame devolve todas as cartas ao DECK e as põe em ordem de chave.
+// O ResetToDeck sozinho deixa a ordem das zonas ao acaso; partindo de uma ordem
+// canônica, a sessão consegue refazer o embaralhamento do jogo seguinte.
+func (d *Deck) ResetForNextGame() {
+	d.ResetToDeck()
+	pile := d.zones[DECK]
+	slices.SortStableFunc(*pile, func(a, b *card.Card) int {
+		return strings.Compare(a.Key(), b.Key())
+	})
+}
+
+// ReplayDrawOrder monta o deck na ordem enviada para a sala, refaz o embaralhamento
+// e devolve as chaves na ordem em que seriam compradas.
+func ReplayDrawOrder(seed ShuffleSeed, playerID string, deckKeys []string) ([]string, error) {
+	d := NewDeck()
+	for _, key := range deckKeys {
+		c, err := card.GetCard(key)
+		if err != nil {
+			return nil, err
+		}
+		d.AddCardToZone(DECK, c)
+	}
+	d.ShuffleWithSeed(seed, playerID)
+
+	order, _ := d.GetCardsInZone(DECK)
+	keys := make([]string, len(order))
+	for i, c := range order {
+		keys[i] = c.Key()
+	}
+	return keys, nil
+}
+
+// VerifyShuffle confere uma prova revelada no GAME_OVER:
+//  1. a semente é a combinação das contribuições reveladas;
+//  2. o hash da semente é o compromisso publicado no GAME_START (proof.Commitment);
+//  3. as cartas compradas pelo jogador (na ordem) são o início da ordem refeita.
+func VerifyShuffle(proof ShuffleProof, playerID string, deckKeys []string, drawn []string) error {
+	seed, err := CombineEntropy(proof.RoomID, proof.Entropies)
+	if err != nil {
+		return err
+	}
+	if seed.String() != proof.Seed {
+		return fmt.Errorf("revealed seed %s is not the combination of the revealed entropies", proof.Seed)
+	}
+	if seed.Commitment() != proof.Commitment {
+		return fmt.Errorf("seed does not match the commitment published at game start")
+	}
+
+	order, err := ReplayDrawOrder(seed, playerID, deckKeys)
+	if err != nil {
+		return err
+	}
+	if len(drawn) > len(order) {
+		return fmt.Errorf("drew %d cards from a deck of %d", len(drawn), len(order))
+	}
+	for i, key := range drawn {
+		if order[i] != key {
+			return fmt.Errorf("draw %d: got %s, shuffle gives %s", i+1, key, order[i])
+		}
+	}
+	return nil
+}
+
+//END OF FILE jokenpo/internal/game/deck/shuffle.go
@@ -132,6 +132,20 @@ func (i *Inventory) HasCardInCollection(cardKey string, num uint) error {
 	return nil
 }
 
+// ValidateDeck checks a full deck list (e.g. a sideboarded deck between games of
+// a series) against the same rules used when building the game deck.
+func (i *Inventory) ValidateDeck(keys []string) error {
+	cards := make([]*card.Card, 0, len(keys))
+	for _, key := range keys {
+		c, err := i.collection.GetCard(key)
+		if err != nil {
+			return err
+		}
+		cards = append(cards, c)
+	}
+	return validateDeckState(cards, i.collection)
+}
+
 // --- Rule 1: Deck Size Validation ---
 // validateDeckSize checks if the number of cards in a deck exceeds the maximum limit.
 func validateDeckSize(deck []*card.Card) error {
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"jokenpo/internal/game/card"
 	"jokenpo/internal/game/deck"
+	"jokenpo/internal/game/rules"
 	"math/rand/v2"
 )
 
@@ -68,11 +69,11 @@ func (p *Player) SeeHand() (string, error) {
 	return p.inventory.GameDeck().ZoneString(deck.HAND)
 }
 
-func (p *Player) WinCondition() (bool, error) {
+func (p *Player) WinCondition(rs rules.Ruleset) (bool, error) {
 	if p.state != PLAY {
 		return false, fmt.Errorf("error: Player must be in PLAY state to check win condition")
 	}
-	return p.inventory.GameDeck().WinCondition(), nil
+	return p.inventory.GameDeck().WinCondition(rs), nil
 }
 
 // HasNoMoreMoves verifica se o jogador não tem mais cartas no deck nem na mão.
@@ -0,0 +1,141 @@
+//START OF FILE jokenpo/internal/game/rating/glicko2.go
+package rating
+
+import (
+	"math"
+	"time"
+)
+
+// Parâmetros do Glicko-2 (Glickman, "Example of the Glicko-2 system").
+const (
+	DefaultRating     = 1500.0
+	DefaultDeviation  = 350.0
+	DefaultVolatility = 0.06
+
+	// tau limita a variação da volatilidade; valores entre 0.3 e 1.2 são razoáveis.
+	tau = 0.5
+	// glickoScale converte entre a escala Glicko (1500/350) e a escala interna do Glicko-2.
+	glickoScale = 173.7178
+	// convergence é a tolerância do algoritmo iterativo da nova volatilidade.
+	convergence = 0.000001
+)
+
+// Resultado de uma partida do ponto de vista do jogador.
+const (
+	Loss = 0.0
+	Draw = 0.5
+	Win  = 1.0
+)
+
+// Rating é a força estimada de um jogador. Deviation (RD) é a incerteza: começa
+// alta e cai conforme o jogador acumula partidas.
+type Rating struct {
+	Rating     float64   `json:"rating"`
+	Deviation  float64   `json:"deviation"`
+	Volatility float64   `json:"volatility"`
+	Games      int       `json:"games"`
+	UpdatedAt  time.Time `json:"updatedAt,omitempty"`
+}
+
+// New é o rating de um jogador que nunca jogou uma partida ranqueada.
+func New() Rating {
+	return Rating{Rating: DefaultRating, Deviation: DefaultDeviation, Volatility: DefaultVolatility}
+}
+
+// UpdateMatch aplica o resultado de uma partida aos dois jogadores, usando os
+// ratings de antes da partida. scoreA é o resultado de A (Win, Draw ou Loss).
+// Cada partida é tratada como um período de rating com um único jogo.
+func UpdateMatch(a, b Rating, scoreA float64) (Rating, Rating) {
+	now := time.Now().UTC()
+	newA := update(a, b, scoreA)
+	newB := update(b, a, 1-scoreA)
+	newA.UpdatedAt, newB.UpdatedAt = now, now
+	return newA, newB
+}
+
+// Expected é a probabilidade de A vencer B.
+func Expected(a, b Rating) float64 {
+	muA, _ := toGlicko2(a)
+	muB, phiB := toGlicko2(b)
+	return expected(muA, muB, phiB)
+}
+
+func update(player, opponent Rating, score float64) Rating {
+	mu, phi := toGlicko2(player)
+	muJ, phiJ := toGlicko2(opponent)
+	sigma := player.Volatility
+	if sigma <= 0 {
+		sigma = DefaultVolatility
+	}
+
+	gJ := g(phiJ)
+	e := expected(mu, muJ, phiJ)
+	v := 1 / (gJ * gJ * e * (1 - e))
+	delta := v * gJ * (score - e)
+
+	newSigma := newVolatility(phi, sigma, v, delta)
+	phiStar := math.Sqrt(phi*phi + newSigma*newSigma)
+	newPhi := 1 / math.Sqrt(1/(phiStar*phiStar)+1/v)
+	newMu := mu + newPhi*newPhi*gJ*(score-e)
+
+	return Rating{
+		Rating:     newMu*glickoScale + DefaultRating,
+		Deviation:  math.Min(newPhi*glickoScale, DefaultDeviation),
+		Volatility: newSigma,
+		Games:      player.Games + 1,
+	}
+}
+
+// newVolatility resolve f(x) = 0 pelo método de Illinois (passo 5 do algoritmo).
+func newVolatility(phi, sigma, v, delta float64) float64 {
+	a := math.Log(sigma * sigma)
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		num := ex * (delta*delta - phi*phi - v - ex)
+		den := 2 * (phi*phi + v + ex) * (phi*phi + v + ex)
+		return num/den - (x-a)/(tau*tau)
+	}
+
+	A := a
+	var B float64
+	if delta*delta > phi*phi+v {
+		B = math.Log(delta*delta - phi*phi - v)
+	} else {
+		k := 1.0
+		for f(a-k*tau) < 0 {
+			k++
+		}
+		B = a - k*tau
+	}
+
+	fA, fB := f(A), f(B)
+	for math.Abs(B-A) > convergence {
+		C := A + (A-B)*fA/(fB-fA)
+		fC := f(C)
+		if fC*fB <= 0 {
+			A, fA = B, fB
+		} else {
+			fA /= 2
+		}
+		B, fB = C, fC
+	}
+	return math.Exp(A / 2)
+}
+
+func toGlicko2(r Rating) (mu, phi float64) {
+	rd := r.Deviation
+	if rd <= 0 {
+		rd = DefaultDeviation
+	}
+	return (r.Rating - DefaultRating) / glickoScale, rd / glickoScale
+}
+
+func g(phi float64) float64 {
+	return 1 / math.Sqrt(1+3*phi*phi/(math.Pi*math.Pi))
+}
+
+func expected(mu, muJ, phiJ float64) float64 {
+	return 1 / (1 + math.Exp(-g(phiJ)*(mu-muJ)))
+}
+
+//END OF FILE jokenpo/internal/game/rating/glicko2.go
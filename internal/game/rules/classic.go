@@ -0,0 +1,77 @@
+//START OF FILE jokenpo/internal/game/rules/classic.go
+package rules
+
+import "jokenpo/internal/game/card"
+
+// classic é o Jokenpo original: pedra vence tesoura, tesoura vence papel,
+// papel vence pedra; cartas do mesmo tipo desempatam pelo valor.
+type classic struct {
+	winConditions map[string]string
+}
+
+func newClassic() *classic {
+	return &classic{
+		// A chave vence o valor. Ex: "rock" vence "scissor".
+		winConditions: map[string]string{
+			"rock":    "scissor",
+			"scissor": "paper",
+			"paper":   "rock",
+		},
+	}
+}
+
+func (r *classic) Name() string { return DefaultName }
+
+func (r *classic) Description() string {
+	return "Classic Jokenpo. Same type: higher value wins. Win with 3 cards of a color, 3 of a type or one of each type."
+}
+
+func (r *classic) Beats(attacker, defender string) bool {
+	return r.winConditions[attacker] == defender
+}
+
+func (r *classic) Tiebreak(c1, c2 Contender) int {
+	return compareValues(c1.Value, c2.Value)
+}
+
+func (r *classic) HasWon(win []*card.Card) bool {
+	return classicWinCondition(win)
+}
+
+// classicWinCondition: 3 cartas de uma mesma cor, 3 de um mesmo tipo
+// ou uma de cada tipo (rock + paper + scissor) na pilha de vitórias.
+func classicWinCondition(win []*card.Card) bool {
+	if len(win) == 0 {
+		return false
+	}
+
+	// Contadores para cores e tipos
+	colorCount := map[string]int{}
+	typeCount := map[string]int{}
+
+	for _, c := range win {
+		colorCount[c.Color()]++
+		typeCount[c.Typo()]++
+	}
+
+	// Verifica cores: 3 de qualquer cor
+	for _, count := range colorCount {
+		if count >= 3 {
+			return true
+		}
+	}
+
+	// Verifica tipos: 1 rock+1 paper+1 scissor ou 3 de qualquer tipo
+	if typeCount["rock"] >= 1 && typeCount["paper"] >= 1 && typeCount["scissor"] >= 1 {
+		return true
+	}
+	for _, count := range typeCount {
+		if count >= 3 {
+			return true
+		}
+	}
+
+	return false
+}
+
+//END OF FILE jokenpo/internal/game/rules/classic.go
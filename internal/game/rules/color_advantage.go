@@ -0,0 +1,43 @@
+//START OF FILE jokenpo/internal/game/rules/color_advantage.go
+package rules
+
+// colorAdvantageBonus é o valor somado à carta cuja cor vence a cor adversária.
+const colorAdvantageBonus = 2
+
+// colorAdvantage é um modo de evento: os tipos seguem o Jokenpo clássico, mas no
+// desempate as cores também brigam (vermelho > verde > azul > vermelho) e a cor
+// vantajosa ganha um bônus no valor. As condições de vitória são as clássicas.
+type colorAdvantage struct {
+	*classic
+	colorBeats map[string]string
+}
+
+func newColorAdvantage() *colorAdvantage {
+	return &colorAdvantage{
+		classic: newClassic(),
+		colorBeats: map[string]string{
+			"red":   "green",
+			"green": "blue",
+			"blue":  "red",
+		},
+	}
+}
+
+func (r *colorAdvantage) Name() string { return "color-advantage" }
+
+func (r *colorAdvantage) Description() string {
+	return "Classic matchups, but on a tie red beats green, green beats blue and blue beats red for +2 value."
+}
+
+func (r *colorAdvantage) Tiebreak(c1, c2 Contender) int {
+	value1, value2 := c1.Value, c2.Value
+	if r.colorBeats[c1.Card.Color()] == c2.Card.Color() {
+		value1 += colorAdvantageBonus
+	}
+	if r.colorBeats[c2.Card.Color()] == c1.Card.Color() {
+		value2 += colorAdvantageBonus
+	}
+	return compareValues(value1, value2)
+}
+
+//END OF FILE jokenpo/internal/game/rules/color_advantage.go
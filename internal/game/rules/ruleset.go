@@ -0,0 +1,113 @@
+//START OF FILE jokenpo/internal/game/rules/ruleset.go
+package rules
+
+import (
+	"fmt"
+	"jokenpo/internal/game/card"
+	"sort"
+)
+
+// Constantes para representar o resultado da comparação de cartas.
+// Usar constantes torna o código que utiliza esta função muito mais legível.
+const (
+	Card1Wins = 1
+	Card2Wins = -1
+	Tie       = 0
+)
+
+// DefaultName é o ruleset usado quando a sala não pede nenhum.
+const DefaultName = "classic"
+
+// Ruleset reúne todas as regras de uma batalha: quem vence quem (matchups),
+// como desempatar cartas do mesmo tipo e quando um jogador vence a partida.
+// Cada sala de jogo recebe um Ruleset; assim modos de evento não exigem
+// alterar o serviço de gameroom.
+type Ruleset interface {
+	// Name é o identificador usado em CreateRoomRequest (ex: "classic").
+	Name() string
+	// Description é um texto curto para exibir aos jogadores.
+	Description() string
+	// Beats informa se o tipo 'attacker' vence o tipo 'defender'.
+	Beats(attacker, defender string) bool
+	// Tiebreak decide o confronto quando nenhum tipo vence o outro.
+	// Retorna Card1Wins, Card2Wins ou Tie.
+	Tiebreak(c1, c2 Contender) int
+	// HasWon avalia a pilha de vitórias (zona "win") de um jogador.
+	HasWon(win []*card.Card) bool
+}
+
+// Contender é uma carta em combate. Value começa com o valor impresso na carta
+// e pode ser alterado por habilidades (ex: value_bonus) antes da comparação.
+type Contender struct {
+	Card  *card.Card
+	Value int
+}
+
+// NewContender cria um Contender com o valor impresso da carta.
+func NewContender(c *card.Card) Contender {
+	return Contender{Card: c, Value: int(c.Value())}
+}
+
+// Compare executa a lógica de batalha completa entre duas cartas.
+// Ela primeiro compara os tipos. Se nenhum tipo vencer o outro, usa o Tiebreak do ruleset.
+// Retorna uma das constantes: Card1Wins, Card2Wins, or Tie.
+func Compare(rs Ruleset, c1, c2 Contender) int {
+	if rs.Beats(c1.Card.Typo(), c2.Card.Typo()) {
+		return Card1Wins
+	}
+	if rs.Beats(c2.Card.Typo(), c1.Card.Typo()) {
+		return Card2Wins
+	}
+	return rs.Tiebreak(c1, c2)
+}
+
+var registry = map[string]Ruleset{}
+
+func register(rs Ruleset) {
+	registry[rs.Name()] = rs
+}
+
+func init() {
+	register(newClassic())
+	register(newColorAdvantage())
+}
+
+// Get busca um ruleset pelo nome. Um nome vazio retorna o ruleset padrão.
+func Get(name string) (Ruleset, error) {
+	if name == "" {
+		name = DefaultName
+	}
+	rs, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown ruleset '%s' (available: %v)", name, Names())
+	}
+	return rs, nil
+}
+
+// Default retorna o ruleset padrão, que reproduz as regras originais do jogo.
+func Default() Ruleset {
+	return registry[DefaultName]
+}
+
+// Names lista os rulesets disponíveis, em ordem alfabética.
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// compareValues é o desempate padrão: o maior valor vence.
+func compareValues(value1, value2 int) int {
+	if value1 > value2 {
+		return Card1Wins
+	}
+	if value2 > value1 {
+		return Card2Wins
+	}
+	return Tie
+}
+
+//END OF FILE jokenpo/internal/game/rules/ruleset.go
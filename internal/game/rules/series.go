@@ -0,0 +1,26 @@
+//START OF FILE jokenpo/internal/game/rules/series.go
+package rules
+
+import "fmt"
+
+// MaxBestOf é o maior formato de série aceito (melhor de 7).
+const MaxBestOf = 7
+
+// NormalizeBestOf valida o formato da série pedido na fila. Zero vira 1 (partida
+// única); o número de jogos precisa ser ímpar para a série sempre ter maioria.
+func NormalizeBestOf(bestOf int) (int, error) {
+	if bestOf == 0 {
+		return 1, nil
+	}
+	if bestOf < 1 || bestOf > MaxBestOf || bestOf%2 == 0 {
+		return 0, fmt.Errorf("invalid series format 'best of %d' (use an odd number from 1 to %d)", bestOf, MaxBestOf)
+	}
+	return bestOf, nil
+}
+
+// WinsNeeded é quantas vitórias decidem uma série melhor de bestOf.
+func WinsNeeded(bestOf int) int {
+	return bestOf/2 + 1
+}
+
+//END OF FILE jokenpo/internal/game/rules/series.go
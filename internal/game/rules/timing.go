@@ -0,0 +1,54 @@
+//START OF FILE jokenpo/internal/game/rules/timing.go
+package rules
+
+import (
+	"fmt"
+	"time"
+)
+
+// Timing é o ritmo de uma sala: o tempo de cada rodada, o banco de tempo de cada
+// jogador (relógio de xadrez: gasto só quando a rodada estoura) e a pausa entre
+// rodadas. Vem da definição do modo na fila e viaja no CreateRoomRequest.
+type Timing struct {
+	RoundMs      int64 `json:"roundMs"`
+	TimeBankMs   int64 `json:"timeBankMs,omitempty"`
+	RoundDelayMs int64 `json:"roundDelayMs"`
+}
+
+// Limites aceitos pela sala para um Timing.
+const (
+	MinRoundTime  = 500 * time.Millisecond
+	MaxRoundTime  = 5 * time.Minute
+	MaxTimeBank   = 30 * time.Minute
+	MaxRoundDelay = time.Minute
+)
+
+// DefaultTiming reproduz o ritmo original do jogo: 2s por rodada, sem banco de
+// tempo e 3s entre rodadas.
+func DefaultTiming() Timing {
+	return Timing{RoundMs: 2000, RoundDelayMs: 3000}
+}
+
+// Validate confere se o Timing está dentro dos limites.
+func (t Timing) Validate() error {
+	if t.Round() < MinRoundTime || t.Round() > MaxRoundTime {
+		return fmt.Errorf("round time must be between %v and %v, got %v", MinRoundTime, MaxRoundTime, t.Round())
+	}
+	if t.TimeBank() < 0 || t.TimeBank() > MaxTimeBank {
+		return fmt.Errorf("time bank must be between 0 and %v, got %v", MaxTimeBank, t.TimeBank())
+	}
+	if t.RoundDelay() < 0 || t.RoundDelay() > MaxRoundDelay {
+		return fmt.Errorf("round delay must be between 0 and %v, got %v", MaxRoundDelay, t.RoundDelay())
+	}
+	return nil
+}
+
+func (t Timing) Round() time.Duration      { return time.Duration(t.RoundMs) * time.Millisecond }
+func (t Timing) TimeBank() time.Duration   { return time.Duration(t.TimeBankMs) * time.Millisecond }
+func (t Timing) RoundDelay() time.Duration { return time.Duration(t.RoundDelayMs) * time.Millisecond }
+
+func (t Timing) String() string {
+	return fmt.Sprintf("round %v, bank %v, delay %v", t.Round(), t.TimeBank(), t.RoundDelay())
+}
+
+//END OF FILE jokenpo/internal/game/rules/timing.go
@@ -29,10 +29,20 @@ var (
 	_ = abi.ConvertType
 )
 
+// JokenpoLedgerPackDerivation is an auto generated low-level Go binding around an user-defined struct.
+type JokenpoLedgerPackDerivation struct {
+	Seed               [32]byte
+	PackageCounter     *big.Int
+	Pity               *big.Int
+	Template           string
+	Catalog            string
+	NextSeedCommitment [32]byte
+}
+
 // LedgerMetaData contains all meta data concerning the Ledger contract.
 var LedgerMetaData = &bind.MetaData{
-	ABI: "[{\"inputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"timestamp\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"roomId\",\"type\":\"string\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"winnerId\",\"type\":\"string\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"loserId\",\"type\":\"string\"}],\"name\":\"AuditMatch\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"timestamp\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"playerId\",\"type\":\"string\"},{\"indexed\":false,\"internalType\":\"string[]\",\"name\":\"cardIds\",\"type\":\"string[]\"}],\"name\":\"AuditPackOpened\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"timestamp\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"fromPlayer\",\"type\":\"string\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"toPlayer\",\"type\":\"string\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"cardId\",\"type\":\"string\"}],\"name\":\"AuditTrade\",\"type\":\"event\"},{\"inputs\":[],\"name\":\"gameServerAuthority\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"string\",\"name\":\"_playerId\",\"type\":\"string\"}],\"name\":\"getPlayerAssets\",\"outputs\":[{\"internalType\":\"string[]\",\"name\":\"\",\"type\":\"string[]\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"string\",\"name\":\"_roomId\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"_winnerId\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"_loserId\",\"type\":\"string\"}],\"name\":\"logMatchResult\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"string\",\"name\":\"_playerId\",\"type\":\"string\"},{\"internalType\":\"string[]\",\"name\":\"_cardIds\",\"type\":\"string[]\"}],\"name\":\"logPackOpening\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"string\",\"name\":\"_fromPlayer\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"_toPlayer\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"_cardId\",\"type\":\"string\"}],\"name\":\"logTrade\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"}]",
-	Bin: "0x6080604052348015600e575f5ffd5b50335f5f6101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff1602179055506115568061005b5f395ff3fe608060405234801561000f575f5ffd5b5060043610610055575f3560e01c80630ada582d146100595780631502cd0c1461007757806357da55ce146100a75780637908708b146100c3578063cf27ed0c146100df575b5f5ffd5b6100616100fb565b60405161006e9190610891565b60405180910390f35b610091600480360381019061008c91906109f7565b61011f565b60405161009e9190610b59565b60405180910390f35b6100c160048036038101906100bc9190610b79565b610211565b005b6100dd60048036038101906100d89190610b79565b610382565b005b6100f960048036038101906100f49190610cff565b610452565b005b5f5f9054906101000a900473ffffffffffffffffffffffffffffffffffffffff1681565b60606001826040516101319190610daf565b9081526020016040518091039020805480602002602001604051908101604052809291908181526020015f905b82821015610206578382905f5260205f2001805461017b90610df2565b80601f01602080910402602001604051908101604052809291908181526020018280546101a790610df2565b80156101f25780601f106101c9576101008083540402835291602001916101f2565b820191905f5260205f20905b8154815290600101906020018083116101d557829003601f168201915b50505050508152602001906001019061015e565b505050509050919050565b5f5f9054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff163373ffffffffffffffffffffffffffffffffffffffff161461029f576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161029690610ea2565b60405180910390fd5b6102a983826105a3565b6102e8576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016102df90610f30565b60405180910390fd5b6102f283826106f1565b6001826040516103029190610daf565b908152602001604051809103902081908060018154018082558091505060019003905f5260205f20015f90919091909150908161033f91906110f7565b507fcb6a9427f5732496720fa2f6427b1bc9a407a78d57f02a411a4f459a1d97c5c842848484604051610375949392919061120d565b60405180910390a1505050565b5f5f9054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff163373ffffffffffffffffffffffffffffffffffffffff1614610410576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161040790610ea2565b60405180910390fd5b7f459166290fcb68519a7a83e9074a5eddb1c5872f6494632588302fe07ab3ac6f42848484604051610445949392919061120d565b60405180910390a1505050565b5f5f9054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff163373ffffffffffffffffffffffffffffffffffffffff16146104e0576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016104d790610ea2565b60405180910390fd5b5f5f90505b8151811015610563576001836040516104fe9190610daf565b908152602001604051809103902082828151811061051f5761051e611265565b5b6020026020010151908060018154018082558091505060019003905f5260205f20015f90919091909150908161055591906110f7565b5080806001019150506104e5565b507f1e2592092e270aa65505d82cfc0297cf9860cc6bc5501cf9544edf647b891be142838360405161059793929190611292565b60405180910390a15050565b5f5f6001846040516105b59190610daf565b9081526020016040518091039020805480602002602001604051908101604052809291908181526020015f905b8282101561068a578382905f5260205f200180546105ff90610df2565b80601f016020809104026020016040519081016040528092919081815260200182805461062b90610df2565b80156106765780601f1061064d57610100808354040283529160200191610676565b820191905f5260205f20905b81548152906001019060200180831161065957829003601f168201915b5050505050815260200190600101906105e2565b5050505090505f5f90505b81518110156106e55783805190602001208282815181106106b9576106b8611265565b5b602002602001015180519060200120036106d8576001925050506106eb565b8080600101915050610695565b505f9150505b92915050565b5f6001836040516107029190610daf565b908152602001604051809103902090505f5f90505b81805490508110156107f357828051906020012082828154811061073e5761073d611265565b5b905f5260205f20016040516107539190611371565b6040518091039020036107e657816001838054905061077291906113b4565b8154811061078357610782611265565b5b905f5260205f200182828154811061079e5761079d611265565b5b905f5260205f200190816107b2919061140e565b50818054806107c4576107c36114f3565b5b600190038181905f5260205f20015f6107dd91906107fa565b905550506107f6565b8080600101915050610717565b50505b5050565b50805461080690610df2565b5f825580601f106108175750610834565b601f0160209004905f5260205f20908101906108339190610837565b5b50565b5b8082111561084e575f815f905550600101610838565b5090565b5f73ffffffffffffffffffffffffffffffffffffffff82169050919050565b5f61087b82610852565b9050919050565b61088b81610871565b82525050565b5f6020820190506108a45f830184610882565b92915050565b5f604051905090565b5f5ffd5b5f5ffd5b5f5ffd5b5f5ffd5b5f601f19601f8301169050919050565b7f4e487b71000000000000000000000000000000000000000000000000000000005f52604160045260245ffd5b610909826108c3565b810181811067ffffffffffffffff82111715610928576109276108d3565b5b80604052505050565b5f61093a6108aa565b90506109468282610900565b919050565b5f67ffffffffffffffff821115610965576109646108d3565b5b61096e826108c3565b9050602081019050919050565b828183375f83830152505050565b5f61099b6109968461094b565b610931565b9050828152602081018484840111156109b7576109b66108bf565b5b6109c284828561097b565b509392505050565b5f82601f8301126109de576109dd6108bb565b5b81356109ee848260208601610989565b91505092915050565b5f60208284031215610a0c57610a0b6108b3565b5b5f82013567ffffffffffffffff811115610a2957610a286108b7565b5b610a35848285016109ca565b91505092915050565b5f81519050919050565b5f82825260208201905092915050565b5f819050602082019050919050565b5f81519050919050565b5f82825260208201905092915050565b8281835e5f83830152505050565b5f610a9982610a67565b610aa38185610a71565b9350610ab3818560208601610a81565b610abc816108c3565b840191505092915050565b5f610ad28383610a8f565b905092915050565b5f602082019050919050565b5f610af082610a3e565b610afa8185610a48565b935083602082028501610b0c85610a58565b805f5b85811015610b475784840389528151610b288582610ac7565b9450610b3383610ada565b925060208a01995050600181019050610b0f565b50829750879550505050505092915050565b5f6020820190508181035f830152610b718184610ae6565b905092915050565b5f5f5f60608486031215610b9057610b8f6108b3565b5b5f84013567ffffffffffffffff811115610bad57610bac6108b7565b5b610bb9868287016109ca565b935050602084013567ffffffffffffffff811115610bda57610bd96108b7565b5b610be6868287016109ca565b925050604084013567ffffffffffffffff811115610c0757610c066108b7565b5b610c13868287016109ca565b9150509250925092565b5f67ffffffffffffffff821115610c3757610c366108d3565b5b602082029050602081019050919050565b5f5ffd5b5f610c5e610c5984610c1d565b610931565b90508083825260208201905060208402830185811115610c8157610c80610c48565b5b835b81811015610cc857803567ffffffffffffffff811115610ca657610ca56108bb565b5b808601610cb389826109ca565b85526020850194505050602081019050610c83565b5050509392505050565b5f82601f830112610ce657610ce56108bb565b5b8135610cf6848260208601610c4c565b91505092915050565b5f5f60408385031215610d1557610d146108b3565b5b5f83013567ffffffffffffffff811115610d3257610d316108b7565b5b610d3e858286016109ca565b925050602083013567ffffffffffffffff811115610d5f57610d5e6108b7565b5b610d6b85828601610cd2565b9150509250929050565b5f81905092915050565b5f610d8982610a67565b610d938185610d75565b9350610da3818560208601610a81565b80840191505092915050565b5f610dba8284610d7f565b915081905092915050565b7f4e487b71000000000000000000000000000000000000000000000000000000005f52602260045260245ffd5b5f6002820490506001821680610e0957607f821691505b602082108103610e1c57610e1b610dc5565b5b50919050565b5f82825260208201905092915050565b7f41636573736f206e656761646f3a204170656e6173206f2047616d65205365725f8201527f76657220706f646520726567697374726172206c6f67732e0000000000000000602082015250565b5f610e8c603883610e22565b9150610e9782610e32565b604082019050919050565b5f6020820190508181035f830152610eb981610e80565b9050919050565b7f4572726f2064652041756469746f7269613a204f206a6f6761646f72206465205f8201527f6f726967656d206e616f20706f73737569206f20617469766f2e000000000000602082015250565b5f610f1a603a83610e22565b9150610f2582610ec0565b604082019050919050565b5f6020820190508181035f830152610f4781610f0e565b9050919050565b5f819050815f5260205f209050919050565b5f6020601f8301049050919050565b5f82821b905092915050565b5f60088302610faa7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff82610f6f565b610fb48683610f6f565b95508019841693508086168417925050509392505050565b5f819050919050565b5f819050919050565b5f610ff8610ff3610fee84610fcc565b610fd5565b610fcc565b9050919050565b5f819050919050565b61101183610fde565b61102561101d82610fff565b848454610f7b565b825550505050565b5f5f905090565b61103c61102d565b611047818484611008565b505050565b5b8181101561106a5761105f5f82611034565b60018101905061104d565b5050565b601f8211156110af5761108081610f4e565b61108984610f60565b81016020851015611098578190505b6110ac6110a485610f60565b83018261104c565b50505b505050565b5f82821c905092915050565b5f6110cf5f19846008026110b4565b1980831691505092915050565b5f6110e783836110c0565b9150826002028217905092915050565b61110082610a67565b67ffffffffffffffff811115611119576111186108d3565b5b6111238254610df2565b61112e82828561106e565b5f60209050601f83116001811461115f575f841561114d578287015190505b61115785826110dc565b8655506111be565b601f19841661116d86610f4e565b5f5b828110156111945784890151825560018201915060208501945060208101905061116f565b868310156111b157848901516111ad601f8916826110c0565b8355505b6001600288020188555050505b505050505050565b6111cf81610fcc565b82525050565b5f6111df82610a67565b6111e98185610e22565b93506111f9818560208601610a81565b611202816108c3565b840191505092915050565b5f6080820190506112205f8301876111c6565b818103602083015261123281866111d5565b9050818103604083015261124681856111d5565b9050818103606083015261125a81846111d5565b905095945050505050565b7f4e487b71000000000000000000000000000000000000000000000000000000005f52603260045260245ffd5b5f6060820190506112a55f8301866111c6565b81810360208301526112b781856111d5565b905081810360408301526112cb8184610ae6565b9050949350505050565b5f81905092915050565b5f819050815f5260205f209050919050565b5f81546112fd81610df2565b61130781866112d5565b9450600182165f8114611321576001811461133657611368565b60ff1983168652811515820286019350611368565b61133f856112df565b5f5b8381101561136057815481890152600182019150602081019050611341565b838801955050505b50505092915050565b5f61137c82846112f1565b915081905092915050565b7f4e487b71000000000000000000000000000000000000000000000000000000005f52601160045260245ffd5b5f6113be82610fcc565b91506113c983610fcc565b92508282039050818111156113e1576113e0611387565b5b92915050565b5f815490506113f581610df2565b9050919050565b5f819050815f5260205f209050919050565b81810361141c5750506114f1565b611425826113e7565b67ffffffffffffffff81111561143e5761143d6108d3565b5b6114488254610df2565b61145382828561106e565b5f601f831160018114611480575f841561146e578287015490505b61147885826110dc565b8655506114ea565b601f19841661148e876113fc565b965061149986610f4e565b5f5b828110156114c05784890154825560018201915060018501945060208101905061149b565b868310156114dd57848901546114d9601f8916826110c0565b8355505b6001600288020188555050505b5050505050505b565b7f4e487b71000000000000000000000000000000000000000000000000000000005f52603160045260245ffdfea2646970667358221220e77b505c556e21eac73ff24c2752e664684e9b9c5b9d0c7a5a829454e39fbde264736f6c634300081e0033",
+	ABI: "[{\"inputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"timestamp\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"playerA\",\"type\":\"string\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"playerB\",\"type\":\"string\"},{\"indexed\":false,\"internalType\":\"string[]\",\"name\":\"cardsA\",\"type\":\"string[]\"},{\"indexed\":false,\"internalType\":\"string[]\",\"name\":\"cardsB\",\"type\":\"string[]\"}],\"name\":\"AuditBundleSwap\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"timestamp\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"roomId\",\"type\":\"string\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"winnerId\",\"type\":\"string\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"loserId\",\"type\":\"string\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"reason\",\"type\":\"string\"}],\"name\":\"AuditMatch\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"timestamp\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"playerId\",\"type\":\"string\"},{\"indexed\":false,\"internalType\":\"string[]\",\"name\":\"cardIds\",\"type\":\"string[]\"},{\"components\":[{\"internalType\":\"bytes32\",\"name\":\"seed\",\"type\":\"bytes32\"},{\"internalType\":\"uint256\",\"name\":\"packageCounter\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"pity\",\"type\":\"uint256\"},{\"internalType\":\"string\",\"name\":\"template\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"catalog\",\"type\":\"string\"},{\"internalType\":\"bytes32\",\"name\":\"nextSeedCommitment\",\"type\":\"bytes32\"}],\"indexed\":false,\"internalType\":\"structJokenpoLedger.PackDerivation\",\"name\":\"derivation\",\"type\":\"tuple\"}],\"name\":\"AuditPackOpened\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"timestamp\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"bytes32\",\"name\":\"commitment\",\"type\":\"bytes32\"}],\"name\":\"AuditSeedCommitted\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"timestamp\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"playerA\",\"type\":\"string\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"playerB\",\"type\":\"string\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"cardA\",\"type\":\"string\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"cardB\",\"type\":\"string\"}],\"name\":\"AuditSwap\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"timestamp\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"fromPlayer\",\"type\":\"string\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"toPlayer\",\"type\":\"string\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"cardId\",\"type\":\"string\"}],\"name\":\"AuditTrade\",\"type\":\"event\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"_commitment\",\"type\":\"bytes32\"}],\"name\":\"commitPackSeed\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"gameServerAuthority\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"string\",\"name\":\"_playerId\",\"type\":\"string\"}],\"name\":\"getPlayerAssets\",\"outputs\":[{\"internalType\":\"string[]\",\"name\":\"\",\"type\":\"string[]\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"string\",\"name\":\"_playerA\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"_playerB\",\"type\":\"string\"},{\"internalType\":\"string[]\",\"name\":\"_cardsA\",\"type\":\"string[]\"},{\"internalType\":\"string[]\",\"name\":\"_cardsB\",\"type\":\"string[]\"}],\"name\":\"logBundleSwap\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"string\",\"name\":\"_roomId\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"_winnerId\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"_loserId\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"_reason\",\"type\":\"string\"}],\"name\":\"logMatchResult\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"string\",\"name\":\"_playerId\",\"type\":\"string\"},{\"internalType\":\"string[]\",\"name\":\"_cardIds\",\"type\":\"string[]\"},{\"components\":[{\"internalType\":\"bytes32\",\"name\":\"seed\",\"type\":\"bytes32\"},{\"internalType\":\"uint256\",\"name\":\"packageCounter\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"pity\",\"type\":\"uint256\"},{\"internalType\":\"string\",\"name\":\"template\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"catalog\",\"type\":\"string\"},{\"internalType\":\"bytes32\",\"name\":\"nextSeedCommitment\",\"type\":\"bytes32\"}],\"internalType\":\"structJokenpoLedger.PackDerivation\",\"name\":\"_derivation\",\"type\":\"tuple\"}],\"name\":\"logPackOpening\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"string\",\"name\":\"_playerA\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"_playerB\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"_cardA\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"_cardB\",\"type\":\"string\"}],\"name\":\"logSwap\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"string\",\"name\":\"_fromPlayer\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"_toPlayer\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"_cardId\",\"type\":\"string\"}],\"name\":\"logTrade\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"packSeedCommitment\",\"outputs\":[{\"internalType\":\"bytes32\",\"name\":\"\",\"type\":\"bytes32\"}],\"stateMutability\":\"view\",\"type\":\"function\"}]",
+	Bin: "0x6080604052348015600e575f5ffd5b50335f5f6101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff16021790555061246c8061005b5f395ff3fe608060405234801561000f575f5ffd5b5060043610610091575f3560e01c80636573447a116100645780636573447a1461011b57806382352af51461013957806389d98f28146101555780638b54a1d114610171578063d317d2101461018d57610091565b806307cfa61c146100955780630ada582d146100b15780631502cd0c146100cf57806357da55ce146100ff575b5f5ffd5b6100af60048036038101906100aa9190611015565b6101a9565b005b6100b961027a565b6040516100c6919061107f565b60405180910390f35b6100e960048036038101906100e491906111d4565b61029e565b6040516100f69190611336565b60405180910390f35b61011960048036038101906101149190611356565b610390565b005b610123610501565b6040516101309190611409565b60405180910390f35b610153600480360381019061014e9190611422565b610507565b005b61016f600480360381019061016a91906116e8565b6105da565b005b61018b6004803603810190610186919061178c565b6107ec565b005b6101a760048036038101906101a29190611422565b610b0d565b005b5f5f9054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff163373ffffffffffffffffffffffffffffffffffffffff1614610237576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161022e906118e0565b60405180910390fd5b806002819055507f2a79c6b597b1e56dbdbba2b58d8a2fd08bceac59a4bb403fd3cd8397d38179d6428260405161026f92919061190d565b60405180910390a150565b5f5f9054906101000a900473ffffffffffffffffffffffffffffffffffffffff1681565b60606001826040516102b0919061196e565b9081526020016040518091039020805480602002602001604051908101604052809291908181526020015f905b82821015610385578382905f5260205f200180546102fa906119b1565b80601f0160208091040260200160405190810160405280929190818152602001828054610326906119b1565b80156103715780601f1061034857610100808354040283529160200191610371565b820191905f5260205f20905b81548152906001019060200180831161035457829003601f168201915b5050505050815260200190600101906102dd565b505050509050919050565b5f5f9054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff163373ffffffffffffffffffffffffffffffffffffffff161461041e576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610415906118e0565b60405180910390fd5b6104288382610d22565b610467576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161045e90611a51565b60405180910390fd5b6104718382610e70565b600182604051610481919061196e565b908152602001604051809103902081908060018154018082558091505060019003905f5260205f20015f9091909190915090816104be9190611c0f565b507fcb6a9427f5732496720fa2f6427b1bc9a407a78d57f02a411a4f459a1d97c5c8428484846040516104f49493929190611d16565b60405180910390a1505050565b60025481565b5f5f9054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff163373ffffffffffffffffffffffffffffffffffffffff1614610595576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161058c906118e0565b60405180910390fd5b7f5438de06cb73f4f40e2b47f9f77c46559da3018e1d93a919b79de602c6a4137842858585856040516105cc959493929190611d6e565b60405180910390a150505050565b5f5f9054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff163373ffffffffffffffffffffffffffffffffffffffff1614610668576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161065f906118e0565b60405180910390fd5b6002546002825f01516040516020016106819190611dfb565b60405160208183030381529060405260405161069d9190611e59565b602060405180830381855afa1580156106b8573d5f5f3e3d5ffd5b5050506040513d601f19601f820116820180604052508101906106db9190611e83565b1461071b576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161071290611f1e565b60405180910390fd5b8060a001516002819055505f5f90505b82518110156107a957600184604051610744919061196e565b908152602001604051809103902083828151811061076557610764611f3c565b5b6020026020010151908060018154018082558091505060019003905f5260205f20015f90919091909150908161079b9190611c0f565b50808060010191505061072b565b507f495cd036d85f02594db0488e37cd32ef1f395eb23d9bc7b276fa2f45142635e4428484846040516107df9493929190612014565b60405180910390a1505050565b5f5f9054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff163373ffffffffffffffffffffffffffffffffffffffff161461087a576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610871906118e0565b60405180910390fd5b5f5f90505b825181101561091c576108ac8584838151811061089f5761089e611f3c565b5b6020026020010151610d22565b6108eb576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016108e2906120dc565b60405180910390fd5b61090f8584838151811061090257610901611f3c565b5b6020026020010151610e70565b808060010191505061087f565b505f5f90505b81518110156109bf5761094f8483838151811061094257610941611f3c565b5b6020026020010151610d22565b61098e576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016109859061216a565b60405180910390fd5b6109b2848383815181106109a5576109a4611f3c565b5b6020026020010151610e70565b8080600101915050610922565b505f5f90505b8251811015610a43576001846040516109de919061196e565b90815260200160405180910390208382815181106109ff576109fe611f3c565b5b6020026020010151908060018154018082558091505060019003905f5260205f20015f909190919091509081610a359190611c0f565b5080806001019150506109c5565b505f5f90505b8151811015610ac757600185604051610a62919061196e565b9081526020016040518091039020828281518110610a8357610a82611f3c565b5b6020026020010151908060018154018082558091505060019003905f5260205f20015f909190919091509081610ab99190611c0f565b508080600101915050610a49565b507f7b698fcb3df662af554ce7abca0c0aad289365449f5a6e898012fa3a63bd6a904285858585604051610aff959493929190612188565b60405180910390a150505050565b5f5f9054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff163373ffffffffffffffffffffffffffffffffffffffff1614610b9b576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610b92906118e0565b60405180910390fd5b610ba58483610d22565b610be4576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610bdb906120dc565b60405180910390fd5b610bee8382610d22565b610c2d576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610c249061216a565b60405180910390fd5b610c378483610e70565b610c418382610e70565b600183604051610c51919061196e565b908152602001604051809103902082908060018154018082558091505060019003905f5260205f20015f909190919091509081610c8e9190611c0f565b50600184604051610c9f919061196e565b908152602001604051809103902081908060018154018082558091505060019003905f5260205f20015f909190919091509081610cdc9190611c0f565b507f0d3d05196f17f84e485ffeebf30d45b629cc8227bff6baff5c3e99b6f4b167164285858585604051610d14959493929190611d6e565b60405180910390a150505050565b5f5f600184604051610d34919061196e565b9081526020016040518091039020805480602002602001604051908101604052809291908181526020015f905b82821015610e09578382905f5260205f20018054610d7e906119b1565b80601f0160208091040260200160405190810160405280929190818152602001828054610daa906119b1565b8015610df55780601f10610dcc57610100808354040283529160200191610df5565b820191905f5260205f20905b815481529060010190602001808311610dd857829003601f168201915b505050505081526020019060010190610d61565b5050505090505f5f90505b8151811015610e64578380519060200120828281518110610e3857610e37611f3c565b5b60200260200101518051906020012003610e5757600192505050610e6a565b8080600101915050610e14565b505f9150505b92915050565b5f600183604051610e81919061196e565b908152602001604051809103902090505f5f90505b8180549050811015610f72578280519060200120828281548110610ebd57610ebc611f3c565b5b905f5260205f2001604051610ed29190612287565b604051809103902003610f65578160018380549050610ef191906122ca565b81548110610f0257610f01611f3c565b5b905f5260205f2001828281548110610f1d57610f1c611f3c565b5b905f5260205f20019081610f319190612324565b5081805480610f4357610f42612409565b5b600190038181905f5260205f20015f610f5c9190610f79565b90555050610f75565b8080600101915050610e96565b50505b5050565b508054610f85906119b1565b5f825580601f10610f965750610fb3565b601f0160209004905f5260205f2090810190610fb29190610fb6565b5b50565b5b80821115610fcd575f815f905550600101610fb7565b5090565b5f604051905090565b5f5ffd5b5f5ffd5b5f819050919050565b610ff481610fe2565b8114610ffe575f5ffd5b50565b5f8135905061100f81610feb565b92915050565b5f6020828403121561102a57611029610fda565b5b5f61103784828501611001565b91505092915050565b5f73ffffffffffffffffffffffffffffffffffffffff82169050919050565b5f61106982611040565b9050919050565b6110798161105f565b82525050565b5f6020820190506110925f830184611070565b92915050565b5f5ffd5b5f5ffd5b5f601f19601f8301169050919050565b7f4e487b71000000000000000000000000000000000000000000000000000000005f52604160045260245ffd5b6110e6826110a0565b810181811067ffffffffffffffff82111715611105576111046110b0565b5b80604052505050565b5f611117610fd1565b905061112382826110dd565b919050565b5f67ffffffffffffffff821115611142576111416110b0565b5b61114b826110a0565b9050602081019050919050565b828183375f83830152505050565b5f61117861117384611128565b61110e565b9050828152602081018484840111156111945761119361109c565b5b61119f848285611158565b509392505050565b5f82601f8301126111bb576111ba611098565b5b81356111cb848260208601611166565b91505092915050565b5f602082840312156111e9576111e8610fda565b5b5f82013567ffffffffffffffff81111561120657611205610fde565b5b611212848285016111a7565b91505092915050565b5f81519050919050565b5f82825260208201905092915050565b5f819050602082019050919050565b5f81519050919050565b5f82825260208201905092915050565b8281835e5f83830152505050565b5f61127682611244565b611280818561124e565b935061129081856020860161125e565b611299816110a0565b840191505092915050565b5f6112af838361126c565b905092915050565b5f602082019050919050565b5f6112cd8261121b565b6112d78185611225565b9350836020820285016112e985611235565b805f5b85811015611324578484038952815161130585826112a4565b9450611310836112b7565b925060208a019950506001810190506112ec565b50829750879550505050505092915050565b5f6020820190508181035f83015261134e81846112c3565b905092915050565b5f5f5f6060848603121561136d5761136c610fda565b5b5f84013567ffffffffffffffff81111561138a57611389610fde565b5b611396868287016111a7565b935050602084013567ffffffffffffffff8111156113b7576113b6610fde565b5b6113c3868287016111a7565b925050604084013567ffffffffffffffff8111156113e4576113e3610fde565b5b6113f0868287016111a7565b9150509250925092565b61140381610fe2565b82525050565b5f60208201905061141c5f8301846113fa565b92915050565b5f5f5f5f6080858703121561143a57611439610fda565b5b5f85013567ffffffffffffffff81111561145757611456610fde565b5b611463878288016111a7565b945050602085013567ffffffffffffffff81111561148457611483610fde565b5b611490878288016111a7565b935050604085013567ffffffffffffffff8111156114b1576114b0610fde565b5b6114bd878288016111a7565b925050606085013567ffffffffffffffff8111156114de576114dd610fde565b5b6114ea878288016111a7565b91505092959194509250565b5f67ffffffffffffffff8211156115105761150f6110b0565b5b602082029050602081019050919050565b5f5ffd5b5f611537611532846114f6565b61110e565b9050808382526020820190506020840283018581111561155a57611559611521565b5b835b818110156115a157803567ffffffffffffffff81111561157f5761157e611098565b5b80860161158c89826111a7565b8552602085019450505060208101905061155c565b5050509392505050565b5f82601f8301126115bf576115be611098565b5b81356115cf848260208601611525565b91505092915050565b5f5ffd5b5f5ffd5b5f819050919050565b6115f2816115e0565b81146115fc575f5ffd5b50565b5f8135905061160d816115e9565b92915050565b5f60c08284031215611628576116276115d8565b5b61163260c061110e565b90505f61164184828501611001565b5f830152506020611654848285016115ff565b6020830152506040611668848285016115ff565b604083015250606082013567ffffffffffffffff81111561168c5761168b6115dc565b5b611698848285016111a7565b606083015250608082013567ffffffffffffffff8111156116bc576116bb6115dc565b5b6116c8848285016111a7565b60808301525060a06116dc84828501611001565b60a08301525092915050565b5f5f5f606084860312156116ff576116fe610fda565b5b5f84013567ffffffffffffffff81111561171c5761171b610fde565b5b611728868287016111a7565b935050602084013567ffffffffffffffff81111561174957611748610fde565b5b611755868287016115ab565b925050604084013567ffffffffffffffff81111561177657611775610fde565b5b61178286828701611613565b9150509250925092565b5f5f5f5f608085870312156117a4576117a3610fda565b5b5f85013567ffffffffffffffff8111156117c1576117c0610fde565b5b6117cd878288016111a7565b945050602085013567ffffffffffffffff8111156117ee576117ed610fde565b5b6117fa878288016111a7565b935050604085013567ffffffffffffffff81111561181b5761181a610fde565b5b611827878288016115ab565b925050606085013567ffffffffffffffff81111561184857611847610fde565b5b611854878288016115ab565b91505092959194509250565b5f82825260208201905092915050565b7f41636573736f206e656761646f3a204170656e6173206f2047616d65205365725f8201527f76657220706f646520726567697374726172206c6f67732e0000000000000000602082015250565b5f6118ca603883611860565b91506118d582611870565b604082019050919050565b5f6020820190508181035f8301526118f7816118be565b9050919050565b611907816115e0565b82525050565b5f6040820190506119205f8301856118fe565b61192d60208301846113fa565b9392505050565b5f81905092915050565b5f61194882611244565b6119528185611934565b935061196281856020860161125e565b80840191505092915050565b5f611979828461193e565b915081905092915050565b7f4e487b71000000000000000000000000000000000000000000000000000000005f52602260045260245ffd5b5f60028204905060018216806119c857607f821691505b6020821081036119db576119da611984565b5b50919050565b7f4572726f2064652041756469746f7269613a204f206a6f6761646f72206465205f8201527f6f726967656d206e616f20706f73737569206f20617469766f2e000000000000602082015250565b5f611a3b603a83611860565b9150611a46826119e1565b604082019050919050565b5f6020820190508181035f830152611a6881611a2f565b9050919050565b5f819050815f5260205f209050919050565b5f6020601f8301049050919050565b5f82821b905092915050565b5f60088302611acb7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff82611a90565b611ad58683611a90565b95508019841693508086168417925050509392505050565b5f819050919050565b5f611b10611b0b611b06846115e0565b611aed565b6115e0565b9050919050565b5f819050919050565b611b2983611af6565b611b3d611b3582611b17565b848454611a9c565b825550505050565b5f5f905090565b611b54611b45565b611b5f818484611b20565b505050565b5b81811015611b8257611b775f82611b4c565b600181019050611b65565b5050565b601f821115611bc757611b9881611a6f565b611ba184611a81565b81016020851015611bb0578190505b611bc4611bbc85611a81565b830182611b64565b50505b505050565b5f82821c905092915050565b5f611be75f1984600802611bcc565b1980831691505092915050565b5f611bff8383611bd8565b9150826002028217905092915050565b611c1882611244565b67ffffffffffffffff811115611c3157611c306110b0565b5b611c3b82546119b1565b611c46828285611b86565b5f60209050601f831160018114611c77575f8415611c65578287015190505b611c6f8582611bf4565b865550611cd6565b601f198416611c8586611a6f565b5f5b82811015611cac57848901518255600182019150602085019450602081019050611c87565b86831015611cc95784890151611cc5601f891682611bd8565b8355505b6001600288020188555050505b505050505050565b5f611ce882611244565b611cf28185611860565b9350611d0281856020860161125e565b611d0b816110a0565b840191505092915050565b5f608082019050611d295f8301876118fe565b8181036020830152611d3b8186611cde565b90508181036040830152611d4f8185611cde565b90508181036060830152611d638184611cde565b905095945050505050565b5f60a082019050611d815f8301886118fe565b8181036020830152611d938187611cde565b90508181036040830152611da78186611cde565b90508181036060830152611dbb8185611cde565b90508181036080830152611dcf8184611cde565b90509695505050505050565b5f819050919050565b611df5611df082610fe2565b611ddb565b82525050565b5f611e068284611de4565b60208201915081905092915050565b5f81519050919050565b5f81905092915050565b5f611e3382611e15565b611e3d8185611e1f565b9350611e4d81856020860161125e565b80840191505092915050565b5f611e648284611e29565b915081905092915050565b5f81519050611e7d81610feb565b92915050565b5f60208284031215611e9857611e97610fda565b5b5f611ea584828501611e6f565b91505092915050565b7f4572726f2064652041756469746f7269613a2073656d656e7465206e616f20635f8201527f6f72726573706f6e646520616f20636f6d70726f6d6973736f2e000000000000602082015250565b5f611f08603a83611860565b9150611f1382611eae565b604082019050919050565b5f6020820190508181035f830152611f3581611efc565b9050919050565b7f4e487b71000000000000000000000000000000000000000000000000000000005f52603260045260245ffd5b611f7281610fe2565b82525050565b611f81816115e0565b82525050565b5f60c083015f830151611f9c5f860182611f69565b506020830151611faf6020860182611f78565b506040830151611fc26040860182611f78565b5060608301518482036060860152611fda828261126c565b91505060808301518482036080860152611ff4828261126c565b91505060a083015161200960a0860182611f69565b508091505092915050565b5f6080820190506120275f8301876118fe565b81810360208301526120398186611cde565b9050818103604083015261204d81856112c3565b905081810360608301526120618184611f87565b905095945050505050565b7f4572726f2064652041756469746f7269613a204f206a6f6761646f722041206e5f8201527f616f20706f73737569206f20617469766f2e0000000000000000000000000000602082015250565b5f6120c6603283611860565b91506120d18261206c565b604082019050919050565b5f6020820190508181035f8301526120f3816120ba565b9050919050565b7f4572726f2064652041756469746f7269613a204f206a6f6761646f722042206e5f8201527f616f20706f73737569206f20617469766f2e0000000000000000000000000000602082015250565b5f612154603283611860565b915061215f826120fa565b604082019050919050565b5f6020820190508181035f83015261218181612148565b9050919050565b5f60a08201905061219b5f8301886118fe565b81810360208301526121ad8187611cde565b905081810360408301526121c18186611cde565b905081810360608301526121d581856112c3565b905081810360808301526121e981846112c3565b90509695505050505050565b5f819050815f5260205f209050919050565b5f8154612213816119b1565b61221d8186611e1f565b9450600182165f8114612237576001811461224c5761227e565b60ff198316865281151582028601935061227e565b612255856121f5565b5f5b8381101561227657815481890152600182019150602081019050612257565b838801955050505b50505092915050565b5f6122928284612207565b915081905092915050565b7f4e487b71000000000000000000000000000000000000000000000000000000005f52601160045260245ffd5b5f6122d4826115e0565b91506122df836115e0565b92508282039050818111156122f7576122f661229d565b5b92915050565b5f8154905061230b816119b1565b9050919050565b5f819050815f5260205f209050919050565b818103612332575050612407565b61233b826122fd565b67ffffffffffffffff811115612354576123536110b0565b5b61235e82546119b1565b612369828285611b86565b5f601f831160018114612396575f8415612384578287015490505b61238e8582611bf4565b865550612400565b601f1984166123a487612312565b96506123af86611a6f565b5f5b828110156123d6578489015482556001820191506001850194506020810190506123b1565b868310156123f357848901546123ef601f891682611bd8565b8355505b6001600288020188555050505b5050505050505b565b7f4e487b71000000000000000000000000000000000000000000000000000000005f52603160045260245ffdfea2646970667358221220f13a471e1d6a6a638ab38bf64f7d12af8022e567ac221132dd2b5e827be4a3a064736f6c634300081e0033",
 }
 
 // LedgerABI is the input ABI used to generate the binding from.
@@ -264,46 +274,140 @@ func (_Ledger *LedgerCallerSession) GetPlayerAssets(_playerId string) ([]string,
 	return _Ledger.Contract.GetPlayerAssets(&_Ledger.CallOpts, _playerId)
 }
 
-// LogMatchResult is a paid mutator transaction binding the contract method 0x7908708b.
+// PackSeedCommitment is a free data retrieval call binding the contract method 0x6573447a.
+//
+// Solidity: function packSeedCommitment() view returns(bytes32)
+func (_Ledger *LedgerCaller) PackSeedCommitment(opts *bind.CallOpts) ([32]byte, error) {
+	var out []interface{}
+	err := _Ledger.contract.Call(opts, &out, "packSeedCommitment")
+
+	if err != nil {
+		return *new([32]byte), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([32]byte)).(*[32]byte)
+
+	return out0, err
+
+}
+
+// PackSeedCommitment is a free data retrieval call binding the contract method 0x6573447a.
+//
+// Solidity: function packSeedCommitment() view returns(bytes32)
+func (_Ledger *LedgerSession) PackSeedCommitment() ([32]byte, error) {
+	return _Ledger.Contract.PackSeedCommitment(&_Ledger.CallOpts)
+}
+
+// PackSeedCommitment is a free data retrieval call binding the contract method 0x6573447a.
+//
+// Solidity: function packSeedCommitment() view returns(bytes32)
+func (_Ledger *LedgerCallerSession) PackSeedCommitment() ([32]byte, error) {
+	return _Ledger.Contract.PackSeedCommitment(&_Ledger.CallOpts)
+}
+
+// CommitPackSeed is a paid mutator transaction binding the contract method 0x07cfa61c.
+//
+// Solidity: function commitPackSeed(bytes32 _commitment) returns()
+func (_Ledger *LedgerTransactor) CommitPackSeed(opts *bind.TransactOpts, _commitment [32]byte) (*types.Transaction, error) {
+	return _Ledger.contract.Transact(opts, "commitPackSeed", _commitment)
+}
+
+// CommitPackSeed is a paid mutator transaction binding the contract method 0x07cfa61c.
 //
-// Solidity: function logMatchResult(string _roomId, string _winnerId, string _loserId) returns()
-func (_Ledger *LedgerTransactor) LogMatchResult(opts *bind.TransactOpts, _roomId string, _winnerId string, _loserId string) (*types.Transaction, error) {
-	return _Ledger.contract.Transact(opts, "logMatchResult", _roomId, _winnerId, _loserId)
+// Solidity: function commitPackSeed(bytes32 _commitment) returns()
+func (_Ledger *LedgerSession) CommitPackSeed(_commitment [32]byte) (*types.Transaction, error) {
+	return _Ledger.Contract.CommitPackSeed(&_Ledger.TransactOpts, _commitment)
 }
 
-// LogMatchResult is a paid mutator transaction binding the contract method 0x7908708b.
+// CommitPackSeed is a paid mutator transaction binding the contract method 0x07cfa61c.
 //
-// Solidity: function logMatchResult(string _roomId, string _winnerId, string _loserId) returns()
-func (_Ledger *LedgerSession) LogMatchResult(_roomId string, _winnerId string, _loserId string) (*types.Transaction, error) {
-	return _Ledger.Contract.LogMatchResult(&_Ledger.TransactOpts, _roomId, _winnerId, _loserId)
+// Solidity: function commitPackSeed(bytes32 _commitment) returns()
+func (_Ledger *LedgerTransactorSession) CommitPackSeed(_commitment [32]byte) (*types.Transaction, error) {
+	return _Ledger.Contract.CommitPackSeed(&_Ledger.TransactOpts, _commitment)
 }
 
-// LogMatchResult is a paid mutator transaction binding the contract method 0x7908708b.
+// LogBundleSwap is a paid mutator transaction binding the contract method 0x8b54a1d1.
 //
-// Solidity: function logMatchResult(string _roomId, string _winnerId, string _loserId) returns()
-func (_Ledger *LedgerTransactorSession) LogMatchResult(_roomId string, _winnerId string, _loserId string) (*types.Transaction, error) {
-	return _Ledger.Contract.LogMatchResult(&_Ledger.TransactOpts, _roomId, _winnerId, _loserId)
+// Solidity: function logBundleSwap(string _playerA, string _playerB, string[] _cardsA, string[] _cardsB) returns()
+func (_Ledger *LedgerTransactor) LogBundleSwap(opts *bind.TransactOpts, _playerA string, _playerB string, _cardsA []string, _cardsB []string) (*types.Transaction, error) {
+	return _Ledger.contract.Transact(opts, "logBundleSwap", _playerA, _playerB, _cardsA, _cardsB)
 }
 
-// LogPackOpening is a paid mutator transaction binding the contract method 0xcf27ed0c.
+// LogBundleSwap is a paid mutator transaction binding the contract method 0x8b54a1d1.
 //
-// Solidity: function logPackOpening(string _playerId, string[] _cardIds) returns()
-func (_Ledger *LedgerTransactor) LogPackOpening(opts *bind.TransactOpts, _playerId string, _cardIds []string) (*types.Transaction, error) {
-	return _Ledger.contract.Transact(opts, "logPackOpening", _playerId, _cardIds)
+// Solidity: function logBundleSwap(string _playerA, string _playerB, string[] _cardsA, string[] _cardsB) returns()
+func (_Ledger *LedgerSession) LogBundleSwap(_playerA string, _playerB string, _cardsA []string, _cardsB []string) (*types.Transaction, error) {
+	return _Ledger.Contract.LogBundleSwap(&_Ledger.TransactOpts, _playerA, _playerB, _cardsA, _cardsB)
 }
 
-// LogPackOpening is a paid mutator transaction binding the contract method 0xcf27ed0c.
+// LogBundleSwap is a paid mutator transaction binding the contract method 0x8b54a1d1.
 //
-// Solidity: function logPackOpening(string _playerId, string[] _cardIds) returns()
-func (_Ledger *LedgerSession) LogPackOpening(_playerId string, _cardIds []string) (*types.Transaction, error) {
-	return _Ledger.Contract.LogPackOpening(&_Ledger.TransactOpts, _playerId, _cardIds)
+// Solidity: function logBundleSwap(string _playerA, string _playerB, string[] _cardsA, string[] _cardsB) returns()
+func (_Ledger *LedgerTransactorSession) LogBundleSwap(_playerA string, _playerB string, _cardsA []string, _cardsB []string) (*types.Transaction, error) {
+	return _Ledger.Contract.LogBundleSwap(&_Ledger.TransactOpts, _playerA, _playerB, _cardsA, _cardsB)
 }
 
-// LogPackOpening is a paid mutator transaction binding the contract method 0xcf27ed0c.
+// LogMatchResult is a paid mutator transaction binding the contract method 0x82352af5.
 //
-// Solidity: function logPackOpening(string _playerId, string[] _cardIds) returns()
-func (_Ledger *LedgerTransactorSession) LogPackOpening(_playerId string, _cardIds []string) (*types.Transaction, error) {
-	return _Ledger.Contract.LogPackOpening(&_Ledger.TransactOpts, _playerId, _cardIds)
+// Solidity: function logMatchResult(string _roomId, string _winnerId, string _loserId, string _reason) returns()
+func (_Ledger *LedgerTransactor) LogMatchResult(opts *bind.TransactOpts, _roomId string, _winnerId string, _loserId string, _reason string) (*types.Transaction, error) {
+	return _Ledger.contract.Transact(opts, "logMatchResult", _roomId, _winnerId, _loserId, _reason)
+}
+
+// LogMatchResult is a paid mutator transaction binding the contract method 0x82352af5.
+//
+// Solidity: function logMatchResult(string _roomId, string _winnerId, string _loserId, string _reason) returns()
+func (_Ledger *LedgerSession) LogMatchResult(_roomId string, _winnerId string, _loserId string, _reason string) (*types.Transaction, error) {
+	return _Ledger.Contract.LogMatchResult(&_Ledger.TransactOpts, _roomId, _winnerId, _loserId, _reason)
+}
+
+// LogMatchResult is a paid mutator transaction binding the contract method 0x82352af5.
+//
+// Solidity: function logMatchResult(string _roomId, string _winnerId, string _loserId, string _reason) returns()
+func (_Ledger *LedgerTransactorSession) LogMatchResult(_roomId string, _winnerId string, _loserId string, _reason string) (*types.Transaction, error) {
+	return _Ledger.Contract.LogMatchResult(&_Ledger.TransactOpts, _roomId, _winnerId, _loserId, _reason)
+}
+
+// LogPackOpening is a paid mutator transaction binding the contract method 0x89d98f28.
+//
+// Solidity: function logPackOpening(string _playerId, string[] _cardIds, (bytes32,uint256,uint256,string,string,bytes32) _derivation) returns()
+func (_Ledger *LedgerTransactor) LogPackOpening(opts *bind.TransactOpts, _playerId string, _cardIds []string, _derivation JokenpoLedgerPackDerivation) (*types.Transaction, error) {
+	return _Ledger.contract.Transact(opts, "logPackOpening", _playerId, _cardIds, _derivation)
+}
+
+// LogPackOpening is a paid mutator transaction binding the contract method 0x89d98f28.
+//
+// Solidity: function logPackOpening(string _playerId, string[] _cardIds, (bytes32,uint256,uint256,string,string,bytes32) _derivation) returns()
+func (_Ledger *LedgerSession) LogPackOpening(_playerId string, _cardIds []string, _derivation JokenpoLedgerPackDerivation) (*types.Transaction, error) {
+	return _Ledger.Contract.LogPackOpening(&_Ledger.TransactOpts, _playerId, _cardIds, _derivation)
+}
+
+// LogPackOpening is a paid mutator transaction binding the contract method 0x89d98f28.
+//
+// Solidity: function logPackOpening(string _playerId, string[] _cardIds, (bytes32,uint256,uint256,string,string,bytes32) _derivation) returns()
+func (_Ledger *LedgerTransactorSession) LogPackOpening(_playerId string, _cardIds []string, _derivation JokenpoLedgerPackDerivation) (*types.Transaction, error) {
+	return _Ledger.Contract.LogPackOpening(&_Ledger.TransactOpts, _playerId, _cardIds, _derivation)
+}
+
+// LogSwap is a paid mutator transaction binding the contract method 0xd317d210.
+//
+// Solidity: function logSwap(string _playerA, string _playerB, string _cardA, string _cardB) returns()
+func (_Ledger *LedgerTransactor) LogSwap(opts *bind.TransactOpts, _playerA string, _playerB string, _cardA string, _cardB string) (*types.Transaction, error) {
+	return _Ledger.contract.Transact(opts, "logSwap", _playerA, _playerB, _cardA, _cardB)
+}
+
+// LogSwap is a paid mutator transaction binding the contract method 0xd317d210.
+//
+// Solidity: function logSwap(string _playerA, string _playerB, string _cardA, string _cardB) returns()
+func (_Ledger *LedgerSession) LogSwap(_playerA string, _playerB string, _cardA string, _cardB string) (*types.Transaction, error) {
+	return _Ledger.Contract.LogSwap(&_Ledger.TransactOpts, _playerA, _playerB, _cardA, _cardB)
+}
+
+// LogSwap is a paid mutator transaction binding the contract method 0xd317d210.
+//
+// Solidity: function logSwap(string _playerA, string _playerB, string _cardA, string _cardB) returns()
+func (_Ledger *LedgerTransactorSession) LogSwap(_playerA string, _playerB string, _cardA string, _cardB string) (*types.Transaction, error) {
+	return _Ledger.Contract.LogSwap(&_Ledger.TransactOpts, _playerA, _playerB, _cardA, _cardB)
 }
 
 // LogTrade is a paid mutator transaction binding the contract method 0x57da55ce.
@@ -327,6 +431,144 @@ func (_Ledger *LedgerTransactorSession) LogTrade(_fromPlayer string, _toPlayer s
 	return _Ledger.Contract.LogTrade(&_Ledger.TransactOpts, _fromPlayer, _toPlayer, _cardId)
 }
 
+// LedgerAuditBundleSwapIterator is returned from FilterAuditBundleSwap and is used to iterate over the raw logs and unpacked data for AuditBundleSwap events raised by the Ledger contract.
+type LedgerAuditBundleSwapIterator struct {
+	Event *LedgerAuditBundleSwap // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *LedgerAuditBundleSwapIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(LedgerAuditBundleSwap)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(LedgerAuditBundleSwap)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *LedgerAuditBundleSwapIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *LedgerAuditBundleSwapIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// LedgerAuditBundleSwap represents a AuditBundleSwap event raised by the Ledger contract.
+type LedgerAuditBundleSwap struct {
+	Timestamp *big.Int
+	PlayerA   string
+	PlayerB   string
+	CardsA    []string
+	CardsB    []string
+	Raw       types.Log // Blockchain specific contextual infos
+}
+
+// FilterAuditBundleSwap is a free log retrieval operation binding the contract event 0x7b698fcb3df662af554ce7abca0c0aad289365449f5a6e898012fa3a63bd6a90.
+//
+// Solidity: event AuditBundleSwap(uint256 timestamp, string playerA, string playerB, string[] cardsA, string[] cardsB)
+func (_Ledger *LedgerFilterer) FilterAuditBundleSwap(opts *bind.FilterOpts) (*LedgerAuditBundleSwapIterator, error) {
+
+	logs, sub, err := _Ledger.contract.FilterLogs(opts, "AuditBundleSwap")
+	if err != nil {
+		return nil, err
+	}
+	return &LedgerAuditBundleSwapIterator{contract: _Ledger.contract, event: "AuditBundleSwap", logs: logs, sub: sub}, nil
+}
+
+// WatchAuditBundleSwap is a free log subscription operation binding the contract event 0x7b698fcb3df662af554ce7abca0c0aad289365449f5a6e898012fa3a63bd6a90.
+//
+// Solidity: event AuditBundleSwap(uint256 timestamp, string playerA, string playerB, string[] cardsA, string[] cardsB)
+func (_Ledger *LedgerFilterer) WatchAuditBundleSwap(opts *bind.WatchOpts, sink chan<- *LedgerAuditBundleSwap) (event.Subscription, error) {
+
+	logs, sub, err := _Ledger.contract.WatchLogs(opts, "AuditBundleSwap")
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(LedgerAuditBundleSwap)
+				if err := _Ledger.contract.UnpackLog(event, "AuditBundleSwap", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseAuditBundleSwap is a log parse operation binding the contract event 0x7b698fcb3df662af554ce7abca0c0aad289365449f5a6e898012fa3a63bd6a90.
+//
+// Solidity: event AuditBundleSwap(uint256 timestamp, string playerA, string playerB, string[] cardsA, string[] cardsB)
+func (_Ledger *LedgerFilterer) ParseAuditBundleSwap(log types.Log) (*LedgerAuditBundleSwap, error) {
+	event := new(LedgerAuditBundleSwap)
+	if err := _Ledger.contract.UnpackLog(event, "AuditBundleSwap", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
 // LedgerAuditMatchIterator is returned from FilterAuditMatch and is used to iterate over the raw logs and unpacked data for AuditMatch events raised by the Ledger contract.
 type LedgerAuditMatchIterator struct {
 	Event *LedgerAuditMatch // Event containing the contract specifics and raw log
@@ -400,12 +642,13 @@ type LedgerAuditMatch struct {
 	RoomId    string
 	WinnerId  string
 	LoserId   string
+	Reason    string
 	Raw       types.Log // Blockchain specific contextual infos
 }
 
-// FilterAuditMatch is a free log retrieval operation binding the contract event 0x459166290fcb68519a7a83e9074a5eddb1c5872f6494632588302fe07ab3ac6f.
+// FilterAuditMatch is a free log retrieval operation binding the contract event 0x5438de06cb73f4f40e2b47f9f77c46559da3018e1d93a919b79de602c6a41378.
 //
-// Solidity: event AuditMatch(uint256 timestamp, string roomId, string winnerId, string loserId)
+// Solidity: event AuditMatch(uint256 timestamp, string roomId, string winnerId, string loserId, string reason)
 func (_Ledger *LedgerFilterer) FilterAuditMatch(opts *bind.FilterOpts) (*LedgerAuditMatchIterator, error) {
 
 	logs, sub, err := _Ledger.contract.FilterLogs(opts, "AuditMatch")
@@ -415,9 +658,9 @@ func (_Ledger *LedgerFilterer) FilterAuditMatch(opts *bind.FilterOpts) (*LedgerA
 	return &LedgerAuditMatchIterator{contract: _Ledger.contract, event: "AuditMatch", logs: logs, sub: sub}, nil
 }
 
-// WatchAuditMatch is a free log subscription operation binding the contract event 0x459166290fcb68519a7a83e9074a5eddb1c5872f6494632588302fe07ab3ac6f.
+// WatchAuditMatch is a free log subscription operation binding the contract event 0x5438de06cb73f4f40e2b47f9f77c46559da3018e1d93a919b79de602c6a41378.
 //
-// Solidity: event AuditMatch(uint256 timestamp, string roomId, string winnerId, string loserId)
+// Solidity: event AuditMatch(uint256 timestamp, string roomId, string winnerId, string loserId, string reason)
 func (_Ledger *LedgerFilterer) WatchAuditMatch(opts *bind.WatchOpts, sink chan<- *LedgerAuditMatch) (event.Subscription, error) {
 
 	logs, sub, err := _Ledger.contract.WatchLogs(opts, "AuditMatch")
@@ -452,9 +695,9 @@ func (_Ledger *LedgerFilterer) WatchAuditMatch(opts *bind.WatchOpts, sink chan<-
 	}), nil
 }
 
-// ParseAuditMatch is a log parse operation binding the contract event 0x459166290fcb68519a7a83e9074a5eddb1c5872f6494632588302fe07ab3ac6f.
+// ParseAuditMatch is a log parse operation binding the contract event 0x5438de06cb73f4f40e2b47f9f77c46559da3018e1d93a919b79de602c6a41378.
 //
-// Solidity: event AuditMatch(uint256 timestamp, string roomId, string winnerId, string loserId)
+// Solidity: event AuditMatch(uint256 timestamp, string roomId, string winnerId, string loserId, string reason)
 func (_Ledger *LedgerFilterer) ParseAuditMatch(log types.Log) (*LedgerAuditMatch, error) {
 	event := new(LedgerAuditMatch)
 	if err := _Ledger.contract.UnpackLog(event, "AuditMatch", log); err != nil {
@@ -533,15 +776,16 @@ func (it *LedgerAuditPackOpenedIterator) Close() error {
 
 // LedgerAuditPackOpened represents a AuditPackOpened event raised by the Ledger contract.
 type LedgerAuditPackOpened struct {
-	Timestamp *big.Int
-	PlayerId  string
-	CardIds   []string
-	Raw       types.Log // Blockchain specific contextual infos
+	Timestamp  *big.Int
+	PlayerId   string
+	CardIds    []string
+	Derivation JokenpoLedgerPackDerivation
+	Raw        types.Log // Blockchain specific contextual infos
 }
 
-// FilterAuditPackOpened is a free log retrieval operation binding the contract event 0x1e2592092e270aa65505d82cfc0297cf9860cc6bc5501cf9544edf647b891be1.
+// FilterAuditPackOpened is a free log retrieval operation binding the contract event 0x495cd036d85f02594db0488e37cd32ef1f395eb23d9bc7b276fa2f45142635e4.
 //
-// Solidity: event AuditPackOpened(uint256 timestamp, string playerId, string[] cardIds)
+// Solidity: event AuditPackOpened(uint256 timestamp, string playerId, string[] cardIds, (bytes32,uint256,uint256,string,string,bytes32) derivation)
 func (_Ledger *LedgerFilterer) FilterAuditPackOpened(opts *bind.FilterOpts) (*LedgerAuditPackOpenedIterator, error) {
 
 	logs, sub, err := _Ledger.contract.FilterLogs(opts, "AuditPackOpened")
@@ -551,9 +795,9 @@ func (_Ledger *LedgerFilterer) FilterAuditPackOpened(opts *bind.FilterOpts) (*Le
 	return &LedgerAuditPackOpenedIterator{contract: _Ledger.contract, event: "AuditPackOpened", logs: logs, sub: sub}, nil
 }
 
-// WatchAuditPackOpened is a free log subscription operation binding the contract event 0x1e2592092e270aa65505d82cfc0297cf9860cc6bc5501cf9544edf647b891be1.
+// WatchAuditPackOpened is a free log subscription operation binding the contract event 0x495cd036d85f02594db0488e37cd32ef1f395eb23d9bc7b276fa2f45142635e4.
 //
-// Solidity: event AuditPackOpened(uint256 timestamp, string playerId, string[] cardIds)
+// Solidity: event AuditPackOpened(uint256 timestamp, string playerId, string[] cardIds, (bytes32,uint256,uint256,string,string,bytes32) derivation)
 func (_Ledger *LedgerFilterer) WatchAuditPackOpened(opts *bind.WatchOpts, sink chan<- *LedgerAuditPackOpened) (event.Subscription, error) {
 
 	logs, sub, err := _Ledger.contract.WatchLogs(opts, "AuditPackOpened")
@@ -588,9 +832,9 @@ func (_Ledger *LedgerFilterer) WatchAuditPackOpened(opts *bind.WatchOpts, sink c
 	}), nil
 }
 
-// ParseAuditPackOpened is a log parse operation binding the contract event 0x1e2592092e270aa65505d82cfc0297cf9860cc6bc5501cf9544edf647b891be1.
+// ParseAuditPackOpened is a log parse operation binding the contract event 0x495cd036d85f02594db0488e37cd32ef1f395eb23d9bc7b276fa2f45142635e4.
 //
-// Solidity: event AuditPackOpened(uint256 timestamp, string playerId, string[] cardIds)
+// Solidity: event AuditPackOpened(uint256 timestamp, string playerId, string[] cardIds, (bytes32,uint256,uint256,string,string,bytes32) derivation)
 func (_Ledger *LedgerFilterer) ParseAuditPackOpened(log types.Log) (*LedgerAuditPackOpened, error) {
 	event := new(LedgerAuditPackOpened)
 	if err := _Ledger.contract.UnpackLog(event, "AuditPackOpened", log); err != nil {
@@ -600,6 +844,279 @@ func (_Ledger *LedgerFilterer) ParseAuditPackOpened(log types.Log) (*LedgerAudit
 	return event, nil
 }
 
+// LedgerAuditSeedCommittedIterator is returned from FilterAuditSeedCommitted and is used to iterate over the raw logs and unpacked data for AuditSeedCommitted events raised by the Ledger contract.
+type LedgerAuditSeedCommittedIterator struct {
+	Event *LedgerAuditSeedCommitted // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *LedgerAuditSeedCommittedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(LedgerAuditSeedCommitted)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(LedgerAuditSeedCommitted)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *LedgerAuditSeedCommittedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *LedgerAuditSeedCommittedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// LedgerAuditSeedCommitted represents a AuditSeedCommitted event raised by the Ledger contract.
+type LedgerAuditSeedCommitted struct {
+	Timestamp  *big.Int
+	Commitment [32]byte
+	Raw        types.Log // Blockchain specific contextual infos
+}
+
+// FilterAuditSeedCommitted is a free log retrieval operation binding the contract event 0x2a79c6b597b1e56dbdbba2b58d8a2fd08bceac59a4bb403fd3cd8397d38179d6.
+//
+// Solidity: event AuditSeedCommitted(uint256 timestamp, bytes32 commitment)
+func (_Ledger *LedgerFilterer) FilterAuditSeedCommitted(opts *bind.FilterOpts) (*LedgerAuditSeedCommittedIterator, error) {
+
+	logs, sub, err := _Ledger.contract.FilterLogs(opts, "AuditSeedCommitted")
+	if err != nil {
+		return nil, err
+	}
+	return &LedgerAuditSeedCommittedIterator{contract: _Ledger.contract, event: "AuditSeedCommitted", logs: logs, sub: sub}, nil
+}
+
+// WatchAuditSeedCommitted is a free log subscription operation binding the contract event 0x2a79c6b597b1e56dbdbba2b58d8a2fd08bceac59a4bb403fd3cd8397d38179d6.
+//
+// Solidity: event AuditSeedCommitted(uint256 timestamp, bytes32 commitment)
+func (_Ledger *LedgerFilterer) WatchAuditSeedCommitted(opts *bind.WatchOpts, sink chan<- *LedgerAuditSeedCommitted) (event.Subscription, error) {
+
+	logs, sub, err := _Ledger.contract.WatchLogs(opts, "AuditSeedCommitted")
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(LedgerAuditSeedCommitted)
+				if err := _Ledger.contract.UnpackLog(event, "AuditSeedCommitted", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseAuditSeedCommitted is a log parse operation binding the contract event 0x2a79c6b597b1e56dbdbba2b58d8a2fd08bceac59a4bb403fd3cd8397d38179d6.
+//
+// Solidity: event AuditSeedCommitted(uint256 timestamp, bytes32 commitment)
+func (_Ledger *LedgerFilterer) ParseAuditSeedCommitted(log types.Log) (*LedgerAuditSeedCommitted, error) {
+	event := new(LedgerAuditSeedCommitted)
+	if err := _Ledger.contract.UnpackLog(event, "AuditSeedCommitted", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// LedgerAuditSwapIterator is returned from FilterAuditSwap and is used to iterate over the raw logs and unpacked data for AuditSwap events raised by the Ledger contract.
+type LedgerAuditSwapIterator struct {
+	Event *LedgerAuditSwap // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *LedgerAuditSwapIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(LedgerAuditSwap)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(LedgerAuditSwap)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *LedgerAuditSwapIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *LedgerAuditSwapIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// LedgerAuditSwap represents a AuditSwap event raised by the Ledger contract.
+type LedgerAuditSwap struct {
+	Timestamp *big.Int
+	PlayerA   string
+	PlayerB   string
+	CardA     string
+	CardB     string
+	Raw       types.Log // Blockchain specific contextual infos
+}
+
+// FilterAuditSwap is a free log retrieval operation binding the contract event 0x0d3d05196f17f84e485ffeebf30d45b629cc8227bff6baff5c3e99b6f4b16716.
+//
+// Solidity: event AuditSwap(uint256 timestamp, string playerA, string playerB, string cardA, string cardB)
+func (_Ledger *LedgerFilterer) FilterAuditSwap(opts *bind.FilterOpts) (*LedgerAuditSwapIterator, error) {
+
+	logs, sub, err := _Ledger.contract.FilterLogs(opts, "AuditSwap")
+	if err != nil {
+		return nil, err
+	}
+	return &LedgerAuditSwapIterator{contract: _Ledger.contract, event: "AuditSwap", logs: logs, sub: sub}, nil
+}
+
+// WatchAuditSwap is a free log subscription operation binding the contract event 0x0d3d05196f17f84e485ffeebf30d45b629cc8227bff6baff5c3e99b6f4b16716.
+//
+// Solidity: event AuditSwap(uint256 timestamp, string playerA, string playerB, string cardA, string cardB)
+func (_Ledger *LedgerFilterer) WatchAuditSwap(opts *bind.WatchOpts, sink chan<- *LedgerAuditSwap) (event.Subscription, error) {
+
+	logs, sub, err := _Ledger.contract.WatchLogs(opts, "AuditSwap")
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(LedgerAuditSwap)
+				if err := _Ledger.contract.UnpackLog(event, "AuditSwap", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseAuditSwap is a log parse operation binding the contract event 0x0d3d05196f17f84e485ffeebf30d45b629cc8227bff6baff5c3e99b6f4b16716.
+//
+// Solidity: event AuditSwap(uint256 timestamp, string playerA, string playerB, string cardA, string cardB)
+func (_Ledger *LedgerFilterer) ParseAuditSwap(log types.Log) (*LedgerAuditSwap, error) {
+	event := new(LedgerAuditSwap)
+	if err := _Ledger.contract.UnpackLog(event, "AuditSwap", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
 // LedgerAuditTradeIterator is returned from FilterAuditTrade and is used to iterate over the raw logs and unpacked data for AuditTrade events raised by the Ledger contract.
 type LedgerAuditTradeIterator struct {
 	Event *LedgerAuditTrade // Event containing the contract specifics and raw log
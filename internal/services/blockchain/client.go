@@ -2,18 +2,22 @@
 package blockchain
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"math/big"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"jokenpo/internal/ledger"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 )
@@ -28,13 +32,42 @@ type BlockchainClient struct {
 	contract *ledger.Ledger
 	auth     *bind.TransactOpts
 	address  common.Address
+	txMu     sync.Mutex // Serializa nonce + envio: as liquidações escrevem de goroutines diferentes
 }
 
+// ErrNotRecorded indica que a escrita com certeza não mudou o ledger: a transação
+// foi revertida pelo contrato ou nem chegou a ser enviada. Qualquer outro erro de
+// uma escrita é ambíguo (o envio ou a espera pelo recibo falhou depois de a
+// transação talvez ter saído) e quem chama precisa conferir o ledger antes de desfazer algo.
+var ErrNotRecorded = errors.New("transaction was not recorded on the ledger")
+
+// receiptTimeout limita a espera pelo recibo de uma transação enviada.
+const receiptTimeout = 2 * time.Minute
+
 type LogEntry struct {
 	Timestamp uint64
 	Message   string
 }
 
+// PackDerivation são as entradas registradas junto de cada pacote aberto
+// (espelho da struct PackDerivation do contrato).
+type PackDerivation struct {
+	Seed               [32]byte
+	PackageCounter     uint64
+	Pity               uint64
+	Template           string
+	Catalog            string
+	NextSeedCommitment [32]byte
+}
+
+// PackAudit é um evento AuditPackOpened já decodificado.
+type PackAudit struct {
+	Timestamp  uint64
+	PlayerID   string
+	CardIDs    []string
+	Derivation PackDerivation
+}
+
 func InitBlockchain(existingAddr string) (*BlockchainClient, string, error) {
 	var client *ethclient.Client
 	var err error
@@ -78,6 +111,9 @@ func InitBlockchain(existingAddr string) (*BlockchainClient, string, error) {
 		addr = common.HexToAddress(existingAddr)
 		contract, err = ledger.NewLedger(addr, client)
 		if err != nil { return nil, "", fmt.Errorf("bind failed: %v", err) }
+		if err := VerifyContractCode(context.Background(), client, addr); err != nil {
+			return nil, "", err
+		}
 		finalAddrStr = existingAddr
 	}
 
@@ -89,6 +125,35 @@ func InitBlockchain(existingAddr string) (*BlockchainClient, string, error) {
 	}, finalAddrStr, nil
 }
 
+// VerifyContractCode confere se o contrato publicado em addr tem todas as funções
+// dos bindings. Um deploy feito com um JokenpoLedger.bin antigo reverte toda
+// chamada a uma função nova (logSwap, logBundleSwap...); melhor falhar na subida.
+func VerifyContractCode(ctx context.Context, client bind.ContractCaller, addr common.Address) error {
+	code, err := client.CodeAt(ctx, addr, nil)
+	if err != nil {
+		return fmt.Errorf("failed to read contract code at %s: %v", addr.Hex(), err)
+	}
+	if len(code) == 0 {
+		return fmt.Errorf("no contract deployed at %s", addr.Hex())
+	}
+	parsed, err := ledger.LedgerMetaData.GetAbi()
+	if err != nil {
+		return err
+	}
+	var missing []string
+	for _, method := range parsed.Methods {
+		// O dispatcher do solc compara o seletor com PUSH4 <seletor>.
+		if !bytes.Contains(code, append([]byte{0x63}, method.ID...)) {
+			missing = append(missing, method.Sig)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("contract at %s is outdated, missing %s: redeploy it from build/JokenpoLedger.bin", addr.Hex(), strings.Join(missing, ", "))
+	}
+	return nil
+}
+
 func (bc *BlockchainClient) GetAuditReport() (string, error) {
 	opts := &bind.FilterOpts{Start: 0, Context: context.Background()}
 	var allLogs []LogEntry
@@ -97,7 +162,16 @@ func (bc *BlockchainClient) GetAuditReport() (string, error) {
 	if err == nil {
 		for iterPacks.Next() {
 			ev := iterPacks.Event
-			msg := fmt.Sprintf("PACK: Player %s... recebeu %d cartas", shortID(ev.PlayerId), len(ev.CardIds))
+			msg := fmt.Sprintf("PACK: Player %s... recebeu %d cartas (pacote #%d, seed %s)", shortID(ev.PlayerId), len(ev.CardIds), ev.Derivation.PackageCounter, shortID(common.Bytes2Hex(ev.Derivation.Seed[:])))
+			allLogs = append(allLogs, LogEntry{Timestamp: ev.Timestamp.Uint64(), Message: msg})
+		}
+	}
+
+	iterSeeds, err := bc.contract.FilterAuditSeedCommitted(opts)
+	if err == nil {
+		for iterSeeds.Next() {
+			ev := iterSeeds.Event
+			msg := fmt.Sprintf("SEED: Shop publicou o compromisso %s", shortID(common.Bytes2Hex(ev.Commitment[:])))
 			allLogs = append(allLogs, LogEntry{Timestamp: ev.Timestamp.Uint64(), Message: msg})
 		}
 	}
@@ -111,11 +185,35 @@ func (bc *BlockchainClient) GetAuditReport() (string, error) {
 		}
 	}
 
+	iterSwaps, err := bc.contract.FilterAuditSwap(opts)
+	if err == nil {
+		for iterSwaps.Next() {
+			ev := iterSwaps.Event
+			msg := fmt.Sprintf("SWAP: %s (%s) <-> %s (%s)", shortID(ev.PlayerA), ev.CardA, shortID(ev.PlayerB), ev.CardB)
+			allLogs = append(allLogs, LogEntry{Timestamp: ev.Timestamp.Uint64(), Message: msg})
+		}
+	}
+
+	iterBundles, err := bc.contract.FilterAuditBundleSwap(opts)
+	if err == nil {
+		for iterBundles.Next() {
+			ev := iterBundles.Event
+			msg := fmt.Sprintf("BUNDLE: %s (%d cartas) <-> %s (%d cartas)", shortID(ev.PlayerA), len(ev.CardsA), shortID(ev.PlayerB), len(ev.CardsB))
+			allLogs = append(allLogs, LogEntry{Timestamp: ev.Timestamp.Uint64(), Message: msg})
+		}
+	}
+
 	iterMatches, err := bc.contract.FilterAuditMatch(opts)
 	if err == nil {
 		for iterMatches.Next() {
 			ev := iterMatches.Event
 			msg := fmt.Sprintf("MATCH: Sala %s | Vencedor: %s", shortID(ev.RoomId), shortID(ev.WinnerId))
+			if ev.WinnerId == "" {
+				msg = fmt.Sprintf("MATCH: Sala %s | Empate", shortID(ev.RoomId))
+			}
+			if ev.Reason != "" {
+				msg += " | Motivo: " + ev.Reason
+			}
 			allLogs = append(allLogs, LogEntry{Timestamp: ev.Timestamp.Uint64(), Message: msg})
 		}
 	}
@@ -137,53 +235,144 @@ func (bc *BlockchainClient) GetAuditReport() (string, error) {
 	return sb.String(), nil
 }
 
-// Helper para enviar e aguardar mineração
-func (bc *BlockchainClient) sendAndWait(txFunc func() error) error {
-	return nil 
+// transact assina a escrita com o próximo nonce, envia e espera o recibo. O nonce
+// vem de PendingNonceAt, então a leitura e o envio ficam sob txMu; depois do envio
+// o nó já conta a transação pendente e a espera pelo recibo pode rodar em paralelo.
+func (bc *BlockchainClient) transact(build func(opts *bind.TransactOpts) (*types.Transaction, error)) (*types.Receipt, error) {
+	bc.txMu.Lock()
+	nonce, err := bc.client.PendingNonceAt(context.Background(), bc.auth.From)
+	if err != nil {
+		bc.txMu.Unlock()
+		return nil, fmt.Errorf("%w: failed to read the nonce: %v", ErrNotRecorded, err)
+	}
+	opts := *bc.auth
+	opts.Nonce = new(big.Int).SetUint64(nonce)
+	opts.NoSend = true // Só assina: o envio é feito abaixo, para separar erro de montagem de erro de envio
+	tx, err := build(&opts)
+	if err != nil {
+		bc.txMu.Unlock()
+		return nil, fmt.Errorf("%w: %v", ErrNotRecorded, err)
+	}
+	err = bc.client.SendTransaction(context.Background(), tx)
+	bc.txMu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to send transaction %s: %w", tx.Hash().Hex(), err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), receiptTimeout)
+	defer cancel()
+	receipt, err := bind.WaitMined(ctx, bc.client, tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the receipt of transaction %s: %w", tx.Hash().Hex(), err)
+	}
+	if receipt.Status == types.ReceiptStatusFailed {
+		return nil, fmt.Errorf("%w: transação falhou (REVERT) no bloco %d", ErrNotRecorded, receipt.BlockNumber)
+	}
+	return receipt, nil
 }
 
-func (bc *BlockchainClient) LogPack(playerId string, uniqueCardIds []string) error {
-	nonce, _ := bc.client.PendingNonceAt(context.Background(), bc.auth.From)
-	bc.auth.Nonce = big.NewInt(int64(nonce))
-	
-	tx, err := bc.contract.LogPackOpening(bc.auth, playerId, uniqueCardIds)
+// LogPack registra a abertura de pacotes revelando a semente e as demais entradas
+// da derivação. O contrato rejeita a transação se sha256(seed) não for o compromisso atual.
+func (bc *BlockchainClient) LogPack(playerId string, uniqueCardIds []string, derivation PackDerivation) error {
+	receipt, err := bc.transact(func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return bc.contract.LogPackOpening(opts, playerId, uniqueCardIds, ledger.JokenpoLedgerPackDerivation{
+			Seed:               derivation.Seed,
+			PackageCounter:     new(big.Int).SetUint64(derivation.PackageCounter),
+			Pity:               new(big.Int).SetUint64(derivation.Pity),
+			Template:           derivation.Template,
+			Catalog:            derivation.Catalog,
+			NextSeedCommitment: derivation.NextSeedCommitment,
+		})
+	})
 	if err != nil { return err }
 
-    // Aguarda mineração
-    receipt, err := bind.WaitMined(context.Background(), bc.client, tx)
-    if err != nil { return err }
-    if receipt.Status == 0 { return fmt.Errorf("transação falhou (REVERT)") }
-    
 	log.Printf("[Blockchain] LogPack Confirmado! Bloco: %d", receipt.BlockNumber)
 	return nil
 }
 
-func (bc *BlockchainClient) LogTrade(from, to, cardId string) error {
-	nonce, _ := bc.client.PendingNonceAt(context.Background(), bc.auth.From)
-	bc.auth.Nonce = big.NewInt(int64(nonce))
+// PackSeedCommitment lê o compromisso de semente atualmente aceito pelo contrato.
+func (bc *BlockchainClient) PackSeedCommitment() ([32]byte, error) {
+	return bc.contract.PackSeedCommitment(&bind.CallOpts{Context: context.Background()})
+}
 
-	tx, err := bc.contract.LogTrade(bc.auth, from, to, cardId)
+// CommitPackSeed publica o compromisso da semente que o Shop vai usar a seguir.
+func (bc *BlockchainClient) CommitPackSeed(commitment [32]byte) error {
+	_, err := bc.transact(func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return bc.contract.CommitPackSeed(opts, commitment)
+	})
 	if err != nil { return err }
-
-    receipt, err := bind.WaitMined(context.Background(), bc.client, tx)
-    if err != nil { return err }
-    if receipt.Status == 0 { return fmt.Errorf("transação falhou (REVERT)") }
+	log.Printf("[Blockchain] Compromisso de semente publicado: %s", shortID(common.Bytes2Hex(commitment[:])))
 	return nil
 }
 
-func (bc *BlockchainClient) LogMatch(roomId, winnerId, loserId string) error {
-	nonce, _ := bc.client.PendingNonceAt(context.Background(), bc.auth.From)
-	bc.auth.Nonce = big.NewInt(int64(nonce))
+// PackAudits retorna todos os eventos AuditPackOpened, em ordem cronológica,
+// para que as cartas possam ser recalculadas a partir das derivações.
+func (bc *BlockchainClient) PackAudits() ([]PackAudit, error) {
+	iter, err := bc.contract.FilterAuditPackOpened(&bind.FilterOpts{Start: 0, Context: context.Background()})
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	var audits []PackAudit
+	for iter.Next() {
+		ev := iter.Event
+		d := ev.Derivation
+		audits = append(audits, PackAudit{
+			Timestamp: ev.Timestamp.Uint64(),
+			PlayerID:  ev.PlayerId,
+			CardIDs:   ev.CardIds,
+			Derivation: PackDerivation{
+				Seed:               d.Seed,
+				PackageCounter:     d.PackageCounter.Uint64(),
+				Pity:               d.Pity.Uint64(),
+				Template:           d.Template,
+				Catalog:            d.Catalog,
+				NextSeedCommitment: d.NextSeedCommitment,
+			},
+		})
+	}
+	return audits, iter.Error()
+}
+
+func (bc *BlockchainClient) LogTrade(from, to, cardId string) error {
+	_, err := bc.transact(func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return bc.contract.LogTrade(opts, from, to, cardId)
+	})
+	return err
+}
 
-	tx, err := bc.contract.LogMatchResult(bc.auth, roomId, winnerId, loserId)
+// LogSwap registra a troca de tokenA (de playerA) por tokenB (de playerB) numa única
+// transação: o contrato confere as duas posses e reverte tudo se uma delas falhar.
+// Só um erro com ErrNotRecorded garante que a troca não entrou.
+func (bc *BlockchainClient) LogSwap(playerA, playerB, tokenA, tokenB string) error {
+	_, err := bc.transact(func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return bc.contract.LogSwap(opts, playerA, playerB, tokenA, tokenB)
+	})
+	return err
+}
+
+// LogBundleSwap registra a troca de dois pacotes de tokens numa única transação:
+// ou todas as cartas mudam de dono, ou nenhuma muda. Só um erro com ErrNotRecorded
+// garante que a troca não entrou.
+func (bc *BlockchainClient) LogBundleSwap(playerA, playerB string, tokensA, tokensB []string) error {
+	receipt, err := bc.transact(func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return bc.contract.LogBundleSwap(opts, playerA, playerB, tokensA, tokensB)
+	})
 	if err != nil { return err }
-    
-    receipt, err := bind.WaitMined(context.Background(), bc.client, tx)
-    if err != nil { return err }
-    if receipt.Status == 0 { return fmt.Errorf("transação falhou (REVERT)") }
+	log.Printf("[Blockchain] BundleSwap Confirmado! Bloco: %d", receipt.BlockNumber)
 	return nil
 }
 
+// LogMatch registra o resultado de uma partida e o motivo do fim (vitória,
+// desistência, empate combinado...). winnerId e loserId vazios = empate.
+func (bc *BlockchainClient) LogMatch(roomId, winnerId, loserId, reason string) error {
+	_, err := bc.transact(func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return bc.contract.LogMatchResult(opts, roomId, winnerId, loserId, reason)
+	})
+	return err
+}
+
 func shortID(id string) string {
 	if len(id) > 8 { return id[:8] + "..." }
 	return id
@@ -213,4 +402,33 @@ func (bc *BlockchainClient) FindTokenForCard(playerID, cardKey string) (string,
 
     return "", fmt.Errorf("token não encontrado na blockchain para a carta %s do jogador %s", cardKey, playerID)
 }
+
+// FindTokensForCards resolve uma lista de cartas genéricas para tokens distintos do jogador.
+// Cartas repetidas na lista recebem tokens diferentes (ex: duas "rock:1:red" -> dois UUIDs).
+func (bc *BlockchainClient) FindTokensForCards(playerID string, cardKeys []string) ([]string, error) {
+	assets, err := bc.PlayerAssets(playerID)
+	if err != nil {
+		return nil, err
+	}
+
+	used := make(map[int]bool, len(cardKeys))
+	tokens := make([]string, 0, len(cardKeys))
+	for _, cardKey := range cardKeys {
+		prefix := cardKey + "#"
+		found := false
+		for i, token := range assets {
+			if !used[i] && strings.HasPrefix(token, prefix) {
+				used[i] = true
+				tokens = append(tokens, token)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("token não encontrado na blockchain para a carta %s do jogador %s", cardKey, playerID)
+		}
+	}
+	return tokens, nil
+}
+
 //END OF FILE jokenpo/internal/services/blockchain/client.go
\ No newline at end of file
@@ -0,0 +1,106 @@
+//START OF FILE jokenpo/internal/services/blockchain/reconcile.go
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"jokenpo/internal/game/card"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+)
+
+// CollectionDrift é a diferença entre a coleção que o Session tem de um jogador
+// e os tokens que o contrato registra para ele.
+type CollectionDrift struct {
+	PlayerID      string
+	LocalOnly     map[string]uint // cardKey -> cópias que existem só localmente
+	ChainOnly     []string        // tokens "cardKey#uuid" que existem só na blockchain
+	UnknownTokens []string        // tokens cuja carta não está no catálogo carregado
+}
+
+// InSync indica que a coleção local e o ledger batem.
+func (d *CollectionDrift) InSync() bool {
+	return len(d.LocalOnly) == 0 && len(d.ChainOnly) == 0 && len(d.UnknownTokens) == 0
+}
+
+func (d *CollectionDrift) String() string {
+	if d.InSync() {
+		return "Collection matches the ledger."
+	}
+	var sb strings.Builder
+	keys := make([]string, 0, len(d.LocalOnly))
+	for key := range d.LocalOnly {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		sb.WriteString(fmt.Sprintf("LOCAL ONLY: %s x%d\n", key, d.LocalOnly[key]))
+	}
+	for _, token := range d.ChainOnly {
+		sb.WriteString(fmt.Sprintf("CHAIN ONLY: %s\n", token))
+	}
+	for _, token := range d.UnknownTokens {
+		sb.WriteString(fmt.Sprintf("UNKNOWN CARD: %s\n", token))
+	}
+	return sb.String()
+}
+
+// PlayerAssets devolve os tokens "cardKey#uuid" que o contrato registra para o jogador.
+func (bc *BlockchainClient) PlayerAssets(playerID string) ([]string, error) {
+	opts := &bind.CallOpts{Context: context.Background()}
+	assets, err := bc.contract.GetPlayerAssets(opts, playerID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler ativos da blockchain: %v", err)
+	}
+	return assets, nil
+}
+
+// Reconcile reconstrói a coleção do jogador a partir do ledger e a compara com a
+// coleção local (que pode ser nil). A coleção devolvida contém só cartas do catálogo.
+func (bc *BlockchainClient) Reconcile(playerID string, local *card.PlayerCollection) (*card.PlayerCollection, *CollectionDrift, error) {
+	assets, err := bc.PlayerAssets(playerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var localCounts map[string]uint
+	if local != nil {
+		localCounts = local.Counts()
+	}
+	rebuilt, drift := ReconcileTokens(playerID, assets, localCounts)
+	return rebuilt, drift, nil
+}
+
+// ReconcileTokens faz o trabalho do Reconcile sem acessar a rede: monta a coleção a
+// partir dos tokens e compara, carta a carta, com as contagens locais. Quando há
+// mais tokens que cópias locais, os tokens excedentes entram em ChainOnly.
+func ReconcileTokens(playerID string, tokens []string, localCounts map[string]uint) (*card.PlayerCollection, *CollectionDrift) {
+	rebuilt := card.NewPlayerCollection()
+	drift := &CollectionDrift{PlayerID: playerID, LocalOnly: make(map[string]uint)}
+
+	tokensByKey := make(map[string][]string)
+	for _, token := range tokens {
+		key, _, _ := strings.Cut(token, "#")
+		if err := rebuilt.AddCard(key, 1); err != nil {
+			drift.UnknownTokens = append(drift.UnknownTokens, token)
+			continue
+		}
+		tokensByKey[key] = append(tokensByKey[key], token)
+	}
+
+	for key, count := range localCounts {
+		if onChain := uint(len(tokensByKey[key])); count > onChain {
+			drift.LocalOnly[key] = count - onChain
+		}
+	}
+	for key, keyTokens := range tokensByKey {
+		if local := int(localCounts[key]); len(keyTokens) > local {
+			drift.ChainOnly = append(drift.ChainOnly, keyTokens[local:]...)
+		}
+	}
+	sort.Strings(drift.ChainOnly)
+	return rebuilt, drift
+}
+
+//END OF FILE jokenpo/internal/services/blockchain/reconcile.go
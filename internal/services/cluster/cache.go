@@ -46,7 +46,7 @@ func (sc *ServiceCacheActor) run() {
 }
 
 func (sc *ServiceCacheActor) internalDiscover(serviceName string, opts DiscoveryOptions) string {
-	cacheKey := fmt.Sprintf("%s-%d-%s", serviceName, opts.Mode, opts.SpecificID)
+	cacheKey := fmt.Sprintf("%s-%d-%s-%s", serviceName, opts.Mode, opts.SpecificID, opts.CatalogFingerprint)
 
 	// Primeiro tenta o cache
 	sc.mu.RLock()
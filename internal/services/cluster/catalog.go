@@ -0,0 +1,108 @@
+//START OF FILE jokenpo/internal/services/cluster/catalog.go
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// CatalogFingerprintHeader é o header HTTP em que cada serviço envia (e devolve)
+// o fingerprint do seu catálogo de cartas.
+const CatalogFingerprintHeader = "X-Catalog-Fingerprint"
+
+// CatalogMetaKey é a chave do Meta do registro no Consul onde o fingerprint é publicado.
+const CatalogMetaKey = "catalog_fingerprint"
+
+// CatalogPolicyEnv define o que fazer quando os catálogos não batem: "reject" (padrão) ou "warn".
+const CatalogPolicyEnv = "CATALOG_MISMATCH_POLICY"
+
+type CatalogPolicy string
+
+const (
+	CatalogPolicyReject CatalogPolicy = "reject"
+	CatalogPolicyWarn   CatalogPolicy = "warn"
+)
+
+// CatalogMismatchResponse é o corpo retornado (409 Conflict) quando uma chamada é recusada.
+type CatalogMismatchResponse struct {
+	Error    string `json:"error"`
+	Local    string `json:"localFingerprint"`
+	Received string `json:"receivedFingerprint"`
+}
+
+// CatalogGuard compara o fingerprint local com o do outro lado de uma chamada HTTP.
+// É usado tanto no lado servidor (Middleware) quanto no lado cliente (Stamp/CheckResponse).
+type CatalogGuard struct {
+	fingerprint string
+	policy      CatalogPolicy
+}
+
+// NewCatalogGuard cria um guard para o fingerprint local, lendo a política de CATALOG_MISMATCH_POLICY.
+func NewCatalogGuard(fingerprint string) *CatalogGuard {
+	policy := CatalogPolicy(strings.ToLower(os.Getenv(CatalogPolicyEnv)))
+	if policy != CatalogPolicyWarn {
+		policy = CatalogPolicyReject
+	}
+	return &CatalogGuard{fingerprint: fingerprint, policy: policy}
+}
+
+func (g *CatalogGuard) Fingerprint() string   { return g.fingerprint }
+func (g *CatalogGuard) Policy() CatalogPolicy { return g.policy }
+
+// Middleware protege um handler: chamadas com fingerprint diferente do local são
+// recusadas com 409 (política "reject") ou apenas logadas (política "warn").
+// Chamadas sem o header (clientes antigos) passam, mas geram um aviso no log.
+func (g *CatalogGuard) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(CatalogFingerprintHeader, g.fingerprint)
+
+		received := r.Header.Get(CatalogFingerprintHeader)
+		if received == "" {
+			log.Printf("[CatalogGuard] WARN: %s %s sem header %s. Não é possível validar o catálogo.", r.Method, r.URL.Path, CatalogFingerprintHeader)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if received != g.fingerprint {
+			if g.policy == CatalogPolicyReject {
+				log.Printf("[CatalogGuard] Recusando %s %s: catálogo local %s, recebido %s.", r.Method, r.URL.Path, g.fingerprint, received)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusConflict)
+				json.NewEncoder(w).Encode(CatalogMismatchResponse{
+					Error:    "card catalog mismatch between services",
+					Local:    g.fingerprint,
+					Received: received,
+				})
+				return
+			}
+			log.Printf("[CatalogGuard] WARN: %s %s com catálogo divergente (local %s, recebido %s).", r.Method, r.URL.Path, g.fingerprint, received)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Stamp adiciona o fingerprint local a uma requisição de saída.
+func (g *CatalogGuard) Stamp(req *http.Request) {
+	req.Header.Set(CatalogFingerprintHeader, g.fingerprint)
+}
+
+// CheckResponse verifica a resposta de uma chamada feita com Stamp.
+// Retorna um erro explicativo se o outro serviço recusou por divergência de catálogo.
+// Em modo "warn", uma divergência aceita pelo servidor apenas gera log.
+func (g *CatalogGuard) CheckResponse(resp *http.Response) error {
+	remote := resp.Header.Get(CatalogFingerprintHeader)
+	if resp.StatusCode == http.StatusConflict && remote != "" {
+		return fmt.Errorf("card catalog mismatch: this service uses catalog %s but %s uses %s (rolling deploy in progress?)",
+			g.fingerprint, resp.Request.URL.Host, remote)
+	}
+	if remote != "" && remote != g.fingerprint {
+		log.Printf("[CatalogGuard] WARN: %s respondeu com catálogo divergente (local %s, remoto %s).", resp.Request.URL.Host, g.fingerprint, remote)
+	}
+	return nil
+}
+
+//END OF FILE jokenpo/internal/services/cluster/catalog.go
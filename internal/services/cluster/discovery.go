@@ -21,6 +21,9 @@ const (
 type DiscoveryOptions struct {
 	Mode       DiscoveryMode
 	SpecificID string
+	// CatalogFingerprint, se definido, restringe o ModeAnyHealthy às instâncias
+	// que publicaram o mesmo fingerprint de catálogo no Consul.
+	CatalogFingerprint string
 }
 
 func Discover(serviceName string, consulAddrs string, opts DiscoveryOptions) string {
@@ -43,7 +46,7 @@ func discoverWithClient(client *consul.Client, serviceName string, opts Discover
 		}
 		return discoverSpecific(client, serviceName, opts.SpecificID)
 	default: // ModeAnyHealthy
-		return discoverAnyHealthy(client, serviceName)
+		return discoverAnyHealthy(client, serviceName, opts.CatalogFingerprint)
 	}
 }
 
@@ -75,12 +78,48 @@ func discoverSpecific(client *consul.Client, serviceName string, nodeID string)
 	return ""
 }
 
-func discoverAnyHealthy(client *consul.Client, serviceName string) string {
+// InstanceHealthy diz se a instância addr ("host:porta") do serviço está registrada
+// e saudável no Consul. Um erro quer dizer que não foi possível saber.
+func InstanceHealthy(manager *ConsulManager, serviceName, addr string) (bool, error) {
+	client := manager.GetClient()
+	if client == nil {
+		return false, fmt.Errorf("consul client is not available")
+	}
+	services, _, err := client.Health().Service(serviceName, "", true, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to read health of '%s': %w", serviceName, err)
+	}
+	for _, s := range services {
+		host := s.Service.Address
+		if host == "" {
+			host = s.Node.Address
+		}
+		if fmt.Sprintf("%s:%d", host, s.Service.Port) == addr {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func discoverAnyHealthy(client *consul.Client, serviceName string, fingerprint string) string {
 	services, _, err := client.Health().Service(serviceName, "", true, nil)
 	if err != nil || len(services) == 0 {
 		log.Printf("AVISO: Nenhum serviço saudável para '%s' encontrado: %v", serviceName, err)
 		return ""
 	}
+	if fingerprint != "" {
+		compatible := services[:0]
+		for _, s := range services {
+			if s.Service.Meta[CatalogMetaKey] == fingerprint {
+				compatible = append(compatible, s)
+			}
+		}
+		if len(compatible) == 0 {
+			log.Printf("AVISO: Nenhuma instância de '%s' com o catálogo %s (%d instâncias saudáveis com outro catálogo).", serviceName, fingerprint, len(services))
+			return ""
+		}
+		services = compatible
+	}
 	r := rand.New(rand.NewSource(time.Now().UnixNano()))
 	s := services[r.Intn(len(services))]
 	addr := s.Service.Address
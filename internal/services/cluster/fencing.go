@@ -0,0 +1,117 @@
+//START OF FILE jokenpo/internal/services/cluster/fencing.go
+package cluster
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// FencingTokenHeader carrega o fencing token do líder que fez a chamada
+// (veja LeaderElector.FencingToken).
+const FencingTokenHeader = "X-Fencing-Token"
+
+// FenceGuard protege endpoints que só o líder de um serviço deve chamar. Ele guarda
+// o maior token já visto e recusa chamadas com token menor: são de um líder antigo
+// que ainda não percebeu que perdeu o lock. Chamadas sem token também são recusadas.
+//
+// Antes da primeira chamada o guard é semeado com o token do líder atual (Seed),
+// então uma instância recém-iniciada não aceita um líder antigo só por não ter
+// visto o novo ainda.
+type FenceGuard struct {
+	service string
+	manager *ConsulManager
+	highest atomic.Uint64
+
+	seedMu sync.Mutex
+	seeded atomic.Bool
+}
+
+func NewFenceGuard(service string, manager *ConsulManager) *FenceGuard {
+	return &FenceGuard{service: service, manager: manager}
+}
+
+// Seed lê no Consul a session que segura o lock do líder do serviço e admite o
+// CreateIndex dela, o mesmo número que o LeaderElector usa como token. Sem líder
+// no momento, o guard fica semeado com 0. Só consulta o Consul até dar certo uma vez.
+func (g *FenceGuard) Seed() error {
+	g.seedMu.Lock()
+	defer g.seedMu.Unlock()
+	if g.seeded.Load() {
+		return nil
+	}
+	client := g.manager.GetClient()
+	if client == nil {
+		return fmt.Errorf("consul client is not available")
+	}
+	pair, _, err := client.KV().Get(fmt.Sprintf(leaderKeyPrefix, g.service), nil)
+	if err != nil {
+		return fmt.Errorf("failed to read leader key of '%s': %w", g.service, err)
+	}
+	if pair != nil && pair.Session != "" {
+		info, _, err := client.Session().Info(pair.Session, nil)
+		if err != nil {
+			return fmt.Errorf("failed to read leader session of '%s': %w", g.service, err)
+		}
+		if info != nil {
+			g.Admit(info.CreateIndex)
+		}
+	}
+	g.seeded.Store(true)
+	log.Printf("[FenceGuard] Guard de '%s' semeado com o token %d.", g.service, g.highest.Load())
+	return nil
+}
+
+// StampFencingToken adiciona o token a uma requisição de saída. Token 0 (não-líder) não é enviado.
+func StampFencingToken(req *http.Request, token uint64) {
+	if token != 0 {
+		req.Header.Set(FencingTokenHeader, strconv.FormatUint(token, 10))
+	}
+}
+
+// Admit registra o token e diz se a chamada pode seguir.
+func (g *FenceGuard) Admit(token uint64) bool {
+	for {
+		current := g.highest.Load()
+		if token < current {
+			return false
+		}
+		if token == current || g.highest.CompareAndSwap(current, token) {
+			return true
+		}
+	}
+}
+
+// Middleware recusa com 412 as chamadas sem token ou de líderes antigos. Se o
+// guard ainda não foi semeado e o Consul não responde, recusa com 503.
+func (g *FenceGuard) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := g.Seed(); err != nil {
+			log.Printf("[FenceGuard] Recusando %s %s: guard de '%s' sem semente: %v", r.Method, r.URL.Path, g.service, err)
+			http.Error(w, `{"error": "Fencing guard is not ready"}`, http.StatusServiceUnavailable)
+			return
+		}
+		raw := r.Header.Get(FencingTokenHeader)
+		if raw == "" {
+			log.Printf("[FenceGuard] Recusando %s %s: sem header %s.", r.Method, r.URL.Path, FencingTokenHeader)
+			http.Error(w, `{"error": "Missing fencing token"}`, http.StatusPreconditionFailed)
+			return
+		}
+		token, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			http.Error(w, `{"error": "Invalid fencing token"}`, http.StatusBadRequest)
+			return
+		}
+		if !g.Admit(token) {
+			log.Printf("[FenceGuard] Recusando %s %s: token %d de um líder antigo de '%s' (atual: %d).", r.Method, r.URL.Path, token, g.service, g.highest.Load())
+			http.Error(w, `{"error": "Stale leader: fencing token is outdated"}`, http.StatusPreconditionFailed)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+//END OF FILE jokenpo/internal/services/cluster/fencing.go
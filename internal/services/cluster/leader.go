@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -30,6 +31,12 @@ type LeaderElector struct {
 	leaderKey     string
 	stateKey      string
 	isLeader      atomic.Bool
+
+	// Fencing: a session do Consul que segura o lock e o token derivado dela.
+	// O token é o CreateIndex da session, que só cresce a cada nova liderança.
+	fenceMu      sync.RWMutex
+	sessionID    string
+	fencingToken uint64
 }
 
 func NewLeaderElector(serviceName string, manager *ConsulManager, nodeID string) (*LeaderElector, error) {
@@ -52,6 +59,24 @@ func (e *LeaderElector) IsLeader() bool {
 	return e.isLeader.Load()
 }
 
+// FencingToken devolve o token da liderança atual (0 se este nó não for líder).
+// Serviços que recebem escritas do líder usam o token para recusar líderes antigos.
+func (e *LeaderElector) FencingToken() uint64 {
+	if !e.IsLeader() {
+		return 0
+	}
+	e.fenceMu.RLock()
+	defer e.fenceMu.RUnlock()
+	return e.fencingToken
+}
+
+func (e *LeaderElector) setFence(sessionID string, token uint64) {
+	e.fenceMu.Lock()
+	defer e.fenceMu.Unlock()
+	e.sessionID = sessionID
+	e.fencingToken = token
+}
+
 func (e *LeaderElector) RunForLeadership(service StatefulService) {
 	for {
 		log.Printf("[%s Elector] Starting new leadership campaign.", e.serviceName)
@@ -63,7 +88,7 @@ func (e *LeaderElector) RunForLeadership(service StatefulService) {
 			continue
 		}
 
-		lockLostCh, err := e.acquireLock(client)
+		lockLostCh, sessionID, token, err := e.acquireLock(client)
 		if err != nil {
 			log.Printf("[%s Elector] Failed to acquire lock: %v. Retrying in 10s...", e.serviceName, err)
 			service.OnBecomeFollower()
@@ -75,6 +100,8 @@ func (e *LeaderElector) RunForLeadership(service StatefulService) {
 		log.Printf("**************************************************")
 		log.Printf("***** This node (%s) is now the LEADER for service '%s' *****", e.nodeID, e.serviceName)
 		log.Println("**************************************************")
+		log.Printf("[%s Elector] Fencing token: %d", e.serviceName, token)
+		e.setFence(sessionID, token)
 		e.isLeader.Store(true)
 		e.restoreState(service)
 		service.OnBecomeLeader()
@@ -84,10 +111,11 @@ func (e *LeaderElector) RunForLeadership(service StatefulService) {
 		log.Printf("[%s Elector] Leadership lost. Becoming follower.", e.serviceName)
 		service.OnBecomeFollower()
 		e.isLeader.Store(false)
+		e.setFence("", 0)
 	}
 }
 
-func (e *LeaderElector) acquireLock(client *consul.Client) (<-chan struct{}, error) {
+func (e *LeaderElector) acquireLock(client *consul.Client) (<-chan struct{}, string, uint64, error) {
 	// 1) Cria uma session explícita com TTL
 	se := &consul.SessionEntry{
 		Name:     fmt.Sprintf("%s-leader-session", e.serviceName),
@@ -97,7 +125,7 @@ func (e *LeaderElector) acquireLock(client *consul.Client) (<-chan struct{}, err
 
 	sessionID, _, err := client.Session().Create(se, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create session: %w", err)
+		return nil, "", 0, fmt.Errorf("failed to create session: %w", err)
 	}
 
 	// 2) Cria o lock usando a session criada
@@ -110,7 +138,7 @@ func (e *LeaderElector) acquireLock(client *consul.Client) (<-chan struct{}, err
 	if err != nil {
 		// tenta destruir a session criada em caso de erro
 		_, _ = client.Session().Destroy(sessionID, nil)
-		return nil, fmt.Errorf("failed to create lock: %w", err)
+		return nil, "", 0, fmt.Errorf("failed to create lock: %w", err)
 	}
 
 	// 3) Tenta adquirir o lock (bloqueante até adquirir ou erro)
@@ -119,7 +147,15 @@ func (e *LeaderElector) acquireLock(client *consul.Client) (<-chan struct{}, err
 		// cleanup
 		_ = lock.Unlock()
 		_, _ = client.Session().Destroy(sessionID, nil)
-		return nil, fmt.Errorf("failed to acquire lock: %w", err)
+		return nil, "", 0, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+
+	// O CreateIndex da session é o fencing token desta liderança.
+	info, _, err := client.Session().Info(sessionID, nil)
+	if err != nil || info == nil {
+		_ = lock.Unlock()
+		_, _ = client.Session().Destroy(sessionID, nil)
+		return nil, "", 0, fmt.Errorf("failed to read session for fencing token: %v", err)
 	}
 
 	log.Printf("[%s Elector] 🔒 Lock adquirido com sucesso (session=%s) para chave '%s'", e.serviceName, sessionID, e.leaderKey)
@@ -162,7 +198,7 @@ func (e *LeaderElector) acquireLock(client *consul.Client) (<-chan struct{}, err
 	}()
 
 	// Retornamos o canal que será fechado quando o lock for perdido.
-	return lockCh, nil
+	return lockCh, sessionID, info.CreateIndex, nil
 }
 
 func (e *LeaderElector) restoreState(service StatefulService) {
@@ -198,11 +234,23 @@ func (e *LeaderElector) PersistState(service StatefulService) error {
 	if err != nil {
 		return fmt.Errorf("failed to marshal state: %w", err)
 	}
-	kvPair := &consul.KVPair{Key: e.stateKey, Value: data}
-	_, err = client.KV().Put(kvPair, nil)
+
+	// A escrita só vale se a nossa session ainda segura o lock de líder. Um líder
+	// antigo (pausado, particionado) tem a escrita recusada mesmo achando que lidera.
+	e.fenceMu.RLock()
+	sessionID, token := e.sessionID, e.fencingToken
+	e.fenceMu.RUnlock()
+	ops := consul.TxnOps{
+		{KV: &consul.KVTxnOp{Verb: consul.KVCheckSession, Key: e.leaderKey, Session: sessionID}},
+		{KV: &consul.KVTxnOp{Verb: consul.KVSet, Key: e.stateKey, Value: data}},
+	}
+	ok, _, _, err := client.Txn().Txn(ops, nil)
 	if err != nil {
 		return fmt.Errorf("failed to write state to Consul: %w", err)
 	}
+	if !ok {
+		return fmt.Errorf("stale leader (fencing token %d): state write rejected", token)
+	}
 	log.Printf("[Leader] Persisted state for '%s'.", e.serviceName)
 	return nil
 }
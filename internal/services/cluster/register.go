@@ -46,6 +46,15 @@ func NewServiceRegistrar(
 	}, nil
 }
 
+// SetMeta adiciona um par chave/valor ao Meta do registro (ex: fingerprint do catálogo).
+// Deve ser chamado antes de Register; o valor é reenviado a cada novo registro.
+func (r *ServiceRegistrar) SetMeta(key, value string) {
+	if r.registration.Meta == nil {
+		r.registration.Meta = make(map[string]string)
+	}
+	r.registration.Meta[key] = value
+}
+
 // Register tenta registrar o serviço usando o cliente Consul atual e ativo.
 // Esta função é projetada para ser chamada múltiplas vezes (inclusive como callback).
 func (r *ServiceRegistrar) Register() {
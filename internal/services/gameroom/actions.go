@@ -0,0 +1,130 @@
+//START OF FILE jokenpo/internal/services/gameroom/actions.go
+package gameroom
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Desistência e empate combinado. As duas terminam a partida pelo GAME_OVER, com
+// rating e registro no ledger como qualquer outro resultado. Uma oferta de empate
+// vale até o fim da rodada em que foi feita; se os dois oferecerem, é empate.
+// Numa série, a desistência entrega a série e o empate combinado empata só o jogo.
+
+// SurrenderAction é a desistência de um jogador.
+type SurrenderAction struct {
+	PlayerID string
+}
+
+// OfferDrawAction é a oferta de empate de um jogador.
+type OfferDrawAction struct {
+	PlayerID string
+}
+
+// AcceptDrawAction aceita a oferta de empate do oponente.
+type AcceptDrawAction struct {
+	PlayerID string
+}
+
+// PlayerActionRequest é o DTO das ações que só levam o jogador
+// (/surrender, /offer-draw e /accept-draw).
+type PlayerActionRequest struct {
+	PlayerID string `json:"playerId"`
+}
+
+// handleSurrender dá a vitória ao oponente de quem desistiu.
+func (gr *GameRoom) handleSurrender(playerID string) {
+	if _, ok := gr.players[playerID]; !ok {
+		return
+	}
+	log.Printf("[GameRoom %s] Player %s surrendered.", gr.ID, playerID)
+	gr.handleForfeit(gr.getOpponentID(playerID), fmt.Sprintf("Player %s surrendered.", playerID))
+}
+
+// handleOfferDraw registra a oferta e avisa o oponente. Se o oponente já tinha
+// oferecido, a oferta vale como aceite.
+func (gr *GameRoom) handleOfferDraw(playerID string) {
+	if _, ok := gr.players[playerID]; !ok {
+		return
+	}
+	if gr.getGameState() == phase_BETWEEN_GAMES {
+		gr.sendEventToPlayer(playerID, "ERROR", map[string]string{"message": "No game is in progress."})
+		return
+	}
+	opponentID := gr.getOpponentID(playerID)
+	switch gr.drawOffer {
+	case opponentID:
+		gr.handleAcceptDraw(playerID)
+		return
+	case playerID:
+		gr.sendEventToPlayer(playerID, "ERROR", map[string]string{"message": "You have already offered a draw this round."})
+		return
+	}
+
+	gr.drawOffer = playerID
+	log.Printf("[GameRoom %s] Player %s offered a draw in round %d.", gr.ID, playerID, gr.round)
+	gr.sendEventToPlayer(playerID, "DRAW_OFFER_SENT", map[string]string{
+		"message": "Draw offered. It stands until the end of this round.",
+	})
+	gr.sendEventToPlayer(opponentID, "DRAW_OFFERED", map[string]string{
+		"message": "Your opponent offers a draw. Accept it before the round ends to finish the match as a draw.",
+	})
+}
+
+// handleAcceptDraw encerra a partida empatada, se houver oferta do oponente.
+func (gr *GameRoom) handleAcceptDraw(playerID string) {
+	if _, ok := gr.players[playerID]; !ok {
+		return
+	}
+	if gr.drawOffer != gr.getOpponentID(playerID) {
+		gr.sendEventToPlayer(playerID, "ERROR", map[string]string{"message": "There is no draw offer to accept."})
+		return
+	}
+	gr.handleGameOver("", "Draw agreed by both players.")
+}
+
+// handlePlayerAction lida com POST /rooms/{id}/surrender, /offer-draw e /accept-draw.
+func handlePlayerAction(w http.ResponseWriter, r *http.Request, room *GameRoom, action string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, fmt.Sprintf(`{"error": "Use POST for /%s action"}`, action), http.StatusMethodNotAllowed)
+		return
+	}
+	var req PlayerActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PlayerID == "" {
+		http.Error(w, fmt.Sprintf(`{"error": "Invalid payload for %s action"}`, action), http.StatusBadRequest)
+		return
+	}
+	if _, ok := room.players[req.PlayerID]; !ok {
+		http.Error(w, `{"error": "Player is not in this room"}`, http.StatusNotFound)
+		return
+	}
+	if room.IsFinished() {
+		http.Error(w, `{"error": "The match is over"}`, http.StatusConflict)
+		return
+	}
+
+	var msg interface{}
+	switch action {
+	case "surrender":
+		msg = SurrenderAction{PlayerID: req.PlayerID}
+	case "offer-draw":
+		msg = OfferDrawAction{PlayerID: req.PlayerID}
+	case "accept-draw":
+		msg = AcceptDrawAction{PlayerID: req.PlayerID}
+	}
+	// Como a presença, estas ações não podem ser descartadas como uma jogada
+	// atrasada: espera a goroutine da sala e responde 503 se ela não atender.
+	select {
+	case room.incoming <- msg:
+		w.WriteHeader(http.StatusAccepted)
+	case <-room.quit:
+		http.Error(w, `{"error": "The match is over"}`, http.StatusGone)
+	case <-time.After(snapshotTimeout):
+		http.Error(w, `{"error": "Room is busy, try again"}`, http.StatusServiceUnavailable)
+	}
+}
+
+//END OF FILE jokenpo/internal/services/gameroom/actions.go
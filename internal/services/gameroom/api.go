@@ -3,11 +3,16 @@ package gameroom
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"jokenpo/internal/game/rules"
+	"jokenpo/internal/services/cluster"
 	"log"
 	"net/http"
 	"os"
 	"strings"
+
+	"github.com/google/uuid"
 )
 
 // ============================================================================
@@ -17,7 +22,11 @@ import (
 // CreateRoomRequest é o DTO que o cliente (jokenpo-session) envia para criar uma sala.
 // Note que usamos a struct InitialPlayerInfo.
 type CreateRoomRequest struct {
+	RoomID      string               `json:"roomId"` // Escolhido pelo Queue antes de a sala ver a entropia
 	PlayerInfos []*InitialPlayerInfo `json:"playerInfos"`
+	Ruleset     string               `json:"ruleset,omitempty"` // Vazio = ruleset padrão ("classic")
+	BestOf      int                  `json:"bestOf,omitempty"`  // Jogos da série (ímpar); 0 ou 1 = partida única
+	Timing      *rules.Timing        `json:"timing,omitempty"`  // Ritmo do modo; ausente = rules.DefaultTiming()
 }
 
 // CreateRoomResponse é o DTO que este serviço retorna após criar a sala.
@@ -37,7 +46,8 @@ type PlayCardRequest struct {
 // ============================================================================
 
 // RegisterHandlers configura todas as rotas da API para o GameRoomService.
-func RegisterHandlers(mux *http.ServeMux, roomManager *RoomManager, port int) {
+// A criação de salas passa pelo CatalogGuard, pois é ali que os decks (chaves de carta) chegam.
+func RegisterHandlers(mux *http.ServeMux, roomManager *RoomManager, port int, catalogGuard *cluster.CatalogGuard, manager *cluster.ConsulManager) {
 	// --- MUDANÇA CRUCIAL ---
 	// Lê o endereço anunciado da mesma variável de ambiente que o registro do Consul.
 	advertiseAddr := os.Getenv("SERVICE_ADVERTISED_HOSTNAME")
@@ -47,9 +57,18 @@ func RegisterHandlers(mux *http.ServeMux, roomManager *RoomManager, port int) {
 		log.Printf("CRITICAL: SERVICE_ADVERTISED_HOSTNAME environment variable is not set!")
 		advertiseAddr = "address-not-configured" // Garante que o problema seja visível
 	}
+	roomManager.serviceAddr = fmt.Sprintf("%s:%d", advertiseAddr, port)
 	
-	// Handler para criar novas salas.
-	mux.HandleFunc("/rooms", handleCreateRoom(roomManager, advertiseAddr, port))
+	// Handler para criar novas salas. Só o líder do Queue cria salas, então o
+	// FenceGuard recusa pedidos de um líder antigo (fencing token menor) ou sem token.
+	// A semente (o token do líder atual) é lida já na subida.
+	fenceGuard := cluster.NewFenceGuard("jokenpo-queue", manager)
+	go func() {
+		if err := fenceGuard.Seed(); err != nil {
+			log.Printf("GAMEROOM AVISO: FenceGuard sem semente na subida (tenta de novo no primeiro pedido): %v", err)
+		}
+	}()
+	mux.Handle("/rooms", fenceGuard.Middleware(catalogGuard.Middleware(handleCreateRoom(roomManager, advertiseAddr, port))))
 	
 	// Handler "coringa" para todas as ações em salas existentes (ex: /rooms/{id}/play).
 	mux.HandleFunc("/rooms/", handleRoomAction(roomManager))
@@ -73,14 +92,46 @@ func handleCreateRoom(rm *RoomManager, advertiseAddr string, port int) http.Hand
 			return
 		}
 		
-		log.Printf("[DEBUG] GameRoom received CreateRoomRequest.")
+		if err := uuid.Validate(req.RoomID); err != nil {
+			http.Error(w, `{"error": "Invalid payload: 'roomId' must be a UUID"}`, http.StatusBadRequest)
+			return
+		}
+
+		ruleset, err := rules.Get(req.Ruleset)
+		if err == nil {
+			_, err = rules.NormalizeBestOf(req.BestOf)
+		}
+		timing := rules.DefaultTiming()
+		if err == nil && req.Timing != nil {
+			timing = *req.Timing
+			err = timing.Validate()
+		}
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		log.Printf("[DEBUG] GameRoom received CreateRoomRequest. Ruleset: %s, best of: %d, timing: %s", ruleset.Name(), req.BestOf, timing)
 		log.Printf("[DEBUG] Player 1 (%s) deck size: %d", req.PlayerInfos[0].ID, len(req.PlayerInfos[0].Deck))
 		log.Printf("[DEBUG] Player 2 (%s) deck size: %d", req.PlayerInfos[1].ID, len(req.PlayerInfos[1].Deck))
 
 		// Chama o RoomManager para criar a sala de forma síncrona.
-		room := rm.CreateRoom(req.PlayerInfos[0], req.PlayerInfos[1])
-		if room == nil {
-			http.Error(w, `{"error": "Failed to create room"}`, http.StatusInternalServerError)
+		// Um pedido repetido (ex: um líder novo do Queue) com o mesmo ID e os mesmos
+		// jogadores recebe a sala que já existe.
+		room, err := rm.CreateRoom(req.RoomID, MatchFormat{Ruleset: ruleset, BestOf: req.BestOf, Timing: timing}, req.PlayerInfos[0], req.PlayerInfos[1])
+		switch {
+		case errors.Is(err, ErrRoomConflict):
+			http.Error(w, `{"error": "Room ID is already in use by other players"}`, http.StatusConflict)
+			return
+		case errors.Is(err, ErrRoomRetired):
+			http.Error(w, `{"error": "Room was already played"}`, http.StatusGone)
+			return
+		case err != nil:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Failed to create room: " + err.Error()})
 			return
 		}
 
@@ -130,13 +181,22 @@ func handleRoomAction(rm *RoomManager) http.HandlerFunc {
 				} else {
 					http.Error(w, `{"error": "Use POST for /play action"}`, http.StatusMethodNotAllowed)
 				}
-			// Futuramente: case "surrender": ...
+			case "events":
+				handleEventStream(w, r, room)
+			case "presence":
+				handlePresenceAction(w, r, room)
+			case "surrender", "offer-draw", "accept-draw":
+				handlePlayerAction(w, r, room, action)
+			case "rematch":
+				handleRematchAction(w, r, room)
+			case "sideboard":
+				handleSideboardAction(w, r, room)
 			default:
 				http.Error(w, `{"error": "Unknown room action"}`, http.StatusNotFound)
 			}
 		} else {
-			// Se for apenas /rooms/{id}, poderia retornar o estado da sala (opcional)
-			http.Error(w, `{"error": "Action required, e.g., /play"}`, http.StatusBadRequest)
+			// /rooms/{id} devolve o snapshot da partida para um jogador (usado na reconexão).
+			handleSnapshot(w, r, room)
 		}
 	}
 }
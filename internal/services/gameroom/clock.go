@@ -0,0 +1,108 @@
+//START OF FILE jokenpo/internal/services/gameroom/clock.go
+package gameroom
+
+import (
+	"time"
+)
+
+// Relógio da sala: cada rodada dá timing.Round a cada jogador. Quem passa disso
+// começa a gastar o próprio banco de tempo (como num relógio de xadrez), e só
+// quando o banco acaba a sala joga uma carta aleatória por ele. O banco é cheio
+// no começo de cada jogo. A pausa entre o resultado de uma rodada e a próxima é
+// um timer no select do Run, então a sala continua atendendo snapshots, presença
+// e desistências nesse meio tempo.
+
+// resetTimeBanks enche o banco de tempo dos jogadores para um jogo novo.
+func (gr *GameRoom) resetTimeBanks() {
+	for _, id := range gr.seats {
+		gr.timeBank[id] = gr.timing.TimeBank()
+	}
+}
+
+// startRoundClock começa a contar o tempo da rodada.
+func (gr *GameRoom) startRoundClock() {
+	gr.roundStart = time.Now()
+	gr.armNextDeadline()
+}
+
+// armNextDeadline arma o roundTimer para o próximo prazo entre os jogadores que
+// ainda não jogaram: o tempo da rodada mais o banco de cada um.
+func (gr *GameRoom) armNextDeadline() {
+	var next time.Time
+	for _, id := range gr.seats {
+		if _, played := gr.playedCards[id]; played {
+			continue
+		}
+		deadline := gr.roundStart.Add(gr.timing.Round() + gr.timeBank[id])
+		if next.IsZero() || deadline.Before(next) {
+			next = deadline
+		}
+	}
+	if next.IsZero() {
+		return
+	}
+	if gr.roundTimer != nil {
+		gr.roundTimer.Stop()
+	}
+	gr.armRoundTimer(max(0, time.Until(next)))
+}
+
+// timeBankLeft é o banco do jogador descontado o que ele já gastou nesta rodada.
+func (gr *GameRoom) timeBankLeft(playerID string, now time.Time) time.Duration {
+	bank := gr.timeBank[playerID]
+	if gr.roundPaused || gr.roundStart.IsZero() || gr.getGameState() != phase_WAITING_FOR_PLAYS {
+		return bank
+	}
+	if _, played := gr.playedCards[playerID]; played {
+		return bank
+	}
+	overtime := now.Sub(gr.roundStart) - gr.timing.Round()
+	return max(0, bank-max(0, overtime))
+}
+
+// chargeTimeBank desconta do banco o tempo que o jogador passou do limite da
+// rodada. Chamado antes da jogada entrar em playedCards.
+func (gr *GameRoom) chargeTimeBank(playerID string, now time.Time) {
+	gr.timeBank[playerID] = gr.timeBankLeft(playerID, now)
+}
+
+// roundTimeLeft é quanto falta do tempo da rodada (sem contar o banco).
+func (gr *GameRoom) roundTimeLeft() time.Duration {
+	if gr.roundPaused || gr.roundStart.IsZero() || gr.getGameState() != phase_WAITING_FOR_PLAYS {
+		return 0
+	}
+	return max(0, time.Until(gr.roundStart.Add(gr.timing.Round())))
+}
+
+// clockFor é o relógio mandado a cada jogador no NEW_ROUND.
+func (gr *GameRoom) clockFor(playerID string) map[string]int64 {
+	now := time.Now()
+	return map[string]int64{
+		"roundMs":            gr.timing.Round().Milliseconds(),
+		"timeBankMs":         gr.timeBankLeft(playerID, now).Milliseconds(),
+		"opponentTimeBankMs": gr.timeBankLeft(gr.getOpponentID(playerID), now).Milliseconds(),
+	}
+}
+
+// scheduleNextRound espera a pausa entre rodadas sem bloquear a goroutine da sala.
+func (gr *GameRoom) scheduleNextRound() {
+	gr.setGameState(phase_ROUND_START)
+	gr.nextRound = time.NewTimer(gr.timing.RoundDelay())
+}
+
+// nextRoundDue devolve o canal da pausa entre rodadas (nil fora dela).
+func (gr *GameRoom) nextRoundDue() <-chan time.Time {
+	if gr.nextRound == nil {
+		return nil
+	}
+	return gr.nextRound.C
+}
+
+func (gr *GameRoom) handleNextRound() {
+	gr.nextRound = nil
+	if gr.getGameState() == phase_ROUND_START {
+		gr.startNewRound()
+	}
+}
+
+//END OF FILE jokenpo/internal/services/gameroom/clock.go
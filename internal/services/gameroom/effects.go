@@ -0,0 +1,125 @@
+//START OF FILE jokenpo/internal/services/gameroom/effects.go
+package gameroom
+
+import (
+	"fmt"
+	"jokenpo/internal/game/card"
+	"jokenpo/internal/game/rules"
+)
+
+// ============================================================================
+// Pilha de Efeitos (Habilidades das Cartas)
+// ============================================================================
+//
+// As habilidades são resolvidas em duas fases por rodada:
+//   1. on_play: antes da comparação (ex: bônus de valor).
+//   2. on_win / on_lose: depois da comparação, antes das cartas irem para WIN/OUT.
+// Dentro de cada fase a ordem é fixa: assento do jogador (ordem do CreateRoomRequest)
+// e depois a ordem da habilidade no catálogo. Assim o resultado nunca depende da
+// ordem de iteração de mapas nem de quem jogou primeiro.
+
+// FiredEffect descreve um efeito que foi de fato aplicado; vai no evento ROUND_RESULT.
+type FiredEffect struct {
+	PlayerID string `json:"playerId"`
+	Card     string `json:"card"`
+	Trigger  string `json:"trigger"`
+	Effect   string `json:"effect"`
+	Detail   string `json:"detail"`
+}
+
+// triggeredEffect é uma entrada da pilha: uma habilidade de uma carta jogada.
+type triggeredEffect struct {
+	playerID string
+	card     *card.Card
+	ability  card.Ability
+}
+
+// pendingDraw é uma compra gerada por efeito, executada depois de WIN/OUT.
+type pendingDraw struct {
+	playerID string
+	amount   int
+}
+
+// roundContext guarda o estado da rodada que os efeitos podem modificar.
+type roundContext struct {
+	contenders map[string]*rules.Contender
+	banished   map[string]bool // jogadores cuja carta jogada vai para OUT mesmo vencendo
+	draws      []pendingDraw
+	fired      []FiredEffect
+}
+
+func (gr *GameRoom) newRoundContext() *roundContext {
+	ctx := &roundContext{
+		contenders: make(map[string]*rules.Contender, len(gr.playedCards)),
+		banished:   make(map[string]bool),
+		fired:      make([]FiredEffect, 0),
+	}
+	for playerID, c := range gr.playedCards {
+		contender := rules.NewContender(c)
+		ctx.contenders[playerID] = &contender
+	}
+	return ctx
+}
+
+// collectEffects monta a pilha de uma fase. 'triggers' diz qual gatilho vale para
+// cada jogador nesta fase (ex: vencedor -> on_win, perdedor -> on_lose).
+func (gr *GameRoom) collectEffects(triggers map[string]string) []triggeredEffect {
+	stack := make([]triggeredEffect, 0)
+	for _, playerID := range gr.getPlayerIDs() {
+		trigger, ok := triggers[playerID]
+		if !ok {
+			continue
+		}
+		played := gr.playedCards[playerID]
+		if played == nil {
+			continue
+		}
+		for _, ability := range played.Abilities() {
+			if ability.Trigger() == trigger {
+				stack = append(stack, triggeredEffect{playerID: playerID, card: played, ability: ability})
+			}
+		}
+	}
+	return stack
+}
+
+// resolveEffects aplica a pilha em ordem sobre o contexto da rodada.
+func (gr *GameRoom) resolveEffects(stack []triggeredEffect, ctx *roundContext) {
+	for _, te := range stack {
+		opponentID := gr.getOpponentID(te.playerID)
+		ability := te.ability
+		var detail string
+
+		switch ability.Effect() {
+		case card.EffectValueBonus:
+			opponent := ctx.contenders[opponentID]
+			if ability.Color() != "" && (opponent == nil || opponent.Card.Color() != ability.Color()) {
+				continue // Condição não satisfeita: o efeito não dispara.
+			}
+			self := ctx.contenders[te.playerID]
+			self.Value += ability.Amount()
+			detail = fmt.Sprintf("%s value is now %d", te.card.Key(), self.Value)
+
+		case card.EffectDraw:
+			ctx.draws = append(ctx.draws, pendingDraw{playerID: te.playerID, amount: ability.Amount()})
+			detail = fmt.Sprintf("draws %d card(s)", ability.Amount())
+
+		case card.EffectBanish:
+			ctx.banished[opponentID] = true
+			detail = fmt.Sprintf("%s goes to OUT", gr.playedCards[opponentID].Key())
+
+		default:
+			continue
+		}
+
+		ctx.fired = append(ctx.fired, FiredEffect{
+			PlayerID: te.playerID,
+			Card:     te.card.Key(),
+			Trigger:  ability.Trigger(),
+			Effect:   ability.Effect(),
+			Detail:   detail,
+		})
+	}
+}
+
+//END OF FILE jokenpo/internal/services/gameroom/effects.go
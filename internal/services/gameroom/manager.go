@@ -2,13 +2,17 @@
 package gameroom
 
 import (
+	"context"
+	"errors"
+	"jokenpo/internal/game/rules"
 	"jokenpo/internal/services/blockchain" // Importar
 	"jokenpo/internal/services/cluster"    // Importar
+	"jokenpo/internal/services/outbox"
+	"jokenpo/internal/services/presence"
+	"jokenpo/internal/services/ratings"
 	"log"
-	"net/http"
+	"os"
 	"time"
-
-	"github.com/google/uuid"
 )
 
 // InitialPlayerInfo é o DTO que vem da API para criar uma sala.
@@ -16,16 +20,38 @@ type InitialPlayerInfo struct {
 	ID          string   `json:"playerId"`
 	CallbackURL string   `json:"callbackUrl"`
 	Deck        []string `json:"deck"`
+	Entropy     string   `json:"entropy,omitempty"` // Contribuição do jogador para a semente do embaralhamento
+}
+
+// MatchFormat é como a partida de uma sala é jogada: regras, formato da série e ritmo.
+type MatchFormat struct {
+	Ruleset rules.Ruleset
+	BestOf  int          // Jogos da série; 0 ou 1 = partida única
+	Timing  rules.Timing // Zero = rules.DefaultTiming()
+	Unrated bool         // O resultado não vai para o rating nem para o ledger (ex: revanche, que não passa pela fila)
 }
 
 // RoomManager (o ator) gerencia o ciclo de vida de todas as salas ativas.
 type RoomManager struct {
-	rooms      map[string]*GameRoom
-	requestCh  chan interface{}
-	httpClient *http.Client
-	blockchain *blockchain.BlockchainClient // Novo campo
+	rooms       map[string]*GameRoom
+	requestCh   chan interface{}
+	blockchain  *blockchain.BlockchainClient // Novo campo
+	ratings     *ratings.Store
+	presence    *presence.Store // Localiza o Session dos jogadores para anunciar a revanche
+	serviceAddr string          // host:porta anunciado deste GameRoomService
+	callbacks   *outbox.Outbox  // Entrega o /match-found da revanche com repetição
+	retired     map[string]time.Time // Salas já encerradas e removidas -> quando; recusam ser criadas de novo
 }
 
+// retiredRoomRetention é por quanto tempo o ID de uma sala removida continua
+// recusando um novo POST /rooms (ex: um líder novo do Queue repetindo a criação).
+const retiredRoomRetention = time.Hour
+
+var (
+	ErrRoomConflict = errors.New("a room with this ID already exists for other players")
+	ErrRoomRetired  = errors.New("this room was already played and removed")
+)
+
 // NewRoomManager agora recebe o ConsulManager para localizar o contrato
 func NewRoomManager(manager *cluster.ConsulManager) *RoomManager {
 	var bcClient *blockchain.BlockchainClient
@@ -66,15 +92,37 @@ func NewRoomManager(manager *cluster.ConsulManager) *RoomManager {
 	return &RoomManager{
 		rooms:      make(map[string]*GameRoom),
 		requestCh:  make(chan interface{}),
-		httpClient: &http.Client{Timeout: 10 * time.Second},
 		blockchain: bcClient, // Armazena o cliente
+		ratings:    ratings.NewStore(manager),
+		presence:   presence.NewStore(manager),
+		callbacks:  outbox.New("jokenpo-gameroom/"+instanceName(), manager),
+		retired:    make(map[string]time.Time),
+	}
+}
+
+// instanceName identifica o outbox desta instância: ao reiniciar com o mesmo nome
+// (o hostname do contêiner), ela retoma os anúncios que não chegou a entregar.
+func instanceName() string {
+	if name := os.Getenv("SERVICE_ADVERTISED_HOSTNAME"); name != "" {
+		return name
+	}
+	name, err := os.Hostname()
+	if err != nil {
+		return "unknown"
 	}
+	return name
 }
 
 // --- Mensagens para o Ator RoomManager ---
 type createRoomRequest struct {
+	RoomID      string
 	PlayerInfos []*InitialPlayerInfo
-	reply       chan *GameRoom
+	Format      MatchFormat
+	reply       chan createRoomResult
+}
+type createRoomResult struct {
+	room *GameRoom
+	err  error
 }
 type getRoomRequest struct {
 	roomID string
@@ -84,13 +132,20 @@ type cleanupFinishedRooms struct{}
 
 // --- APIs Públicas do Ator ---
 
-func (rm *RoomManager) CreateRoom(p1, p2 *InitialPlayerInfo) *GameRoom {
-	reply := make(chan *GameRoom)
+// CreateRoom cria a sala roomID. O ID vem de fora (do Queue ou da revanche) porque
+// entra na semente do embaralhamento. A criação é idempotente: repetir o pedido
+// com os mesmos jogadores devolve a sala que já existe. O ID de outro par dá
+// ErrRoomConflict e o de uma sala já encerrada, ErrRoomRetired.
+func (rm *RoomManager) CreateRoom(roomID string, format MatchFormat, p1, p2 *InitialPlayerInfo) (*GameRoom, error) {
+	reply := make(chan createRoomResult)
 	rm.requestCh <- createRoomRequest{
+		RoomID:      roomID,
 		PlayerInfos: []*InitialPlayerInfo{p1, p2},
+		Format:      format,
 		reply:       reply,
 	}
-	return <-reply
+	result := <-reply
+	return result.room, result.err
 }
 
 func (rm *RoomManager) GetRoom(roomID string) *GameRoom {
@@ -109,35 +164,58 @@ func (rm *RoomManager) handleMessage(msg interface{}) {
 
 	switch req := msg.(type) {
 	case createRoomRequest:
-		roomID := uuid.NewString()
+		roomID := req.RoomID
+		if existing, ok := rm.rooms[roomID]; ok {
+			if !existing.hasSeats(req.PlayerInfos) {
+				log.Printf("ERROR: Room %s already exists for other players. Refusing to create it.", roomID)
+				req.reply <- createRoomResult{err: ErrRoomConflict}
+				return
+			}
+			log.Printf("[RoomManager] Room %s already exists for the same players. Returning it.", roomID)
+			req.reply <- createRoomResult{room: existing}
+			return
+		}
+		if _, ok := rm.retired[roomID]; ok {
+			log.Printf("[RoomManager] Room %s was already played and removed. Refusing to create it again.", roomID)
+			req.reply <- createRoomResult{err: ErrRoomRetired}
+			return
+		}
 		// CORREÇÃO DO ERRO: Agora passamos rm.blockchain como 4º argumento
-		room, err := NewGameRoom(roomID, req.PlayerInfos, rm.httpClient, rm.blockchain)
+		room, err := NewGameRoom(roomID, req.Format, req.PlayerInfos, rm.blockchain, rm.ratings)
 		
 		log.Printf("[DEBUG] Created Room %s", roomID)
 		if err != nil {
 			log.Printf("ERROR: Failed to create new game room: %v", err)
-			req.reply <- nil
+			req.reply <- createRoomResult{err: err}
 			return
 		}
+		room.manager = rm
 		rm.rooms[roomID] = room
 		go room.Run()
-		req.reply <- room
+		req.reply <- createRoomResult{room: room}
 
 	case getRoomRequest:
 		req.reply <- rm.rooms[req.roomID]
 
 	case cleanupFinishedRooms:
 		for id, room := range rm.rooms {
-			if room.IsFinished() {
+			if room.expired() {
 				delete(rm.rooms, id)
+				rm.retired[id] = time.Now()
 				log.Printf("[RoomManager] Cleaned up finished room %s", id)
 			}
 		}
+		for id, at := range rm.retired {
+			if time.Since(at) > retiredRoomRetention {
+				delete(rm.retired, id)
+			}
+		}
 	}
 }
 
 func (rm *RoomManager) Run() {
 	log.Println("[RoomManager] Actor started.")
+	go rm.callbacks.Run(context.Background())
 	cleanupTicker := time.NewTicker(1 * time.Minute)
 	defer cleanupTicker.Stop()
 
@@ -0,0 +1,342 @@
+//START OF FILE jokenpo/internal/services/gameroom/reconnect.go
+package gameroom
+
+import (
+	"encoding/json"
+	"fmt"
+	"jokenpo/internal/game/deck"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Reconexão: quando o WebSocket de um jogador cai, o Session avisa a sala
+// (POST /rooms/{id}/presence). A partir daí o jogador tem um período de graça para
+// voltar; enquanto ele estiver fora, uma rodada que expira fica pausada em vez de
+// jogar uma carta aleatória por ele. Se o período acabar, ele perde por W.O.
+// Quem volta pede um snapshot da partida (GET /rooms/{id}?playerId=...) e retoma
+// o stream de eventos a partir do seq do snapshot. O mesmo snapshot atende o
+// VIEW_MATCH do Session e, sem playerId, operadores depurando uma sala.
+
+const (
+	defaultReconnectGrace = 60 * time.Second
+	snapshotTimeout       = 5 * time.Second // Quanto a API espera a goroutine da sala atender
+)
+
+// PresenceAction é a mensagem interna (vinda da API) que avisa que a conexão de
+// um jogador caiu ou voltou.
+type PresenceAction struct {
+	PlayerID  string
+	Connected bool
+}
+
+// PresenceRequest é o DTO de POST /rooms/{id}/presence.
+type PresenceRequest struct {
+	PlayerID  string `json:"playerId"`
+	Connected bool   `json:"connected"`
+}
+
+// RoomSnapshot é o estado da partida. Os campos do jogador (seq, mão, pilhas e
+// jogada) só vêm quando o snapshot é pedido com playerId; sem ele (visão de
+// operador) só aparece o que é público.
+type RoomSnapshot struct {
+	RoomID         string          `json:"roomId"`
+	Phase          string          `json:"phase"`
+	Round          int             `json:"round"`
+	TimeLeftMs     int64           `json:"timeLeftMs"` // Tempo restante da rodada, sem o banco; 0 fora de waiting_for_plays
+	RoundPaused    bool            `json:"roundPaused"`
+	Players        []SeatSnapshot  `json:"players"`        // Na ordem dos assentos
+	Away           []string        `json:"away,omitempty"` // Jogadores desconectados, em período de graça
+	PlayerID       string          `json:"playerId,omitempty"`
+	Seq            uint64          `json:"seq,omitempty"` // Último evento do stream do jogador refletido no snapshot
+	Hand           []string        `json:"hand,omitempty"`
+	WinPile        []string        `json:"winPile,omitempty"`
+	OutPile        []string        `json:"outPile,omitempty"`
+	PlayedCard     string          `json:"playedCard,omitempty"` // Carta que o jogador já jogou nesta rodada
+	OpponentPlayed bool            `json:"opponentPlayed,omitempty"`
+	Series         *SeriesSnapshot `json:"series,omitempty"` // Placar, numa série melhor de N
+}
+
+// SeatSnapshot traz o tamanho das zonas de um jogador, sem revelar as cartas.
+type SeatSnapshot struct {
+	PlayerID   string `json:"playerId"`
+	Deck       int    `json:"deck"`
+	Hand       int    `json:"hand"`
+	Win        int    `json:"win"`
+	Out        int    `json:"out"`
+	Played     bool   `json:"played"` // Já jogou na rodada atual
+	Connected  bool   `json:"connected"`
+	TimeBankMs int64  `json:"timeBankMs"` // Banco de tempo restante no jogo atual
+}
+
+type snapshotQuery struct {
+	playerID string
+	reply    chan RoomSnapshot
+}
+
+// reconnectGraceFromEnv lê GAMEROOM_RECONNECT_GRACE_SECONDS (padrão: 60).
+func reconnectGraceFromEnv() time.Duration {
+	if raw := os.Getenv("GAMEROOM_RECONNECT_GRACE_SECONDS"); raw != "" {
+		if seconds, err := strconv.Atoi(raw); err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+		log.Printf("WARN: Invalid GAMEROOM_RECONNECT_GRACE_SECONDS '%s'. Using %v.", raw, defaultReconnectGrace)
+	}
+	return defaultReconnectGrace
+}
+
+// armRoundTimer inicia o timer da rodada. Quando ele expira vem de armNextDeadline.
+func (gr *GameRoom) armRoundTimer(d time.Duration) {
+	gr.roundPaused = false
+	gr.roundTimer = time.NewTimer(d)
+}
+
+// roundExpired devolve o canal do roundTimer (nil antes da primeira rodada, por
+// exemplo quando um jogo termina já na compra inicial).
+func (gr *GameRoom) roundExpired() <-chan time.Time {
+	if gr.roundTimer == nil {
+		return nil
+	}
+	return gr.roundTimer.C
+}
+
+// handlePresence registra a queda ou a volta de um jogador.
+func (gr *GameRoom) handlePresence(playerID string, connected bool) {
+	if _, ok := gr.players[playerID]; !ok || gr.IsFinished() {
+		return
+	}
+	opponentID := gr.getOpponentID(playerID)
+	_, wasAway := gr.away[playerID]
+
+	if !connected {
+		if wasAway {
+			return
+		}
+		gr.away[playerID] = time.Now()
+		log.Printf("[GameRoom %s] Player %s disconnected. Grace period of %v started.", gr.ID, playerID, gr.grace)
+		gr.sendEventToPlayer(opponentID, "OPPONENT_DISCONNECTED", map[string]interface{}{
+			"message":      fmt.Sprintf("Your opponent disconnected. They have %d seconds to come back.", int(gr.grace.Seconds())),
+			"graceSeconds": int(gr.grace.Seconds()),
+		})
+		gr.scheduleGrace()
+		return
+	}
+
+	if !wasAway {
+		return
+	}
+	delete(gr.away, playerID)
+	log.Printf("[GameRoom %s] Player %s reconnected.", gr.ID, playerID)
+	gr.sendEventToPlayer(opponentID, "OPPONENT_RECONNECTED", map[string]string{
+		"message": "Your opponent is back.",
+	})
+	gr.scheduleGrace()
+
+	if gr.roundPaused && len(gr.away) == 0 && gr.getGameState() == phase_WAITING_FOR_PLAYS {
+		// A rodada recomeça com o tempo cheio; o banco de tempo continua de onde parou.
+		gr.startRoundClock()
+		gr.broadcastEvent("ROUND_RESUMED", map[string]interface{}{
+			"message":    "Both players are connected again. The round continues.",
+			"timeLeftMs": gr.timing.Round().Milliseconds(),
+		})
+	}
+}
+
+// pauseRoundIfDisconnected é chamado quando o timer da rodada expira. Com algum
+// jogador fora, a rodada fica pausada até ele voltar ou perder por W.O.
+func (gr *GameRoom) pauseRoundIfDisconnected() bool {
+	if len(gr.away) == 0 {
+		return false
+	}
+	if !gr.roundPaused {
+		// O banco gasto até aqui fica descontado; o resto espera a volta.
+		now := time.Now()
+		for _, id := range gr.seats {
+			if _, played := gr.playedCards[id]; !played {
+				gr.chargeTimeBank(id, now)
+			}
+		}
+		gr.roundPaused = true
+		gr.roundStart = time.Time{}
+		log.Printf("[GameRoom %s] Round %d paused: waiting for disconnected players.", gr.ID, gr.round)
+		gr.broadcastEvent("ROUND_PAUSED", map[string]string{
+			"message": "The round is paused while a player reconnects.",
+		})
+	}
+	return true
+}
+
+// scheduleGrace reinicia o graceTimer para o próximo fim de período de graça.
+func (gr *GameRoom) scheduleGrace() {
+	if gr.graceTimer != nil {
+		gr.graceTimer.Stop()
+		gr.graceTimer = nil
+	}
+	var next time.Time
+	for _, since := range gr.away {
+		if deadline := since.Add(gr.grace); next.IsZero() || deadline.Before(next) {
+			next = deadline
+		}
+	}
+	if !next.IsZero() {
+		gr.graceTimer = time.NewTimer(time.Until(next))
+	}
+}
+
+// graceExpired devolve o canal do graceTimer (nil, que nunca dispara, sem timer).
+func (gr *GameRoom) graceExpired() <-chan time.Time {
+	if gr.graceTimer == nil {
+		return nil
+	}
+	return gr.graceTimer.C
+}
+
+// handleGraceExpired dá W.O. a quem não voltou a tempo. Se os dois sumiram, a
+// partida é encerrada sem vencedor e sem afetar o rating.
+func (gr *GameRoom) handleGraceExpired() {
+	gr.graceTimer = nil
+	var expired []string
+	for playerID, since := range gr.away {
+		if time.Since(since) >= gr.grace {
+			expired = append(expired, playerID)
+		}
+	}
+	sort.Strings(expired)
+	switch {
+	case len(expired) == 0:
+		gr.scheduleGrace()
+	case len(expired) == len(gr.players):
+		gr.abortGame("Both players disconnected and did not come back.")
+	default:
+		loserID := expired[0]
+		gr.handleForfeit(gr.getOpponentID(loserID), fmt.Sprintf("Player %s disconnected and did not come back in time.", loserID))
+	}
+}
+
+// snapshotFor monta o snapshot visto por playerID ("" = operador). Só é chamado
+// pela goroutine da sala (ou depois que ela terminou).
+func (gr *GameRoom) snapshotFor(playerID string) RoomSnapshot {
+	snapshot := RoomSnapshot{
+		RoomID:      gr.ID,
+		Phase:       gr.getGameState(),
+		Round:       gr.round,
+		RoundPaused: gr.roundPaused,
+		Series:      gr.seriesSnapshot(),
+	}
+	snapshot.TimeLeftMs = gr.roundTimeLeft().Milliseconds()
+	now := time.Now()
+	for _, id := range gr.seats {
+		_, played := gr.playedCards[id]
+		_, away := gr.away[id]
+		gameDeck := gr.players[id].GameDeck
+		snapshot.Players = append(snapshot.Players, SeatSnapshot{
+			PlayerID:   id,
+			Deck:       zoneSize(gameDeck, deck.DECK),
+			Hand:       zoneSize(gameDeck, deck.HAND),
+			Win:        zoneSize(gameDeck, deck.WIN),
+			Out:        zoneSize(gameDeck, deck.OUT),
+			Played:     played,
+			Connected:  !away,
+			TimeBankMs: gr.timeBankLeft(id, now).Milliseconds(),
+		})
+		if away {
+			snapshot.Away = append(snapshot.Away, id)
+		}
+	}
+
+	pInfo, ok := gr.players[playerID]
+	if !ok {
+		return snapshot
+	}
+	snapshot.PlayerID = playerID
+	snapshot.Seq = gr.streams[playerID].lastSeq()
+	snapshot.Hand = zoneKeys(pInfo.GameDeck, deck.HAND)
+	snapshot.WinPile = zoneKeys(pInfo.GameDeck, deck.WIN)
+	snapshot.OutPile = zoneKeys(pInfo.GameDeck, deck.OUT)
+	if played, ok := gr.playedCards[playerID]; ok && played != nil {
+		snapshot.PlayedCard = played.Key()
+	}
+	_, snapshot.OpponentPlayed = gr.playedCards[gr.getOpponentID(playerID)]
+	return snapshot
+}
+
+// Snapshot pede o snapshot à goroutine da sala. Depois do fim da partida a sala
+// não muda mais e o snapshot é montado direto.
+func (gr *GameRoom) Snapshot(playerID string) (RoomSnapshot, error) {
+	query := snapshotQuery{playerID: playerID, reply: make(chan RoomSnapshot, 1)}
+	select {
+	case gr.queries <- query:
+		return <-query.reply, nil
+	case <-gr.quit:
+		return gr.snapshotFor(playerID), nil
+	case <-time.After(snapshotTimeout):
+		return RoomSnapshot{}, fmt.Errorf("room %s is busy", gr.ID)
+	}
+}
+
+func zoneSize(d *deck.Deck, zone string) int {
+	cards, _ := d.GetCardsInZone(zone)
+	return len(cards)
+}
+
+func zoneKeys(d *deck.Deck, zone string) []string {
+	cards, _ := d.GetCardsInZone(zone)
+	keys := make([]string, len(cards))
+	for i, c := range cards {
+		keys[i] = c.Key()
+	}
+	return keys
+}
+
+// handleSnapshot lida com GET /rooms/{id}[?playerId=...]. Sem playerId devolve a
+// visão de operador, útil para investigar salas travadas.
+func handleSnapshot(w http.ResponseWriter, r *http.Request, room *GameRoom) {
+	if r.Method != http.MethodGet {
+		http.Error(w, `{"error": "Use GET for the room snapshot"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	playerID := r.URL.Query().Get("playerId")
+	if _, ok := room.players[playerID]; playerID != "" && !ok {
+		http.Error(w, `{"error": "Player is not in this room"}`, http.StatusNotFound)
+		return
+	}
+	snapshot, err := room.Snapshot(playerID)
+	if err != nil {
+		http.Error(w, `{"error": "Room is busy, try again"}`, http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(snapshot)
+}
+
+// handlePresenceAction lida com POST /rooms/{id}/presence.
+func handlePresenceAction(w http.ResponseWriter, r *http.Request, room *GameRoom) {
+	if r.Method != http.MethodPost {
+		http.Error(w, `{"error": "Use POST for /presence action"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	var req PresenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PlayerID == "" {
+		http.Error(w, `{"error": "Invalid payload for presence action"}`, http.StatusBadRequest)
+		return
+	}
+	if _, ok := room.players[req.PlayerID]; !ok {
+		http.Error(w, `{"error": "Player is not in this room"}`, http.StatusNotFound)
+		return
+	}
+	// Ao contrário de uma jogada atrasada, um aviso de presença não pode ser
+	// descartado: espera a goroutine da sala ficar livre.
+	select {
+	case room.incoming <- PresenceAction{PlayerID: req.PlayerID, Connected: req.Connected}:
+		w.WriteHeader(http.StatusAccepted)
+	case <-room.quit:
+		http.Error(w, `{"error": "The match is over"}`, http.StatusGone)
+	case <-time.After(snapshotTimeout):
+		http.Error(w, `{"error": "Room is busy, try again"}`, http.StatusServiceUnavailable)
+	}
+}
+
+//END OF FILE jokenpo/internal/services/gameroom/reconnect.go
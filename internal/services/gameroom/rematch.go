@@ -0,0 +1,186 @@
+//START OF FILE jokenpo/internal/services/gameroom/rematch.go
+package gameroom
+
+import (
+	"encoding/json"
+	"fmt"
+	"jokenpo/internal/game/deck"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Revanche: cada jogador pede a revanche (POST /rooms/{id}/rematch) durante a
+// partida ou depois do GAME_OVER, enquanto a sala ainda existe. Com a partida
+// terminada e os dois pedidos feitos, uma sala nova é criada neste mesmo nó com
+// os mesmos decks, o mesmo formato (série e ritmo) e a entropia que veio nos pedidos. Os dois Sessions recebem a
+// sala nova pelo /match-found, como numa partida vinda da fila, mas pelo outbox e
+// marcado com RematchOf: o Session só aceita se o jogador ainda está no lobby
+// esperando a revanche daquela sala.
+
+// rematchStartDelay dá tempo do GAME_OVER chegar aos Sessions pelo stream antes do
+// /match-found da revanche, quando os dois pediram a revanche durante a partida.
+const rematchStartDelay = 2 * time.Second
+
+// RematchRequest é o DTO de POST /rooms/{id}/rematch.
+type RematchRequest struct {
+	PlayerID string `json:"playerId"`
+	Entropy  string `json:"entropy,omitempty"` // Contribuição para a semente da nova partida
+}
+
+// RematchResponse diz em que ponto está a revanche.
+type RematchResponse struct {
+	Status string `json:"status"` // "waiting" (falta o oponente ou o fim da partida) ou "starting"
+}
+
+// matchCreatedPayload é o que o Session espera no /match-found.
+type matchCreatedPayload struct {
+	PlayerIDs   []string `json:"playerIds"`
+	RoomID      string   `json:"roomId"`
+	ServiceAddr string   `json:"serviceAddr"`
+	RematchOf   string   `json:"rematchOf,omitempty"` // Sala cuja revanche é esta
+}
+
+// rematchState guarda os pedidos de revanche. Tem mutex próprio porque os pedidos
+// continuam chegando depois que a goroutine da sala terminou.
+type rematchState struct {
+	mu      sync.Mutex
+	votes   map[string]string // playerID -> entropia
+	started bool
+}
+
+// voteRematch registra o pedido e, se for o caso, cria a sala da revanche.
+func (gr *GameRoom) voteRematch(playerID, entropy string) string {
+	gr.rematch.mu.Lock()
+	if gr.rematch.votes == nil {
+		gr.rematch.votes = make(map[string]string)
+	}
+	_, already := gr.rematch.votes[playerID]
+	gr.rematch.votes[playerID] = entropy
+	both := len(gr.rematch.votes) == len(gr.players)
+	gr.rematch.mu.Unlock()
+
+	if !both {
+		if !already {
+			gr.notifyPlayer(gr.getOpponentID(playerID), "REMATCH_REQUESTED", map[string]string{
+				"message": "Your opponent wants a rematch. Use REQUEST_REMATCH to accept.",
+			})
+		}
+		return "waiting"
+	}
+	if !gr.IsFinished() {
+		return "waiting"
+	}
+	gr.startRematch()
+	return "starting"
+}
+
+// startRematch cria a sala da revanche quando a partida acabou e os dois pediram.
+// É chamado pelo pedido que completa o par ou pelo fim da partida.
+func (gr *GameRoom) startRematch() {
+	gr.rematch.mu.Lock()
+	if gr.rematch.started || len(gr.rematch.votes) < len(gr.players) || !gr.IsFinished() {
+		gr.rematch.mu.Unlock()
+		return
+	}
+	gr.rematch.started = true
+	infos := make([]*InitialPlayerInfo, 0, len(gr.entries))
+	for _, entry := range gr.entries {
+		info := *entry
+		info.Entropy = gr.rematch.votes[entry.ID]
+		infos = append(infos, &info)
+	}
+	gr.rematch.mu.Unlock()
+
+	if gr.manager == nil || len(infos) != 2 {
+		return
+	}
+	format := gr.format()
+	format.Unrated = true
+	next, err := gr.manager.CreateRoom(deck.RematchRoomID(gr.ID), format, infos[0], infos[1])
+	if err != nil {
+		log.Printf("[GameRoom %s] ERROR: Rematch room was not created: %v", gr.ID, err)
+		for _, id := range gr.seats {
+			gr.notifyPlayer(id, "REMATCH_FAILED", map[string]string{"message": "Could not create the rematch room."})
+		}
+		return
+	}
+	log.Printf("[GameRoom %s] Rematch accepted. New room: %s", gr.ID, next.ID)
+
+	// Um anúncio por jogador, cada um no stream dele: o Session de cada um aceita ou
+	// recusa o seu sem afetar o do oponente.
+	for _, id := range gr.seats {
+		payload := matchCreatedPayload{PlayerIDs: []string{id}, RoomID: next.ID, ServiceAddr: gr.manager.serviceAddr, RematchOf: gr.ID}
+		target, err := gr.sessionURL(id, "/match-found")
+		if err == nil {
+			err = gr.manager.callbacks.Enqueue(id, target, "MATCH_FOUND rematch "+next.ID, payload)
+		}
+		if err != nil {
+			log.Printf("[GameRoom %s] WARN: Failed to announce rematch room %s to %s: %v", gr.ID, next.ID, id, err)
+		}
+	}
+	next.StartGame()
+}
+
+// format é o formato com que a sala foi criada, repetido na revanche.
+func (gr *GameRoom) format() MatchFormat {
+	return MatchFormat{Ruleset: gr.ruleset, BestOf: gr.series.bestOf, Timing: gr.timing, Unrated: gr.unrated}
+}
+
+// notifyPlayer entrega um evento ao jogador pelo stream. Com o stream já fechado
+// (depois do GAME_OVER) o evento é descartado: o jogador vê o estado da revanche
+// na resposta do próprio REQUEST_REMATCH.
+func (gr *GameRoom) notifyPlayer(playerID, eventType string, data interface{}) {
+	events, ok := gr.streams[playerID]
+	if !ok {
+		return
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	event := StreamEvent{EventType: eventType, PlayerID: playerID, RoomID: gr.ID, Data: payload}
+	if !events.append(event) {
+		log.Printf("[GameRoom %s] Stream of %s is closed. Dropping '%s'.", gr.ID, playerID, eventType)
+	}
+}
+
+// sessionURL monta o endereço de path no nó do Session onde o jogador está (pela
+// presença no Consul) ou, sem presença, no nó de onde ele entrou na fila.
+func (gr *GameRoom) sessionURL(playerID, path string) (string, error) {
+	if gr.manager != nil && gr.manager.presence != nil {
+		if located, err := gr.manager.presence.Locate(playerID); err == nil && located != "" {
+			return located + path, nil
+		}
+	}
+	callback, err := url.Parse(gr.players[playerID].CallbackURL)
+	if err != nil || callback.Host == "" {
+		return "", fmt.Errorf("no session address for player %s", playerID)
+	}
+	return callback.Scheme + "://" + callback.Host + path, nil
+}
+
+// handleRematchAction lida com POST /rooms/{id}/rematch.
+func handleRematchAction(w http.ResponseWriter, r *http.Request, room *GameRoom) {
+	if r.Method != http.MethodPost {
+		http.Error(w, `{"error": "Use POST for /rematch action"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	var req RematchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PlayerID == "" {
+		http.Error(w, `{"error": "Invalid payload for rematch action"}`, http.StatusBadRequest)
+		return
+	}
+	if _, ok := room.players[req.PlayerID]; !ok {
+		http.Error(w, `{"error": "Player is not in this room"}`, http.StatusNotFound)
+		return
+	}
+	status := room.voteRematch(req.PlayerID, req.Entropy)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(RematchResponse{Status: status})
+}
+
+//END OF FILE jokenpo/internal/services/gameroom/rematch.go
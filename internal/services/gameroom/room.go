@@ -2,17 +2,16 @@
 package gameroom
 
 import (
-	"bytes"
-	"context"
 	"encoding/json"
 	"fmt"
 	"jokenpo/internal/game/card"
 	"jokenpo/internal/game/deck"
+	"jokenpo/internal/game/rules"
 	"jokenpo/internal/services/blockchain" // Importar
+	"jokenpo/internal/services/ratings"
 	"log"
 	"math/rand/v2"
-	"net/http"
-	"net/url"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -24,6 +23,7 @@ const (
 	phase_RESOLVING_ROUND   = "resolving_round"
 	phase_GAME_OVER         = "game_over"
 	phase_ROUND_START       = "round_start"
+	phase_BETWEEN_GAMES     = "between_games" // Intervalo entre os jogos de uma série
 	initial_HAND_SIZE       = 5
 )
 
@@ -35,55 +35,142 @@ type PlayerGameInfo struct {
 
 type GameRoom struct {
 	ID          string
+	ruleset     rules.Ruleset
 	players     map[string]*PlayerGameInfo
+	seats       []string // IDs na ordem do CreateRoomRequest; define a ordem determinística da sala
 	rng         *rand.Rand
+	shuffleSeed deck.ShuffleSeed
+	shuffle     deck.ShuffleProof // Prova da semente; só o Commitment é divulgado antes do GAME_OVER
 	incoming    chan interface{}
 	quit        chan struct{}
 	start       chan struct{}
-	httpClient  *http.Client
+	startOnce   sync.Once // StartGame pode ser chamado de novo por um POST /rooms repetido
+	streams     map[string]*eventLog // Eventos de cada jogador, lidos pelo Session via GET /rooms/{id}/events
+	finishedAt  atomic.Int64         // UnixNano do fim da sala; 0 enquanto ela roda
 	gameState   atomic.Value
 	playedCards map[string]*card.Card
 	roundTimer  *time.Timer
+	round       int       // Número da rodada atual (1 = primeira)
+	roundStart  time.Time // Quando o relógio da rodada atual começou; zero com a rodada pausada
+	roundPaused bool      // O timer expirou com um jogador desconectado: a rodada espera a volta dele
+	away        map[string]time.Time // Jogadores desconectados e desde quando
+	graceTimer  *time.Timer          // Dispara no próximo fim de período de graça
+	grace       time.Duration        // Tempo que um desconectado tem para voltar antes do W.O.
+	queries     chan snapshotQuery   // Pedidos de snapshot, respondidos pela goroutine da sala
+	timing      rules.Timing             // Tempo da rodada, banco de tempo e pausa entre rodadas (clock.go)
+	timeBank    map[string]time.Duration // Banco de tempo restante de cada jogador no jogo atual
+	nextRound   *time.Timer              // Pausa entre o resultado e a próxima rodada; nil fora dela
+	drawOffer   string               // Jogador com oferta de empate aberta nesta rodada
+	entries     []*InitialPlayerInfo // Jogadores e decks como chegaram; a revanche parte deles
+	rematch     rematchState
+	manager     *RoomManager // Cria a sala da revanche neste mesmo nó
+	series      seriesState
+	sideboard   time.Duration // Intervalo entre jogos da série em que o deck pode ser trocado; 0 = sem sideboard
     blockchain  *blockchain.BlockchainClient // Novo campo
+	ratings     *ratings.Store               // nil = partidas não alteram o rating
+	unrated     bool                         // Sala fora da fila (revanche): o resultado não conta para o rating
 }
 
 // NewGameRoom atualizado
-func NewGameRoom(id string, initialPlayerInfos []*InitialPlayerInfo, client *http.Client, bc *blockchain.BlockchainClient) (*GameRoom, error) {
+func NewGameRoom(id string, format MatchFormat, initialPlayerInfos []*InitialPlayerInfo, bc *blockchain.BlockchainClient, ratingStore *ratings.Store) (*GameRoom, error) {
+	ruleset := format.Ruleset
+	if ruleset == nil {
+		ruleset = rules.Default()
+	}
+	bestOf, err := rules.NormalizeBestOf(format.BestOf)
+	if err != nil {
+		return nil, err
+	}
+	timing := format.Timing
+	if timing == (rules.Timing{}) {
+		timing = rules.DefaultTiming()
+	}
+	if err := timing.Validate(); err != nil {
+		return nil, err
+	}
 	gr := &GameRoom{
 		ID:          id,
+		ruleset:     ruleset,
 		players:     make(map[string]*PlayerGameInfo),
-		rng:         rand.New(rand.NewPCG(uint64(time.Now().UnixNano()), 1)),
 		incoming:    make(chan interface{}),
 		quit:        make(chan struct{}),
 		start:       make(chan struct{}),
-		httpClient:  client,
+		streams:     make(map[string]*eventLog),
 		playedCards: make(map[string]*card.Card),
+		away:        make(map[string]time.Time),
+		grace:       reconnectGraceFromEnv(),
+		queries:     make(chan snapshotQuery),
+		timing:      timing,
+		timeBank:    make(map[string]time.Duration),
+		series:      seriesState{bestOf: bestOf, score: make(map[string]int), sideboards: make(map[string][]string)},
+		sideboard:   sideboardWindowFromEnv(),
         blockchain:  bc,
+		ratings:     ratingStore,
+		unrated:     format.Unrated,
 	}
-	log.Printf("GameRoom de ID %s foi criado",gr.ID)
+	log.Printf("GameRoom de ID %s foi criado (ruleset: %s, melhor de %d, %s)",gr.ID, ruleset.Name(), bestOf, timing)
 	gr.gameState.Store(phase_ROOM_START)
 
+	entropies := make([]string, 0, len(initialPlayerInfos))
 	for i, info := range initialPlayerInfos {
-		gameDeck := deck.NewDeck()
-		for _, cardKey := range info.Deck {
-			c, err := card.GetCard(cardKey)
-			if err != nil {
-				return nil, fmt.Errorf("player %s has an invalid card in deck: %w", info.ID, err)
-			}
-			gameDeck.AddCardToZone("deck", c)
+		gameDeck, err := buildGameDeck(info.Deck)
+		if err != nil {
+			return nil, fmt.Errorf("player %s: %w", info.ID, err)
 		}
 		gr.players[info.ID] = &PlayerGameInfo{
 			ID:          info.ID,
 			CallbackURL: info.CallbackURL,
 			GameDeck:    gameDeck,
 		}
+		gr.seats = append(gr.seats, info.ID)
+		gr.entries = append(gr.entries, info)
+		gr.streams[info.ID] = newEventLog()
+
+		// Sessões antigas não mandam entropia: a sala contribui no lugar delas.
+		entropy := info.Entropy
+		if entropy == "" {
+			generated, err := deck.NewEntropy()
+			if err != nil {
+				return nil, err
+			}
+			log.Printf("[GameRoom %s] WARN: Player %s sent no shuffle entropy. Using room-generated entropy.", id, info.ID)
+			entropy = generated
+		}
+		entropies = append(entropies, entropy)
 		log.Printf("[DEBUG] Player %d, ID: (%s) deck size: %d",i , info.ID, gameDeck.DeckSize())
 	}
+
+	seed, err := deck.CombineEntropy(id, entropies)
+	if err != nil {
+		return nil, fmt.Errorf("invalid shuffle entropy: %w", err)
+	}
+	gr.shuffleSeed = seed
+	gr.shuffle = deck.ShuffleProof{
+		RoomID:     id,
+		Entropies:  entropies,
+		Seed:       seed.String(),
+		Commitment: seed.Commitment(),
+	}
+	// Toda a aleatoriedade da sala (embaralhamento e jogadas forçadas) sai da semente.
+	gr.rng = seed.RNG("room")
 	return gr, nil
 }
 
 func (gr *GameRoom) StartGame() {
-	close(gr.start)
+	gr.startOnce.Do(func() { close(gr.start) })
+}
+
+// hasSeats diz se a sala foi criada para estes jogadores, na mesma ordem.
+func (gr *GameRoom) hasSeats(infos []*InitialPlayerInfo) bool {
+	if len(infos) != len(gr.seats) {
+		return false
+	}
+	for i, info := range infos {
+		if info == nil || info.ID != gr.seats[i] {
+			return false
+		}
+	}
+	return true
 }
 
 func (gr *GameRoom) Run() {
@@ -95,7 +182,20 @@ func (gr *GameRoom) Run() {
 		if gr.roundTimer != nil {
 			gr.roundTimer.Stop()
 		}
+		if gr.graceTimer != nil {
+			gr.graceTimer.Stop()
+		}
+		if gr.series.nextGame != nil {
+			gr.series.nextGame.Stop()
+		}
+		if gr.nextRound != nil {
+			gr.nextRound.Stop()
+		}
 		gr.setGameState(phase_GAME_OVER)
+		for _, events := range gr.streams {
+			events.close()
+		}
+		gr.finishedAt.Store(time.Now().UnixNano())
 		log.Printf("[GameRoom %s] Goroutine stopped.", gr.ID)
 	}()
 
@@ -108,12 +208,43 @@ func (gr *GameRoom) Run() {
 			switch act := action.(type) {
 			case PlayCardAction:
 				gr.HandlePlayCard(act.PlayerID, act.CardIndex)
+				// Com as duas cartas na mesa a rodada é resolvida sem esperar o timer.
+				if gr.getGameState() == phase_RESOLVING_ROUND {
+					gr.resolveRound()
+				}
+			case PresenceAction:
+				gr.handlePresence(act.PlayerID, act.Connected)
+			case SurrenderAction:
+				gr.handleSurrender(act.PlayerID)
+			case OfferDrawAction:
+				gr.handleOfferDraw(act.PlayerID)
+			case AcceptDrawAction:
+				gr.handleAcceptDraw(act.PlayerID)
+			case SideboardAction:
+				gr.handleSideboard(act)
 			}
-		case <-gr.roundTimer.C:
+		case query := <-gr.queries:
+			query.reply <- gr.snapshotFor(query.playerID)
+		case <-gr.graceExpired():
+			gr.handleGraceExpired()
+		case <-gr.nextGameDue():
+			gr.startNextGame()
+		case <-gr.nextRoundDue():
+			gr.handleNextRound()
+		case <-gr.roundExpired():
 			if gr.getGameState() == phase_WAITING_FOR_PLAYS {
+				// Com alguém desconectado a rodada espera, em vez de jogar por ele.
+				if gr.pauseRoundIfDisconnected() {
+					continue
+				}
 				gr.handleTimeout()
-				if gr.getGameState() != phase_GAME_OVER {
+				// Um W.O. no timeout encerra o jogo (e, numa série, abre o intervalo).
+				switch gr.getGameState() {
+				case phase_RESOLVING_ROUND:
 					gr.resolveRound()
+				case phase_WAITING_FOR_PLAYS:
+					// Quem ainda tem banco de tempo continua pensando.
+					gr.armNextDeadline()
 				}
 			}
 		case <-gr.quit:
@@ -136,6 +267,13 @@ func (gr *GameRoom) ForwardAction(action interface{}) {
 	}
 }
 
+// expired indica que a sala terminou há mais de finishedRoomRetention: os streams
+// de eventos já tiveram tempo de entregar o GAME_OVER e ela pode ser removida.
+func (gr *GameRoom) expired() bool {
+	at := gr.finishedAt.Load()
+	return at != 0 && time.Since(time.Unix(0, at)) > finishedRoomRetention
+}
+
 func (gr *GameRoom) IsFinished() bool {
 	return gr.getGameState() == phase_GAME_OVER
 }
@@ -150,84 +288,32 @@ func (gr *GameRoom) setGameState(state string) {
 
 func (gr *GameRoom) broadcastEvent(eventType string, data interface{}) {
 	log.Printf("[GameRoom %s] Broadcasting event '%s' to %d players.", gr.ID, eventType, len(gr.players))
-	for _, pInfo := range gr.players {
-		go func(player *PlayerGameInfo) {
-			if err := gr.sendCallbackToPlayer(player.ID, eventType, data); err != nil {
-				log.Printf("[GameRoom %s] ERROR: Failed to send event '%s' to player %s after all retries: %v", gr.ID, eventType, player.ID, err)
-			}
-		}(pInfo)
+	for _, playerID := range gr.seats {
+		if err := gr.sendEventToPlayer(playerID, eventType, data); err != nil {
+			log.Printf("[GameRoom %s] ERROR: Failed to send event '%s' to player %s: %v", gr.ID, eventType, playerID, err)
+		}
 	}
 }
 
-func (gr *GameRoom) sendCallbackToPlayer(playerID string, eventType string, data interface{}) error {
-	pInfo, ok := gr.players[playerID]
+// sendEventToPlayer acrescenta o evento ao log do jogador; o Session o recebe pelo
+// stream de eventos (stream.go), na ordem em que foi gerado.
+func (gr *GameRoom) sendEventToPlayer(playerID string, eventType string, data interface{}) error {
+	events, ok := gr.streams[playerID]
 	if !ok {
 		return fmt.Errorf("player %s not found in room", playerID)
 	}
-
-	if pInfo.CallbackURL == "" {
-		return fmt.Errorf("player %s has an empty callback URL", playerID)
-	}
-	log.Printf("O CALLBACK DO PLAYER %s É %s", pInfo.ID, pInfo.CallbackURL)
-
-	if _, err := url.ParseRequestURI(pInfo.CallbackURL); err != nil {
-		return fmt.Errorf("invalid callback URL for player %s: %w", playerID, err)
-	}
-
-	eventPayload := map[string]interface{}{
-		"eventType": eventType,
-		"playerId":  playerID,
-		"roomId":    gr.ID,
-		"data":      data,
-	}
-	jsonData, err := json.Marshal(eventPayload)
+	payload, err := json.Marshal(data)
 	if err != nil {
 		return fmt.Errorf("failed to marshal payload for event %s: %w", eventType, err)
 	}
-
-	var lastErr error
-	backoff := 200 * time.Millisecond
-
-	for attempt := 1; attempt <= 3; attempt++ {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, pInfo.CallbackURL, bytes.NewBuffer(jsonData))
-		if err != nil {
-			return fmt.Errorf("failed to create HTTP request: %w", err)
-		}
-		req.Header.Set("Content-Type", "application/json")
-
-		log.Printf("[GameRoom %s] Sending event '%s' to player %s at %s (Attempt %d/3)...", gr.ID, eventType, playerID, pInfo.CallbackURL, attempt)
-		resp, err := gr.httpClient.Do(req)
-
-		if err != nil {
-			lastErr = err
-			log.Printf("[GameRoom %s] WARN: Attempt %d to send '%s' to player %s failed: %v", gr.ID, attempt, eventType, playerID, err)
-		} else {
-			resp.Body.Close()
-			if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-				log.Printf("[GameRoom %s] SUCCESS: Event '%s' delivered to player %s.", gr.ID, eventType, playerID)
-				return nil
-			}
-			lastErr = fmt.Errorf("received non-success status code: %s", resp.Status)
-			log.Printf("[GameRoom %s] WARN: Attempt %d to send '%s' to player %s received status: %s", gr.ID, attempt, eventType, playerID, resp.Status)
-		}
-
-		if attempt < 3 {
-			time.Sleep(backoff)
-			backoff *= 2
-		}
-	}
-
-	return fmt.Errorf("failed to send callback after all retries: %w", lastErr)
+	events.append(StreamEvent{EventType: eventType, PlayerID: playerID, RoomID: gr.ID, Data: payload})
+	return nil
 }
 
+// getPlayerIDs retorna os IDs na ordem dos assentos (sempre a mesma durante a partida).
 func (gr *GameRoom) getPlayerIDs() []string {
-	ids := make([]string, 0, len(gr.players))
-	for id := range gr.players {
-		ids = append(ids, id)
-	}
+	ids := make([]string, len(gr.seats))
+	copy(ids, gr.seats)
 	return ids
 }
 //END OF FILE jokenpo/internal/services/gameroom/room.go
\ No newline at end of file
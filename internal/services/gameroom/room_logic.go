@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"jokenpo/internal/game/card"
 	"jokenpo/internal/game/deck"
+	"jokenpo/internal/game/rules"
 	"log"
 	"time"
 )
@@ -22,14 +23,17 @@ type PlayCardAction struct {
 // startGame embaralha os decks, compra as mãos iniciais e inicia a primeira rodada.
 func (gr *GameRoom) startGame() {
 	if gr.getGameState() != phase_ROOM_START {
-		gr.handleGameOver("", "Game start failed: invalid phase.")
+		gr.abortGame("Game start failed: invalid phase.")
 		return
 	}
 
 	drawStatus := make(map[string]bool)
 
-	for playerID, pInfo := range gr.players {
-		pInfo.GameDeck.Shuffle(deck.DECK, gr.rng)
+	gr.series.game++
+	gr.resetTimeBanks()
+	for _, playerID := range gr.getPlayerIDs() {
+		// Cada jogo da série embaralha num fluxo próprio da mesma semente.
+		gr.players[playerID].GameDeck.ShuffleWithSeed(gr.shuffleSeed, deck.SeriesStream(playerID, gr.series.game))
 		drawStatus[playerID] = gr.drawCardsAndNotify(playerID, initial_HAND_SIZE)
 	}
 
@@ -37,27 +41,41 @@ func (gr *GameRoom) startGame() {
 		return
 	}
 
-	log.Printf("[GameRoom %s] Match started, timer of 5s activated.", gr.ID)
+	log.Printf("[GameRoom %s] Match started (%s).", gr.ID, gr.timing)
 
-	gr.broadcastEvent("GAME_START", map[string]string{
-		"message": "The match has started! You have 2 seconds to play your card.",
-	})
+	seconds := gr.timing.Round().Seconds()
+	start := map[string]interface{}{
+		"message": fmt.Sprintf("The match has started! You have %g seconds to play your card.", seconds),
+		"ruleset": gr.ruleset.Name(),
+		"rules":   gr.ruleset.Description(),
+		"timing":  gr.timing,
+		// Hash da semente do embaralhamento. A semente é revelada no GAME_OVER.
+		"shuffle_commitment": gr.shuffle.Commitment,
+	}
+	if gr.isSeries() {
+		start["message"] = fmt.Sprintf("Game %d of a best of %d has started! You have %g seconds to play your card.", gr.series.game, gr.series.bestOf, seconds)
+		start["game"] = gr.series.game
+		start["series"] = gr.seriesSnapshot()
+	}
+	gr.broadcastEvent("GAME_START", start)
 
+	gr.round++
 	gr.setGameState(phase_WAITING_FOR_PLAYS)
-	gr.roundTimer = time.NewTimer(2 * time.Second)
+	gr.startRoundClock()
 }
 
 // startNewRound compra uma nova carta para cada jogador e inicia a próxima rodada.
 func (gr *GameRoom) startNewRound() {
 	if gr.getGameState() != phase_ROUND_START {
-		gr.handleGameOver("", "Round start failed: invalid phase.")
+		gr.abortGame("Round start failed: invalid phase.")
 		return
 	}
 
 	gr.playedCards = make(map[string]*card.Card)
+	gr.drawOffer = "" // Ofertas de empate valem só para a rodada em que foram feitas
 	drawStatus := make(map[string]bool)
 
-	for playerID := range gr.players {
+	for _, playerID := range gr.getPlayerIDs() {
 		drawStatus[playerID] = gr.drawCardsAndNotify(playerID, 1)
 	}
 
@@ -65,22 +83,36 @@ func (gr *GameRoom) startNewRound() {
 		return
 	}
 
-	gr.broadcastEvent("NEW_ROUND", map[string]string{
-		"message": "A new round has started! You have 2 seconds to play your card.",
-	})
-
+	gr.round++
 	gr.setGameState(phase_WAITING_FOR_PLAYS)
-	gr.roundTimer = time.NewTimer(2 * time.Second)
+	gr.startRoundClock()
+
+	// Cada jogador recebe o próprio relógio: tempo da rodada e os dois bancos.
+	seconds := gr.timing.Round().Seconds()
+	for _, playerID := range gr.seats {
+		clock := gr.clockFor(playerID)
+		message := fmt.Sprintf("A new round has started! You have %g seconds to play your card.", seconds)
+		if bank := clock["timeBankMs"]; bank > 0 {
+			message = fmt.Sprintf("A new round has started! You have %g seconds to play your card, plus %g seconds in your time bank.", seconds, float64(bank)/1000)
+		}
+		gr.sendEventToPlayer(playerID, "NEW_ROUND", map[string]interface{}{
+			"message":            message,
+			"round":              gr.round,
+			"timeLeftMs":         clock["roundMs"],
+			"timeBankMs":         clock["timeBankMs"],
+			"opponentTimeBankMs": clock["opponentTimeBankMs"],
+		})
+	}
 }
 
 // HandlePlayCard processa a jogada de um jogador.
 func (gr *GameRoom) HandlePlayCard(playerID string, cardIndex int) {
 	if gr.getGameState() != phase_WAITING_FOR_PLAYS {
-		gr.sendCallbackToPlayer(playerID, "ERROR", map[string]string{"message": "It's not time to play a card right now."})
+		gr.sendEventToPlayer(playerID, "ERROR", map[string]string{"message": "It's not time to play a card right now."})
 		return
 	}
 	if _, alreadyPlayed := gr.playedCards[playerID]; alreadyPlayed {
-		gr.sendCallbackToPlayer(playerID, "ERROR", map[string]string{"message": "You have already played a card this round."})
+		gr.sendEventToPlayer(playerID, "ERROR", map[string]string{"message": "You have already played a card this round."})
 		return
 	}
 
@@ -89,17 +121,18 @@ func (gr *GameRoom) HandlePlayCard(playerID string, cardIndex int) {
 
 	playedCard, err := pInfo.GameDeck.PlayCardFromHand(cardIndex)
 	if err != nil {
-		gr.sendCallbackToPlayer(playerID, "ERROR", map[string]string{"message": fmt.Sprintf("Failed to play card: %v", err)})
+		gr.sendEventToPlayer(playerID, "ERROR", map[string]string{"message": fmt.Sprintf("Failed to play card: %v", err)})
 		return
 	}
 
+	gr.chargeTimeBank(playerID, time.Now())
 	gr.playedCards[playerID] = playedCard
 
-	gr.sendCallbackToPlayer(playerID, "PLAY_CONFIRMED", map[string]string{
+	gr.sendEventToPlayer(playerID, "PLAY_CONFIRMED", map[string]string{
 		"message": fmt.Sprintf("You played %s. Waiting for opponent...", playedCard.Key()),
 	})
 	opponentID := gr.getOpponentID(playerID)
-	gr.sendCallbackToPlayer(opponentID, "OPPONENT_PLAYED", map[string]string{
+	gr.sendEventToPlayer(opponentID, "OPPONENT_PLAYED", map[string]string{
 		"message": "Your opponent has played a card.",
 	})
 
@@ -107,13 +140,16 @@ func (gr *GameRoom) HandlePlayCard(playerID string, cardIndex int) {
 		gr.roundTimer.Stop()
 		gr.setGameState(phase_RESOLVING_ROUND)
 		// A resolução agora é chamada pela goroutine Run
+		return
 	}
+	// O prazo que falta é o do adversário, que pode ter mais banco de tempo.
+	gr.armNextDeadline()
 }
 
 // resolveRound compara as cartas e determina o resultado da rodada.
 func (gr *GameRoom) resolveRound() {
 	if gr.getGameState() != phase_RESOLVING_ROUND {
-		gr.handleGameOver("", "Round resolution failed: invalid phase.")
+		gr.abortGame("Round resolution failed: invalid phase.")
 		return
 	}
 
@@ -125,37 +161,60 @@ func (gr *GameRoom) resolveRound() {
 	p1Card, p2Card := gr.playedCards[p1ID], gr.playedCards[p2ID]
 
 	if p1Card == nil || p2Card == nil {
-		gr.handleGameOver("", "Failed to resolve round: one or more players did not play a card.")
+		gr.abortGame("Failed to resolve round: one or more players did not play a card.")
 		return
 	}
 
-	winnerResult := card.Compare(p1Card, p2Card)
+	// --- Fase 1: habilidades on_play (antes da comparação) ---
+	round := gr.newRoundContext()
+	gr.resolveEffects(gr.collectEffects(map[string]string{
+		p1ID: card.TriggerOnPlay,
+		p2ID: card.TriggerOnPlay,
+	}), round)
+
+	winnerResult := rules.Compare(gr.ruleset, *round.contenders[p1ID], *round.contenders[p2ID])
 	var p1Won, p2Won bool
 	var resultText string
 	
 	switch winnerResult {
-	case card.Card1Wins:
+	case rules.Card1Wins:
 		p1Won, p2Won = true, false
 		resultText = fmt.Sprintf("Player %s's %s wins against Player %s's %s!", p1ID, p1Card.Key(), p2ID, p2Card.Key())
-	case card.Card2Wins:
+	case rules.Card2Wins:
 		p1Won, p2Won = false, true
 		resultText = fmt.Sprintf("Player %s's %s wins against Player %s's %s!", p2ID, p2Card.Key(), p1ID, p1Card.Key())
-	case card.Tie:
+	case rules.Tie:
 		p1Won, p2Won = false, false
 		resultText = fmt.Sprintf("It's a tie between %s and %s!", p1Card.Key(), p2Card.Key())
 	}
 	
-	p1Info.GameDeck.ResolvePlay(p1Won)
-	p2Info.GameDeck.ResolvePlay(p2Won)
+	// --- Fase 2: habilidades on_win / on_lose (empate não dispara nenhuma) ---
+	if p1Won {
+		gr.resolveEffects(gr.collectEffects(map[string]string{p1ID: card.TriggerOnWin, p2ID: card.TriggerOnLose}), round)
+	} else if p2Won {
+		gr.resolveEffects(gr.collectEffects(map[string]string{p1ID: card.TriggerOnLose, p2ID: card.TriggerOnWin}), round)
+	}
+
+	// Uma carta banida vai para OUT mesmo tendo vencido.
+	p1Info.GameDeck.ResolvePlay(p1Won && !round.banished[p1ID])
+	p2Info.GameDeck.ResolvePlay(p2Won && !round.banished[p2ID])
+
+	// Compras geradas por efeitos acontecem depois que a mesa foi limpa.
+	for _, draw := range round.draws {
+		gr.drawCardsAndNotify(draw.playerID, draw.amount)
+	}
 
 	gr.broadcastEvent("ROUND_RESULT", map[string]interface{}{
 		"message":    resultText,
 		"p1_card":    p1Card.Key(),
 		"p2_card":    p2Card.Key(),
+		"p1_value":   round.contenders[p1ID].Value,
+		"p2_value":   round.contenders[p2ID].Value,
+		"effects":    round.fired,
 	})
 	
-	p1HasWon := p1Info.GameDeck.WinCondition()
-	p2HasWon := p2Info.GameDeck.WinCondition()
+	p1HasWon := p1Info.GameDeck.WinCondition(gr.ruleset)
+	p2HasWon := p2Info.GameDeck.WinCondition(gr.ruleset)
 
 	if p1HasWon && p2HasWon {
 		gr.handleGameOver("", "Both players met win conditions simultaneously.")
@@ -170,28 +229,58 @@ func (gr *GameRoom) resolveRound() {
 		return
 	}
 
-	time.Sleep(3 * time.Second)
-	gr.setGameState(phase_ROUND_START)
-	gr.startNewRound()
+	// A próxima rodada sai do select do Run, depois de timing.RoundDelay().
+	gr.scheduleNextRound()
 }
 
-// handleGameOver finaliza a partida e notifica os jogadores.
+// handleGameOver finaliza um jogo decidido (winnerID vazio = empate). Numa série,
+// o jogo entra no placar e a partida só termina quando a série for decidida.
 func (gr *GameRoom) handleGameOver(winnerID string, reason string) {
+	if gr.isSeries() {
+		gr.recordSeriesGame(winnerID, reason)
+		return
+	}
+	gr.finishGame(winnerID, reason, true)
+}
+
+// handleForfeit encerra a partida inteira (a série, se houver) a favor de winnerID.
+func (gr *GameRoom) handleForfeit(winnerID string, reason string) {
+	gr.finishGame(winnerID, reason, true)
+}
+
+// abortGame encerra a partida por erro interno da sala: sem vencedor e sem afetar o rating.
+func (gr *GameRoom) abortGame(reason string) {
+	gr.finishGame("", reason, false)
+}
+
+func (gr *GameRoom) finishGame(winnerID string, reason string, rated bool) {
 	if gr.IsFinished() { return }
 	gr.setGameState(phase_GAME_OVER)
+	// Salas criadas fora da fila (revanche) não passaram pela janela de rating nem
+	// pelo fencing do Queue: o resultado não conta, senão dois jogadores combinados
+	// trocariam rating com REQUEST_REMATCH + SURRENDER em sequência.
+	rated = rated && !gr.unrated
 	
 	if gr.roundTimer != nil {
 		gr.roundTimer.Stop()
 	}
+	if gr.nextRound != nil {
+		gr.nextRound.Stop()
+		gr.nextRound = nil
+	}
 	log.Printf("[GameRoom %s] Game Over. Winner: %s. Reason: %s", gr.ID, winnerID, reason)
 	
     // --- REGISTRO NA BLOCKCHAIN ---
-    if gr.blockchain != nil && winnerID != "" {
+    // Todo resultado que conta para o rating vai para o ledger, com o motivo (empates com vencedor e perdedor vazios).
+    if gr.blockchain != nil && rated {
         // Identifica o perdedor
-        loserID := gr.getLoserID(winnerID)
+        loserID := ""
+        if winnerID != "" {
+            loserID = gr.getLoserID(winnerID)
+        }
         
         go func() {
-            if err := gr.blockchain.LogMatch(gr.ID, winnerID, loserID); err != nil {
+            if err := gr.blockchain.LogMatch(gr.ID, winnerID, loserID, reason); err != nil {
                 log.Printf("GAMEROOM ERRO: Falha ao registrar partida na blockchain: %v", err)
             } else {
                 log.Printf("[BLOCKCHAIN]: Partida %s registrada na blockchain (Vencedor: %s)", gr.ID, winnerID)
@@ -199,23 +288,40 @@ func (gr *GameRoom) handleGameOver(winnerID string, reason string) {
         }()
     }
 
-	gr.broadcastEvent("GAME_OVER", map[string]interface{}{
+	if rated {
+		gr.recordRatings(winnerID)
+	}
+
+	gameOver := map[string]interface{}{
 		"winnerId": winnerID,
 		"reason":   reason,
-	})
+		"shuffle":  gr.shuffle, // Revela a semente para que os jogadores verifiquem o embaralhamento
+	}
+	if gr.isSeries() {
+		gameOver["series"] = gr.seriesSnapshot()
+	}
+	gr.broadcastEvent("GAME_OVER", gameOver)
 
 	close(gr.quit)
+	// Se os dois já pediram revanche durante a partida, ela começa em seguida.
+	time.AfterFunc(rematchStartDelay, gr.startRematch)
 }
 
-// handleTimeout força a jogada de jogadores que não agiram a tempo.
+// handleTimeout força a jogada de quem estourou o tempo da rodada e o próprio
+// banco de tempo. Quem ainda tem banco continua pensando.
 func (gr *GameRoom) handleTimeout() {
 	if gr.getGameState() != phase_WAITING_FOR_PLAYS { return }
-	log.Printf("[GameRoom %s] Round timer expired. Forcing remaining plays.", gr.ID)
-
-	gr.setGameState(phase_RESOLVING_ROUND)
+	log.Printf("[GameRoom %s] Round timer expired. Forcing plays of players out of time.", gr.ID)
 
-	for playerID, pInfo := range gr.players {
+	now := time.Now()
+	for _, playerID := range gr.getPlayerIDs() {
+		pInfo := gr.players[playerID]
 		if _, hasPlayed := gr.playedCards[playerID]; !hasPlayed {
+			if gr.timeBankLeft(playerID, now) > 0 {
+				continue
+			}
+			gr.timeBank[playerID] = 0
+
 			hand, _ := pInfo.GameDeck.GetCardsInZone("hand")
 			if len(hand) == 0 {
 				opponentID := gr.getOpponentID(playerID)
@@ -231,11 +337,15 @@ func (gr *GameRoom) handleTimeout() {
 			}
 			gr.playedCards[playerID] = playedCard
 
-			gr.sendCallbackToPlayer(playerID, "FORCED_PLAY", map[string]string{
+			gr.sendEventToPlayer(playerID, "FORCED_PLAY", map[string]string{
 				"message": fmt.Sprintf("You ran out of time! The card %s was played for you.", playedCard.Key()),
 			})
 		}
 	}
+
+	if len(gr.playedCards) == len(gr.players) {
+		gr.setGameState(phase_RESOLVING_ROUND)
+	}
 }
 
 
@@ -249,13 +359,16 @@ func (gr *GameRoom) drawCardsAndNotify(playerID string, numToDraw int) bool {
 	
 	drawSuccessful := true
 	var warningMessage string
+	drawn := make([]string, 0, numToDraw)
 
 	for i := 0; i < numToDraw; i++ {
-		if _, err := pInfo.GameDeck.DrawToHand(); err != nil {
+		c, err := pInfo.GameDeck.DrawToHand()
+		if err != nil {
 			warningMessage = "Warning: Not enough cards in your deck."
 			drawSuccessful = false
 			break
 		}
+		drawn = append(drawn, c.Key())
 	}
 
 	hand, _ := pInfo.GameDeck.GetCardsInZone("hand")
@@ -264,9 +377,10 @@ func (gr *GameRoom) drawCardsAndNotify(playerID string, numToDraw int) bool {
 		handKeys[i] = c.Key()
 	}
 	
-	gr.sendCallbackToPlayer(playerID, "UPDATE_HAND", map[string]interface{}{
+	gr.sendEventToPlayer(playerID, "UPDATE_HAND", map[string]interface{}{
 	"message": warningMessage,
 	"hand":    handKeys,
+	"drawn":   drawn, // Em ordem; usado pela sessão para verificar o embaralhamento
 })
 
 	return drawSuccessful
@@ -303,6 +417,26 @@ func (gr *GameRoom) getOpponentID(playerID string) string {
 	return ""
 }
 
+// recordRatings atualiza o rating dos dois jogadores em background, para não
+// segurar o GAME_OVER esperando o Consul.
+func (gr *GameRoom) recordRatings(winnerID string) {
+	if gr.ratings == nil {
+		return
+	}
+	seats := gr.getPlayerIDs()
+	if len(seats) != 2 {
+		return
+	}
+	go func() {
+		r1, r2, err := gr.ratings.RecordMatch(seats[0], seats[1], winnerID)
+		if err != nil {
+			log.Printf("[GameRoom %s] ERROR: Failed to update ratings: %v", gr.ID, err)
+			return
+		}
+		log.Printf("[GameRoom %s] Ratings updated: %s=%.0f, %s=%.0f", gr.ID, seats[0], r1.Rating, seats[1], r2.Rating)
+	}()
+}
+
 // Helper para identificar o perdedor dado um vencedor
 func (gr *GameRoom) getLoserID(winnerID string) string {
 	for id := range gr.players {
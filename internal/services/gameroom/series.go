@@ -0,0 +1,293 @@
+//START OF FILE jokenpo/internal/services/gameroom/series.go
+package gameroom
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"jokenpo/internal/game/card"
+	"jokenpo/internal/game/deck"
+	"jokenpo/internal/game/rules"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Série melhor de N: a sala joga vários jogos com os mesmos jogadores. Entre um
+// jogo e outro os decks voltam inteiros (ResetToDeck), em ordem de chave, e são
+// embaralhados de novo com a mesma semente num fluxo próprio do jogo. Cada jogo
+// termina com GAME_RESULT; o GAME_OVER (com rating, ledger e revelação da semente)
+// só sai quando a série está decidida. No intervalo os jogadores podem trocar o
+// deck (sideboard, POST /rooms/{id}/sideboard) para o próximo jogo.
+// Desistência e W.O. encerram a série inteira; empate combinado empata só o jogo.
+
+const (
+	defaultSideboardWindow = 20 * time.Second
+	nextGameDelay          = 3 * time.Second // Intervalo entre jogos com o sideboard desligado
+)
+
+// errNotBetweenGames é a resposta a um sideboard fora do intervalo entre jogos.
+var errNotBetweenGames = errors.New("sideboarding is only allowed between games of a series")
+
+// seriesState é o placar da série. Só a goroutine da sala mexe nele.
+type seriesState struct {
+	bestOf     int
+	game       int            // Jogo atual (1 = primeiro)
+	score      map[string]int // Vitórias de cada jogador
+	sideboards map[string][]string
+	nextGame   *time.Timer // Dispara o próximo jogo; nil fora do intervalo
+	startsAt   time.Time
+}
+
+// SideboardAction troca o deck de um jogador a partir do próximo jogo da série.
+type SideboardAction struct {
+	PlayerID string
+	Deck     []string
+	reply    chan error
+}
+
+// SideboardRequest é o DTO de POST /rooms/{id}/sideboard.
+type SideboardRequest struct {
+	PlayerID string   `json:"playerId"`
+	Deck     []string `json:"deck"`
+}
+
+// SeriesSnapshot é o placar da série no snapshot da sala e no GAME_OVER.
+type SeriesSnapshot struct {
+	BestOf       int            `json:"bestOf"`
+	Game         int            `json:"game"`
+	Score        map[string]int `json:"score"`
+	NextGameInMs int64          `json:"nextGameInMs,omitempty"` // Só no intervalo entre jogos
+}
+
+// sideboardWindowFromEnv lê GAMEROOM_SIDEBOARD_SECONDS (padrão: 20). Zero desliga
+// o sideboard: o próximo jogo começa depois de um intervalo curto.
+func sideboardWindowFromEnv() time.Duration {
+	if raw := os.Getenv("GAMEROOM_SIDEBOARD_SECONDS"); raw != "" {
+		if seconds, err := strconv.Atoi(raw); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second
+		}
+		log.Printf("WARN: Invalid GAMEROOM_SIDEBOARD_SECONDS %q, using %s", raw, defaultSideboardWindow)
+	}
+	return defaultSideboardWindow
+}
+
+// isSeries indica se a sala joga uma série (melhor de 3 ou mais).
+func (gr *GameRoom) isSeries() bool {
+	return gr.series.bestOf > 1
+}
+
+// recordSeriesGame fecha o jogo atual da série. Se ela ficou decidida, encerra a
+// sala; senão abre o intervalo até o próximo jogo.
+func (gr *GameRoom) recordSeriesGame(winnerID string, reason string) {
+	if winnerID != "" {
+		gr.series.score[winnerID]++
+	}
+	if gr.roundTimer != nil {
+		gr.roundTimer.Stop()
+	}
+	if gr.nextRound != nil {
+		gr.nextRound.Stop()
+		gr.nextRound = nil
+	}
+	gr.roundStart = time.Time{}
+	gr.roundPaused = false
+	gr.drawOffer = ""
+	log.Printf("[GameRoom %s] Game %d of %d over. Winner: %s. Reason: %s. Score: %v", gr.ID, gr.series.game, gr.series.bestOf, winnerID, reason, gr.series.score)
+
+	if seriesWinner, decided := gr.seriesResult(); decided {
+		gr.finishGame(seriesWinner, gr.seriesReason(seriesWinner, reason), true)
+		return
+	}
+
+	window := nextGameDelay
+	if gr.sideboard > 0 {
+		window = gr.sideboard
+	}
+	gr.setGameState(phase_BETWEEN_GAMES)
+	gr.series.startsAt = time.Now().Add(window)
+	gr.series.nextGame = time.NewTimer(window)
+
+	gr.broadcastEvent("GAME_RESULT", map[string]interface{}{
+		"message":      fmt.Sprintf("Game %d is over: %s Next game in %d seconds.", gr.series.game, reason, int(window.Seconds())),
+		"game":         gr.series.game,
+		"winnerId":     winnerID,
+		"reason":       reason,
+		"series":       gr.seriesSnapshot(),
+		"sideboarding": gr.sideboard > 0,
+	})
+}
+
+// seriesResult diz se a série acabou e quem venceu ("" = empatada). Ela acaba
+// quando alguém chega às vitórias necessárias ou quando todos os jogos foram
+// jogados (empates podem deixar a série sem maioria).
+func (gr *GameRoom) seriesResult() (string, bool) {
+	leader, best, tied := "", -1, false
+	for _, id := range gr.seats {
+		switch wins := gr.series.score[id]; {
+		case wins >= rules.WinsNeeded(gr.series.bestOf):
+			return id, true
+		case wins > best:
+			leader, best, tied = id, wins, false
+		case wins == best:
+			tied = true
+		}
+	}
+	if gr.series.game < gr.series.bestOf {
+		return "", false
+	}
+	if tied {
+		return "", true
+	}
+	return leader, true
+}
+
+// seriesReason é o motivo do GAME_OVER de uma série, com o placar final.
+func (gr *GameRoom) seriesReason(winnerID string, lastGame string) string {
+	p1, p2 := gr.seats[0], gr.seats[1]
+	score := fmt.Sprintf("%d-%d", gr.series.score[p1], gr.series.score[p2])
+	if winnerID == "" {
+		return fmt.Sprintf("The series ended tied %s after %d games. Last game: %s", score, gr.series.game, lastGame)
+	}
+	winnerScore := fmt.Sprintf("%d-%d", gr.series.score[winnerID], gr.series.score[gr.getOpponentID(winnerID)])
+	return fmt.Sprintf("Player %s won the best of %d series %s. Last game: %s", winnerID, gr.series.bestOf, winnerScore, lastGame)
+}
+
+// nextGameDue devolve o canal do timer do próximo jogo (nil fora do intervalo).
+func (gr *GameRoom) nextGameDue() <-chan time.Time {
+	if gr.series.nextGame == nil {
+		return nil
+	}
+	return gr.series.nextGame.C
+}
+
+// startNextGame devolve as cartas aos decks, aplica os sideboards e começa o
+// próximo jogo da série.
+func (gr *GameRoom) startNextGame() {
+	gr.series.nextGame = nil
+	if gr.getGameState() != phase_BETWEEN_GAMES {
+		return
+	}
+	for _, id := range gr.seats {
+		pInfo := gr.players[id]
+		if keys, ok := gr.series.sideboards[id]; ok {
+			gameDeck, err := buildGameDeck(keys)
+			if err != nil {
+				// O deck já foi validado no sideboard; se falhar aqui, segue com o antigo.
+				log.Printf("[GameRoom %s] ERROR: Failed to apply sideboard of %s: %v", gr.ID, id, err)
+			} else {
+				pInfo.GameDeck = gameDeck
+			}
+		}
+		pInfo.GameDeck.ResetForNextGame()
+	}
+	gr.series.sideboards = make(map[string][]string)
+	gr.playedCards = make(map[string]*card.Card)
+	gr.drawOffer = ""
+	gr.round = 0
+	gr.setGameState(phase_ROOM_START)
+	gr.startGame()
+}
+
+// handleSideboard guarda o deck novo de um jogador para o próximo jogo.
+func (gr *GameRoom) handleSideboard(act SideboardAction) {
+	act.reply <- gr.applySideboard(act.PlayerID, act.Deck)
+}
+
+func (gr *GameRoom) applySideboard(playerID string, keys []string) error {
+	if gr.getGameState() != phase_BETWEEN_GAMES {
+		return errNotBetweenGames
+	}
+	size := gr.deckSizeOf(playerID)
+	if len(keys) != size {
+		return fmt.Errorf("the sideboarded deck must have %d cards, got %d", size, len(keys))
+	}
+	if _, err := buildGameDeck(keys); err != nil {
+		return err
+	}
+	gr.series.sideboards[playerID] = append([]string(nil), keys...)
+	log.Printf("[GameRoom %s] Player %s sideboarded for game %d.", gr.ID, playerID, gr.series.game+1)
+	return nil
+}
+
+// deckSizeOf é o tamanho do deck com que o jogador entrou na série.
+func (gr *GameRoom) deckSizeOf(playerID string) int {
+	for _, entry := range gr.entries {
+		if entry.ID == playerID {
+			return len(entry.Deck)
+		}
+	}
+	return 0
+}
+
+// seriesSnapshot monta o placar atual da série (nil numa partida única).
+func (gr *GameRoom) seriesSnapshot() *SeriesSnapshot {
+	if !gr.isSeries() {
+		return nil
+	}
+	snapshot := &SeriesSnapshot{
+		BestOf: gr.series.bestOf,
+		Game:   gr.series.game,
+		Score:  make(map[string]int, len(gr.seats)),
+	}
+	for _, id := range gr.seats {
+		snapshot.Score[id] = gr.series.score[id]
+	}
+	if gr.getGameState() == phase_BETWEEN_GAMES && !gr.series.startsAt.IsZero() {
+		snapshot.NextGameInMs = max(0, time.Until(gr.series.startsAt).Milliseconds())
+	}
+	return snapshot
+}
+
+// buildGameDeck monta um deck de partida a partir das chaves, na ordem dada.
+func buildGameDeck(keys []string) (*deck.Deck, error) {
+	gameDeck := deck.NewDeck()
+	for _, cardKey := range keys {
+		c, err := card.GetCard(cardKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid card in deck: %w", err)
+		}
+		gameDeck.AddCardToZone(deck.DECK, c)
+	}
+	return gameDeck, nil
+}
+
+// handleSideboardAction lida com POST /rooms/{id}/sideboard.
+func handleSideboardAction(w http.ResponseWriter, r *http.Request, room *GameRoom) {
+	if r.Method != http.MethodPost {
+		http.Error(w, `{"error": "Use POST for /sideboard action"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	var req SideboardRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PlayerID == "" {
+		http.Error(w, `{"error": "Invalid payload for sideboard action"}`, http.StatusBadRequest)
+		return
+	}
+	if _, ok := room.players[req.PlayerID]; !ok {
+		http.Error(w, `{"error": "Player is not in this room"}`, http.StatusNotFound)
+		return
+	}
+	// Como a presença, o sideboard não pode ser descartado, e o jogador precisa
+	// saber se o deck foi aceito: espera a resposta da goroutine da sala.
+	action := SideboardAction{PlayerID: req.PlayerID, Deck: req.Deck, reply: make(chan error, 1)}
+	select {
+	case room.incoming <- action:
+	case <-room.quit:
+		http.Error(w, `{"error": "The match is over"}`, http.StatusGone)
+		return
+	case <-time.After(snapshotTimeout):
+		http.Error(w, `{"error": "Room is busy, try again"}`, http.StatusServiceUnavailable)
+		return
+	}
+	if err := <-action.reply; err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+//END OF FILE jokenpo/internal/services/gameroom/series.go
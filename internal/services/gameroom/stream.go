@@ -0,0 +1,147 @@
+//START OF FILE jokenpo/internal/services/gameroom/stream.go
+package gameroom
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Eventos de jogo: cada jogador da sala tem um log de eventos numerados (seq começa
+// em 1). O Session lê o log por uma conexão longa (GET /rooms/{id}/events, NDJSON)
+// e, se ela cair, reconecta pedindo os eventos depois do último seq recebido.
+// Assim os eventos chegam em ordem e nenhum se perde numa queda de conexão.
+
+const (
+	streamHeartbeat       = 15 * time.Second // Linha vazia para manter a conexão viva
+	finishedRoomRetention = 2 * time.Minute  // Tempo que o log fica disponível depois do GAME_OVER
+)
+
+// StreamEvent é uma linha do stream de eventos.
+type StreamEvent struct {
+	Seq       uint64          `json:"seq"`
+	EventType string          `json:"eventType"`
+	PlayerID  string          `json:"playerId"`
+	RoomID    string          `json:"roomId"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// eventLog guarda os eventos de um jogador. append é chamado pela goroutine da
+// sala; since, pelos handlers HTTP do stream.
+type eventLog struct {
+	mu     sync.Mutex
+	events []StreamEvent
+	notify chan struct{} // Fechado (e trocado) a cada evento novo
+	closed bool          // A sala terminou: nenhum evento novo virá
+}
+
+func newEventLog() *eventLog {
+	return &eventLog{notify: make(chan struct{})}
+}
+
+// append acrescenta o evento; devolve false se o log já foi fechado.
+func (l *eventLog) append(event StreamEvent) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return false
+	}
+	event.Seq = uint64(len(l.events)) + 1
+	l.events = append(l.events, event)
+	close(l.notify)
+	l.notify = make(chan struct{})
+	return true
+}
+
+func (l *eventLog) close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.closed {
+		l.closed = true
+		close(l.notify)
+	}
+}
+
+// lastSeq devolve o seq do último evento do log.
+func (l *eventLog) lastSeq() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return uint64(len(l.events))
+}
+
+// since devolve os eventos com seq maior que after, o canal que avisa do próximo
+// evento e se o log já foi fechado.
+func (l *eventLog) since(after uint64) ([]StreamEvent, <-chan struct{}, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var pending []StreamEvent
+	if after < uint64(len(l.events)) {
+		pending = append(pending, l.events[after:]...)
+	}
+	return pending, l.notify, l.closed
+}
+
+// handleEventStream lida com GET /rooms/{id}/events?playerId=...&after=N. A resposta
+// fica aberta até a sala terminar (ou o Session desconectar).
+func handleEventStream(w http.ResponseWriter, r *http.Request, room *GameRoom) {
+	if r.Method != http.MethodGet {
+		http.Error(w, `{"error": "Use GET for /events"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	events, ok := room.streams[r.URL.Query().Get("playerId")]
+	if !ok {
+		http.Error(w, `{"error": "Player is not in this room"}`, http.StatusNotFound)
+		return
+	}
+	var after uint64
+	if raw := r.URL.Query().Get("after"); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			http.Error(w, `{"error": "Invalid 'after' sequence"}`, http.StatusBadRequest)
+			return
+		}
+		after = parsed
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, `{"error": "Streaming unsupported"}`, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	encoder := json.NewEncoder(w)
+	for {
+		pending, wait, closed := events.since(after)
+		for _, event := range pending {
+			if err := encoder.Encode(event); err != nil {
+				return
+			}
+			after = event.Seq
+		}
+		flusher.Flush()
+		if closed {
+			log.Printf("[GameRoom %s] Event stream of %s ended at seq %d.", room.ID, r.URL.Query().Get("playerId"), after)
+			return
+		}
+		select {
+		case <-wait:
+		case <-heartbeat.C:
+			if _, err := w.Write([]byte("\n")); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+//END OF FILE jokenpo/internal/services/gameroom/stream.go
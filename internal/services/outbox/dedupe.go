@@ -0,0 +1,102 @@
+//START OF FILE jokenpo/internal/services/outbox/dedupe.go
+package outbox
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Deduplicator é o lado de quem recebe os callbacks: guarda os Idempotency-Key já
+// processados por ttl e responde 200 sem chamar o handler quando um deles volta
+// (uma repetição do outbox cuja resposta anterior se perdeu). Uma chave só conta
+// como processada se o handler respondeu 2xx; se falhou, a repetição é processada.
+// Uma repetição que chega enquanto a primeira entrega ainda está no handler recebe
+// 503: ainda não se sabe se ela vai dar certo, então o outbox tenta de novo depois.
+type Deduplicator struct {
+	mu   sync.Mutex
+	seen map[string]time.Time // chave -> quando foi processada (zero = em processamento)
+	ttl  time.Duration
+}
+
+func NewDeduplicator(ttl time.Duration) *Deduplicator {
+	return &Deduplicator{seen: make(map[string]time.Time), ttl: ttl}
+}
+
+// Middleware aplica a deduplicação a um handler de callback. Pedidos sem o
+// header passam direto.
+func (d *Deduplicator) Middleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyHeader)
+		if key == "" {
+			next(w, r)
+			return
+		}
+		switch d.claim(key) {
+		case keyProcessed:
+			log.Printf("[Dedupe] Callback %s %s was already processed. Ignoring the retry.", r.URL.Path, key)
+			w.WriteHeader(http.StatusOK)
+			return
+		case keyInFlight:
+			log.Printf("[Dedupe] Callback %s %s is still being processed. Asking for a later retry.", r.URL.Path, key)
+			http.Error(w, "Callback is still being processed", http.StatusServiceUnavailable)
+			return
+		}
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		returned := false
+		// Se o handler entrar em pânico, a chave é liberada para a próxima repetição.
+		defer func() { d.finish(key, returned && rec.status >= 200 && rec.status < 300) }()
+		next(rec, r)
+		returned = true
+	}
+}
+
+// Estados de uma chave devolvidos por claim.
+const (
+	keyClaimed   = iota // Nova: o chamador processa
+	keyInFlight         // Outra entrega com a mesma chave está no handler
+	keyProcessed        // Já processada com sucesso
+)
+
+// claim marca a chave como em processamento se ela é nova.
+func (d *Deduplicator) claim(key string) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	for k, at := range d.seen {
+		if !at.IsZero() && now.Sub(at) > d.ttl {
+			delete(d.seen, k)
+		}
+	}
+	if at, ok := d.seen[key]; ok {
+		if at.IsZero() {
+			return keyInFlight
+		}
+		return keyProcessed
+	}
+	d.seen[key] = time.Time{}
+	return keyClaimed
+}
+
+func (d *Deduplicator) finish(key string, ok bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if ok {
+		d.seen[key] = time.Now()
+	} else {
+		delete(d.seen, key)
+	}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+//END OF FILE jokenpo/internal/services/outbox/dedupe.go
@@ -0,0 +1,344 @@
+//START OF FILE jokenpo/internal/services/outbox/outbox.go
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"jokenpo/internal/services/cluster"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	consul "github.com/hashicorp/consul/api"
+)
+
+// Outbox entrega callbacks HTTP (/match-found, /trade-found, /market-event, ...)
+// sem perdê-los quando o destino está fora do ar. Cada mensagem é gravada no KV
+// do Consul antes de sair (jokenpo/outbox/<owner>/<id>) e só é apagada quando o
+// destino responde 2xx. Falhas de rede e respostas 5xx são repetidas com backoff
+// exponencial; esgotadas as tentativas, a mensagem vai para a lista de mensagens
+// mortas (jokenpo/outbox-dlq/<owner>/<id>). Respostas 4xx são definitivas: o
+// destino recebeu e recusou (ex: o jogador não está mais naquele nó).
+//
+// Cada POST leva o header Idempotency-Key com o ID da mensagem, que não muda entre
+// tentativas: o Session usa o Deduplicator para não processar o mesmo evento duas vezes.
+//
+// As mensagens de um mesmo stream (normalmente o ID do jogador) saem em ordem:
+// a próxima só é enviada depois que a anterior foi entregue ou descartada.
+
+const (
+	KeyPrefix           = "jokenpo/outbox/"
+	DeadLetterKeyPrefix = "jokenpo/outbox-dlq/"
+	IdempotencyHeader   = "Idempotency-Key"
+
+	MaxAttemptsEnv = "OUTBOX_MAX_ATTEMPTS"        // Tentativas antes da lista de mensagens mortas
+	BaseBackoffEnv = "OUTBOX_BASE_BACKOFF_MS"     // Espera depois da primeira falha; dobra a cada tentativa
+	MaxBackoffEnv  = "OUTBOX_MAX_BACKOFF_SECONDS" // Teto da espera entre tentativas
+
+	defaultMaxAttempts = 10
+	defaultBaseBackoff = 200 * time.Millisecond
+	defaultMaxBackoff  = 30 * time.Second
+)
+
+// Message é um callback pendente.
+type Message struct {
+	ID          string          `json:"id"` // Também é o Idempotency-Key
+	Stream      string          `json:"stream"`
+	Label       string          `json:"label"` // Só para os logs (ex: "GAME_EVENT ROUND_START")
+	URL         string          `json:"url"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempts    int             `json:"attempts"`
+	LastError   string          `json:"lastError,omitempty"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	NextAttempt time.Time       `json:"nextAttempt"`
+}
+
+// Outbox é um ator: Enqueue grava e entrega a mensagem ao Run, que despacha as
+// tentativas e recebe os resultados.
+type Outbox struct {
+	owner      string
+	manager    *cluster.ConsulManager
+	httpClient *http.Client
+
+	maxAttempts int
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+
+	incoming chan *Message
+	results  chan deliveryResult
+
+	// Estado do ator (só acessado dentro de Run).
+	streams  map[string][]*Message
+	inFlight map[string]bool
+}
+
+type deliveryResult struct {
+	msg    *Message
+	status int // 0 = erro de rede
+	err    error
+}
+
+// New cria o outbox de owner (ex: "jokenpo-queue" ou "jokenpo-gameroom/<host>").
+// Com manager nil as mensagens ficam só em memória.
+func New(owner string, manager *cluster.ConsulManager) *Outbox {
+	return &Outbox{
+		owner:       owner,
+		manager:     manager,
+		httpClient:  &http.Client{Timeout: 5 * time.Second},
+		maxAttempts: envInt(MaxAttemptsEnv, defaultMaxAttempts),
+		baseBackoff: time.Duration(envInt(BaseBackoffEnv, int(defaultBaseBackoff/time.Millisecond))) * time.Millisecond,
+		maxBackoff:  time.Duration(envInt(MaxBackoffEnv, int(defaultMaxBackoff/time.Second))) * time.Second,
+		incoming:    make(chan *Message, 256),
+		results:     make(chan deliveryResult, 64),
+		streams:     make(map[string][]*Message),
+		inFlight:    make(map[string]bool),
+	}
+}
+
+// Enqueue grava o callback e o agenda para entrega. Se o Consul estiver fora, a
+// mensagem segue só em memória. Se o ator não estiver rodando, ela fica gravada e
+// sai no próximo Run (ex: quando este nó do Queue voltar a ser líder).
+func (o *Outbox) Enqueue(stream, url, label string, payload any) error {
+	if url == "" {
+		return fmt.Errorf("callback %s for %s has an empty URL", label, stream)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal callback %s: %w", label, err)
+	}
+	now := time.Now()
+	msg := &Message{
+		ID:          uuid.NewString(),
+		Stream:      stream,
+		Label:       label,
+		URL:         url,
+		Payload:     data,
+		CreatedAt:   now,
+		NextAttempt: now,
+	}
+	if err := o.save(KeyPrefix, msg); err != nil {
+		log.Printf("[Outbox %s] WARN: Could not persist %s for %s, keeping it in memory only: %v", o.owner, label, stream, err)
+	}
+	select {
+	case o.incoming <- msg:
+	case <-time.After(2 * time.Second):
+		log.Printf("[Outbox %s] WARN: Delivery loop is not running; %s for %s waits for the next start.", o.owner, label, stream)
+	}
+	return nil
+}
+
+// Run é o ator do outbox. Ao começar, recarrega as mensagens pendentes do Consul
+// (deixadas por uma execução anterior ou pelo líder anterior).
+func (o *Outbox) Run(ctx context.Context) {
+	o.streams = make(map[string][]*Message)
+	o.inFlight = make(map[string]bool)
+	for _, msg := range o.loadPending() {
+		o.streams[msg.Stream] = append(o.streams[msg.Stream], msg)
+	}
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		o.dispatch(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-o.incoming:
+			if !o.queued(msg) {
+				o.streams[msg.Stream] = append(o.streams[msg.Stream], msg)
+			}
+		case res := <-o.results:
+			o.handleResult(res)
+		case <-ticker.C:
+		}
+	}
+}
+
+// queued evita duplicar uma mensagem que o Run já recarregou do Consul.
+func (o *Outbox) queued(msg *Message) bool {
+	for _, m := range o.streams[msg.Stream] {
+		if m.ID == msg.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// dispatch envia a cabeça de cada stream que não está esperando resposta nem backoff.
+func (o *Outbox) dispatch(ctx context.Context) {
+	now := time.Now()
+	for stream, queue := range o.streams {
+		if len(queue) == 0 {
+			delete(o.streams, stream)
+			continue
+		}
+		head := queue[0]
+		if o.inFlight[stream] || now.Before(head.NextAttempt) {
+			continue
+		}
+		o.inFlight[stream] = true
+		go func(msg *Message) {
+			status, err := o.post(ctx, msg)
+			select {
+			case o.results <- deliveryResult{msg: msg, status: status, err: err}:
+			case <-ctx.Done():
+			}
+		}(head)
+	}
+}
+
+func (o *Outbox) post(ctx context.Context, msg *Message) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, msg.URL, bytes.NewReader(msg.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IdempotencyHeader, msg.ID)
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func (o *Outbox) handleResult(res deliveryResult) {
+	msg := res.msg
+	delete(o.inFlight, msg.Stream)
+	msg.Attempts++
+	switch {
+	case res.err == nil && res.status >= 200 && res.status < 300:
+		o.pop(msg)
+		o.remove(KeyPrefix, msg)
+		return
+	case res.err == nil && res.status < 500 && res.status != http.StatusTooManyRequests && res.status != http.StatusRequestTimeout:
+		log.Printf("[Outbox %s] %s for %s rejected by %s (%d). Dropping it.", o.owner, msg.Label, msg.Stream, msg.URL, res.status)
+		o.pop(msg)
+		o.remove(KeyPrefix, msg)
+		return
+	}
+
+	if res.err != nil {
+		msg.LastError = res.err.Error()
+	} else {
+		msg.LastError = fmt.Sprintf("received status %d", res.status)
+	}
+	if msg.Attempts >= o.maxAttempts {
+		log.Printf("[Outbox %s] ERROR: %s for %s failed %d times (%s). Moving it to the dead-letter list.", o.owner, msg.Label, msg.Stream, msg.Attempts, msg.LastError)
+		o.pop(msg)
+		if err := o.save(DeadLetterKeyPrefix, msg); err != nil {
+			log.Printf("[Outbox %s] WARN: Could not store dead letter %s: %v", o.owner, msg.ID, err)
+		}
+		o.remove(KeyPrefix, msg)
+		return
+	}
+	wait := o.backoff(msg.Attempts)
+	msg.NextAttempt = time.Now().Add(wait)
+	log.Printf("[Outbox %s] WARN: Attempt %d of %s for %s failed (%s). Retrying in %v.", o.owner, msg.Attempts, msg.Label, msg.Stream, msg.LastError, wait)
+	if err := o.save(KeyPrefix, msg); err != nil {
+		log.Printf("[Outbox %s] WARN: Could not persist retry state of %s: %v", o.owner, msg.ID, err)
+	}
+}
+
+// backoff dobra a espera a cada tentativa, até maxBackoff.
+func (o *Outbox) backoff(attempts int) time.Duration {
+	wait := o.baseBackoff
+	for i := 1; i < attempts && wait < o.maxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, o.maxBackoff)
+}
+
+func (o *Outbox) pop(msg *Message) {
+	queue := o.streams[msg.Stream]
+	if len(queue) > 0 && queue[0].ID == msg.ID {
+		o.streams[msg.Stream] = queue[1:]
+	}
+}
+
+// --- Persistência no KV do Consul ---
+
+func (o *Outbox) key(prefix string, msg *Message) string {
+	return prefix + o.owner + "/" + msg.ID
+}
+
+func (o *Outbox) kv() *consul.KV {
+	if o.manager == nil {
+		return nil
+	}
+	client := o.manager.GetClient()
+	if client == nil {
+		return nil
+	}
+	return client.KV()
+}
+
+func (o *Outbox) save(prefix string, msg *Message) error {
+	if o.manager == nil {
+		return nil // Outbox só em memória.
+	}
+	kv := o.kv()
+	if kv == nil {
+		return fmt.Errorf("consul client is not available")
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = kv.Put(&consul.KVPair{Key: o.key(prefix, msg), Value: data}, nil)
+	return err
+}
+
+func (o *Outbox) remove(prefix string, msg *Message) {
+	kv := o.kv()
+	if kv == nil {
+		return
+	}
+	if _, err := kv.Delete(o.key(prefix, msg), nil); err != nil {
+		log.Printf("[Outbox %s] WARN: Could not delete %s from the outbox: %v", o.owner, msg.ID, err)
+	}
+}
+
+// loadPending lê as mensagens gravadas, em ordem de criação.
+func (o *Outbox) loadPending() []*Message {
+	kv := o.kv()
+	if kv == nil {
+		return nil
+	}
+	pairs, _, err := kv.List(KeyPrefix+o.owner+"/", nil)
+	if err != nil {
+		log.Printf("[Outbox %s] WARN: Could not load pending callbacks: %v", o.owner, err)
+		return nil
+	}
+	pending := make([]*Message, 0, len(pairs))
+	for _, pair := range pairs {
+		var msg Message
+		if err := json.Unmarshal(pair.Value, &msg); err != nil {
+			log.Printf("[Outbox %s] WARN: Skipping unreadable callback %s: %v", o.owner, pair.Key, err)
+			continue
+		}
+		pending = append(pending, &msg)
+	}
+	slices.SortFunc(pending, func(a, b *Message) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	if len(pending) > 0 {
+		log.Printf("[Outbox %s] Resuming %d pending callbacks.", o.owner, len(pending))
+	}
+	return pending
+}
+
+func envInt(name string, fallback int) int {
+	if raw := os.Getenv(name); raw != "" {
+		if v, err := strconv.Atoi(raw); err == nil && v > 0 {
+			return v
+		}
+		log.Printf("[Outbox] WARN: Invalid %s=%q, using %d", name, raw, fallback)
+	}
+	return fallback
+}
+
+//END OF FILE jokenpo/internal/services/outbox/outbox.go
@@ -0,0 +1,95 @@
+//START OF FILE jokenpo/internal/services/presence/resume.go
+package presence
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	consul "github.com/hashicorp/consul/api"
+)
+
+// ResumeKeyPrefix guarda a partida em andamento de cada jogador:
+// jokenpo/resume/<playerId> -> ResumeRecord. O Session grava quando a partida
+// começa e apaga no GAME_OVER; um jogador que caiu no meio da partida volta a ela
+// em qualquer nó apresentando o token do registro.
+const ResumeKeyPrefix = "jokenpo/resume/"
+
+// ResumeRecord aponta para a sala onde o jogador está jogando.
+type ResumeRecord struct {
+	Token       string    `json:"token"`
+	RoomID      string    `json:"roomId"`
+	ServiceAddr string    `json:"serviceAddr"`
+	IssuedAt    time.Time `json:"issuedAt"`
+}
+
+// ResumeStore lê e grava os registros de retomada no KV do Consul.
+type ResumeStore struct {
+	store *Store
+}
+
+func NewResumeStore(store *Store) *ResumeStore {
+	return &ResumeStore{store: store}
+}
+
+// Save grava (ou substitui) a partida em andamento do jogador.
+func (r *ResumeStore) Save(playerID string, record ResumeRecord) error {
+	client := r.store.manager.GetClient()
+	if client == nil {
+		return ErrConsulUnavailable
+	}
+	value, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if _, err := client.KV().Put(&consul.KVPair{Key: ResumeKeyPrefix + playerID, Value: value}, nil); err != nil {
+		return fmt.Errorf("failed to save resume record of %s: %w", playerID, err)
+	}
+	return nil
+}
+
+// Load devolve a partida em andamento do jogador, ou nil se não há nenhuma.
+func (r *ResumeStore) Load(playerID string) (*ResumeRecord, error) {
+	client := r.store.manager.GetClient()
+	if client == nil {
+		return nil, ErrConsulUnavailable
+	}
+	pair, _, err := client.KV().Get(ResumeKeyPrefix+playerID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read resume record of %s: %w", playerID, err)
+	}
+	if pair == nil {
+		return nil, nil
+	}
+	var record ResumeRecord
+	if err := json.Unmarshal(pair.Value, &record); err != nil {
+		return nil, fmt.Errorf("corrupted resume record of %s: %w", playerID, err)
+	}
+	return &record, nil
+}
+
+// Clear apaga o registro, mas só se ele ainda é da sala roomID: uma partida nova
+// já gravada por cima não é afetada.
+func (r *ResumeStore) Clear(playerID, roomID string) error {
+	client := r.store.manager.GetClient()
+	if client == nil {
+		return ErrConsulUnavailable
+	}
+	pair, _, err := client.KV().Get(ResumeKeyPrefix+playerID, nil)
+	if err != nil {
+		return fmt.Errorf("failed to read resume record of %s: %w", playerID, err)
+	}
+	if pair == nil {
+		return nil
+	}
+	var record ResumeRecord
+	if json.Unmarshal(pair.Value, &record) == nil && record.RoomID != roomID {
+		return nil
+	}
+	if _, _, err := client.KV().DeleteCAS(pair, nil); err != nil {
+		return fmt.Errorf("failed to clear resume record of %s: %w", playerID, err)
+	}
+	return nil
+}
+
+//END OF FILE jokenpo/internal/services/presence/resume.go
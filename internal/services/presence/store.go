@@ -0,0 +1,135 @@
+//START OF FILE jokenpo/internal/services/presence/store.go
+package presence
+
+import (
+	"errors"
+	"fmt"
+	"jokenpo/internal/services/cluster"
+	"log"
+	"sync"
+	"time"
+
+	consul "github.com/hashicorp/consul/api"
+)
+
+// KeyPrefix é onde a presença fica no KV do Consul: jokenpo/presence/<playerId> -> URL
+// base do nó do Session que segura a conexão (ex: "http://session-2:8080").
+// O Session grava no login e apaga no disconnect; o Queue lê para entregar eventos
+// a um jogador sem saber em qual nó ele está.
+//
+// A chave também é a trava do login: o nó a adquire (Acquire) com uma session do
+// Consul própria dele, então a mesma conta não entra em dois nós ao mesmo tempo.
+// Se o nó cair, a session expira e as chaves dele são apagadas.
+const KeyPrefix = "jokenpo/presence/"
+
+const (
+	nodeSessionTTL       = "15s"
+	nodeSessionLockDelay = time.Second // Espera depois que a session de um nó caído expira
+)
+
+var (
+	ErrConsulUnavailable = errors.New("consul client is not available")
+	ErrAlreadyConnected  = errors.New("player is connected on another node")
+)
+
+// Store registra em qual nó do Session cada jogador está conectado.
+type Store struct {
+	manager *cluster.ConsulManager
+
+	mu        sync.Mutex
+	sessionID string // Session do Consul deste nó que segura as chaves; "" até o primeiro Claim
+}
+
+func NewStore(manager *cluster.ConsulManager) *Store {
+	return &Store{manager: manager}
+}
+
+// Claim marca o jogador como conectado no nó nodeURL, adquirindo a chave com a
+// session deste nó. Retorna ErrAlreadyConnected se outro nó segura a chave.
+func (s *Store) Claim(playerID, nodeURL string) error {
+	client := s.manager.GetClient()
+	if client == nil {
+		return ErrConsulUnavailable
+	}
+	sessionID, err := s.nodeSession(client)
+	if err != nil {
+		return err
+	}
+	acquired, _, err := client.KV().Acquire(&consul.KVPair{Key: KeyPrefix + playerID, Value: []byte(nodeURL), Session: sessionID}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to claim presence of %s: %w", playerID, err)
+	}
+	if !acquired {
+		return ErrAlreadyConnected
+	}
+	return nil
+}
+
+// nodeSession devolve a session do Consul deste nó, criando-a se preciso. Ela é
+// renovada em segundo plano; se a renovação falhar, o próximo Claim cria outra.
+func (s *Store) nodeSession(client *consul.Client) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sessionID != "" {
+		return s.sessionID, nil
+	}
+	se := &consul.SessionEntry{
+		Name:      "jokenpo-session-presence",
+		TTL:       nodeSessionTTL,
+		Behavior:  consul.SessionBehaviorDelete, // Apaga as presenças do nó quando ele cai
+		LockDelay: nodeSessionLockDelay,
+	}
+	id, _, err := client.Session().Create(se, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create presence session: %w", err)
+	}
+	s.sessionID = id
+	go func() {
+		err := client.Session().RenewPeriodic(nodeSessionTTL, id, nil, nil)
+		log.Printf("[Presence] WARN: Session %s is no longer renewed: %v", id, err)
+		s.mu.Lock()
+		if s.sessionID == id {
+			s.sessionID = ""
+		}
+		s.mu.Unlock()
+	}()
+	return id, nil
+}
+
+// Withdraw apaga a presença, mas só se ela ainda aponta para nodeURL: se o jogador
+// já reconectou em outro nó, o registro novo é mantido.
+func (s *Store) Withdraw(playerID, nodeURL string) error {
+	client := s.manager.GetClient()
+	if client == nil {
+		return ErrConsulUnavailable
+	}
+	pair, _, err := client.KV().Get(KeyPrefix+playerID, nil)
+	if err != nil {
+		return fmt.Errorf("failed to read presence of %s: %w", playerID, err)
+	}
+	if pair == nil || string(pair.Value) != nodeURL {
+		return nil
+	}
+	if _, _, err := client.KV().DeleteCAS(pair, nil); err != nil {
+		return fmt.Errorf("failed to withdraw presence of %s: %w", playerID, err)
+	}
+	return nil
+}
+
+// Locate devolve a URL base do nó do jogador, ou "" se ele não está conectado.
+func (s *Store) Locate(playerID string) (string, error) {
+	client := s.manager.GetClient()
+	if client == nil {
+		return "", ErrConsulUnavailable
+	}
+	pair, _, err := client.KV().Get(KeyPrefix+playerID, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to read presence of %s: %w", playerID, err)
+	}
+	if pair == nil {
+		return "", nil
+	}
+	return string(pair.Value), nil
+}
+
+//END OF FILE jokenpo/internal/services/presence/store.go
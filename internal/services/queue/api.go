@@ -3,9 +3,14 @@ package queue
 
 import (
 	"encoding/json"
+	"errors"
+	"jokenpo/internal/game/deck"
+	"jokenpo/internal/game/rules"
 	"jokenpo/internal/services/cluster"
 	"log"
 	"net/http"
+	"strings"
+	"time"
 )
 
 // ============================================================================
@@ -13,9 +18,13 @@ import (
 // ============================================================================
 
 type EnqueueMatchRequest struct {
-	PlayerID    string   `json:"playerId"`
-	CallbackURL string   `json:"callbackUrl"` // Esta será a URL para /game-event
-	Deck        []string `json:"deck"`
+	PlayerID    string        `json:"playerId"`
+	CallbackURL string        `json:"callbackUrl"` // URL base do Session do jogador, repassada ao GameRoom
+	Deck        []string      `json:"deck"`
+	Ruleset     string        `json:"ruleset,omitempty"`     // Só jogadores com o mesmo ruleset são pareados
+	BestOf      int           `json:"bestOf,omitempty"`      // Formato da série (melhor de N); 0 ou 1 = partida única
+	Entropy     string        `json:"entropy,omitempty"`     // Repassada ao GameRoom para a semente do embaralhamento
+	DeckProfile *deck.Profile `json:"deckProfile,omitempty"` // Informativo: o Queue recalcula das chaves e só registra divergências
 }
 
 type EnqueueTradeRequest struct {
@@ -28,18 +37,86 @@ type DequeueRequest struct {
 	PlayerID string `json:"playerId"`
 }
 
+type CreateListingRequest struct {
+	PlayerID    string   `json:"playerId"`
+	CallbackURL string   `json:"callbackUrl"` // URL para /market-event
+	Offer       string   `json:"offer"`
+	Want        []string `json:"want,omitempty"` // Vazio = aceita qualquer carta
+}
+
+type CreateListingResponse struct {
+	ListingID string    `json:"listingId"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// MarketPlayerRequest identifica o jogador que cancela uma oferta ou responde a um par.
+type MarketPlayerRequest struct {
+	PlayerID string `json:"playerId"`
+}
+
+// ProposeTradeRequest é uma troca direta: FromID dá Give a ToID e recebe Receive.
+// ProposalID, escolhido pelo Session, torna o pedido seguro de repetir.
+type ProposeTradeRequest struct {
+	ProposalID string   `json:"proposalId,omitempty"`
+	FromID     string   `json:"fromId"`
+	ToID       string   `json:"toId"`
+	Give       []string `json:"give"`
+	Receive    []string `json:"receive"`
+}
+
+type ProposeTradeResponse struct {
+	ProposalID string    `json:"proposalId"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+}
+
 // ============================================================================
 // Configuração dos Handlers
 // ============================================================================
 
-func RegisterQueueHandlers(mux *http.ServeMux, queueMaster *QueueMaster, elector *cluster.LeaderElector) {
+func RegisterQueueHandlers(mux *http.ServeMux, queueMaster *QueueMaster, elector *cluster.LeaderElector, catalogGuard *cluster.CatalogGuard) {
 	leaderOnly := leaderOnlyMiddleware(elector)
-	mux.Handle("/queue/match", leaderOnly(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	mux.Handle("/queue/match", leaderOnly(catalogGuard.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		handleMatchQueue(w, r, queueMaster)
-	})))
+	}))))
 	mux.Handle("/queue/trade", leaderOnly(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		handleTradeQueue(w, r, queueMaster)
 	})))
+	mux.Handle("/queue/trade/escrows", leaderOnly(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handleCollectEscrows(w, r, queueMaster)
+	})))
+	mux.Handle("/queue/trade/escrows/ack", leaderOnly(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handleAckDelivery(w, r, queueMaster.AckEscrows)
+	})))
+	mux.Handle("/queue/custody", leaderOnly(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handleCustody(w, r, queueMaster)
+	})))
+	mux.Handle("/market/listings", leaderOnly(catalogGuard.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handleListings(w, r, queueMaster)
+	}))))
+	mux.Handle("/market/listings/", leaderOnly(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handleListingAction(w, r, queueMaster)
+	})))
+	mux.Handle("/market/matches/", leaderOnly(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handleListingMatchAction(w, r, queueMaster)
+	})))
+	mux.Handle("/market/outcomes", leaderOnly(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handleCollectListingOutcomes(w, r, queueMaster)
+	})))
+	mux.Handle("/market/outcomes/ack", leaderOnly(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handleAckDelivery(w, r, queueMaster.AckListingOutcomes)
+	})))
+	mux.Handle("/trades/proposals", leaderOnly(catalogGuard.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handleProposeTrade(w, r, queueMaster)
+	}))))
+	mux.Handle("/trades/proposals/", leaderOnly(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handleProposalAction(w, r, queueMaster)
+	})))
+	mux.Handle("/trades/outcomes", leaderOnly(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handleCollectTradeOutcomes(w, r, queueMaster)
+	})))
+	mux.Handle("/trades/outcomes/ack", leaderOnly(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handleAckDelivery(w, r, queueMaster.AckTradeOutcomes)
+	})))
 }
 
 func leaderOnlyMiddleware(elector *cluster.LeaderElector) func(http.Handler) http.Handler {
@@ -74,15 +151,44 @@ func handleMatchQueue(w http.ResponseWriter, r *http.Request, qm *QueueMaster) {
 			return
 		}
 
+		bestOf, err := rules.NormalizeBestOf(req.BestOf)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		// O perfil do deck (faixa de poder) é sempre calculado aqui, a partir das
+		// chaves, com o catálogo do Queue: a sessão não escolhe a própria faixa.
+		profile, err := deck.ProfileFromKeys(req.Deck)
+		if err != nil {
+			http.Error(w, `{"error": "Invalid deck: unknown card key"}`, http.StatusBadRequest)
+			return
+		}
+		// O perfil enviado pela sessão é só informativo; se divergir, fica registrado
+		// (catálogos diferentes entre os serviços) e vale o calculado aqui.
+		if sent := req.DeckProfile; sent != nil && (sent.Power != profile.Power || sent.Cards != profile.Cards) {
+			log.Printf("WARN: Deck profile sent by the session of %s (power %d, %d cards) differs from the one computed by the Queue (power %d, %d cards). Using the Queue's.", req.PlayerID, sent.Power, sent.Cards, profile.Power, profile.Cards)
+		}
+
 		log.Printf("[DEBUG] Queue received EnqueueMatchRequest for Player %s. GameCallback: %s, MatchCallback: %s", req.PlayerID, req.CallbackURL, matchCallbackURL)
 
 		player := &PlayerInfo{
 			ID:               req.PlayerID,
-			CallbackURL:      req.CallbackURL, // A URL para /game-event que será passada ao GameRoom
+			CallbackURL:      req.CallbackURL, // URL base do Session, que será passada ao GameRoom
 			MatchCallbackURL: matchCallbackURL,  // A URL para /match-found que o Queue usará
 			Deck:             req.Deck,
+			Ruleset:          req.Ruleset,
+			BestOf:           bestOf,
+			Entropy:          req.Entropy,
+			Rating:           qm.playerRating(req.PlayerID),
+			DeckProfile:      &profile,
+		}
+		if err := qm.EnqueueMatch(player); err != nil {
+			http.Error(w, `{"error": "Queue is not available, try again"}`, http.StatusServiceUnavailable)
+			return
 		}
-		qm.EnqueueMatch(player)
 		w.WriteHeader(http.StatusAccepted)
 
 	case http.MethodDelete:
@@ -91,7 +197,10 @@ func handleMatchQueue(w http.ResponseWriter, r *http.Request, qm *QueueMaster) {
 			http.Error(w, `{"error": "Invalid payload for leaving queue"}`, http.StatusBadRequest)
 			return
 		}
-		qm.DequeueMatch(req.PlayerID)
+		if err := qm.DequeueMatch(req.PlayerID); err != nil {
+			http.Error(w, `{"error": "Queue is not available, try again"}`, http.StatusServiceUnavailable)
+			return
+		}
 		w.WriteHeader(http.StatusOK)
 
 	default:
@@ -114,7 +223,10 @@ func handleTradeQueue(w http.ResponseWriter, r *http.Request, qm *QueueMaster) {
 			},
 			OfferCard: req.OfferCard,
 		}
-		qm.EnqueueTrade(trade)
+		if err := qm.EnqueueTrade(trade); err != nil {
+			http.Error(w, `{"error": "Queue is not available, try again"}`, http.StatusServiceUnavailable)
+			return
+		}
 		w.WriteHeader(http.StatusAccepted)
 
 	case http.MethodDelete:
@@ -123,11 +235,282 @@ func handleTradeQueue(w http.ResponseWriter, r *http.Request, qm *QueueMaster) {
 			http.Error(w, `{"error": "Invalid payload for leaving queue"}`, http.StatusBadRequest)
 			return
 		}
-		qm.DequeueTrade(req.PlayerID)
+		if err := qm.DequeueTrade(req.PlayerID); err != nil {
+			writeMarketError(w, err)
+			return
+		}
 		w.WriteHeader(http.StatusOK)
 
 	default:
 		http.Error(w, `{"error": "Method not allowed"}`, http.StatusMethodNotAllowed)
 	}
 }
+
+// handleCollectEscrows: POST /queue/trade/escrows lista para o Session os escrows
+// terminados do jogador. Eles continuam aqui até o POST /queue/trade/escrows/ack.
+func handleCollectEscrows(w http.ResponseWriter, r *http.Request, qm *QueueMaster) {
+	if r.Method != http.MethodPost {
+		http.Error(w, `{"error": "Method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	var req MarketPlayerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PlayerID == "" {
+		http.Error(w, `{"error": "Invalid payload: 'playerId' is required"}`, http.StatusBadRequest)
+		return
+	}
+	escrows, err := qm.ListEscrows(req.PlayerID)
+	if err != nil {
+		writeMarketError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(escrows)
+}
+
+// handleCustody: POST /queue/custody conta o que ainda prende cartas do jogador
+// no Queue. O Session consulta antes do SYNC_FROM_LEDGER.
+func handleCustody(w http.ResponseWriter, r *http.Request, qm *QueueMaster) {
+	if r.Method != http.MethodPost {
+		http.Error(w, `{"error": "Method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	var req MarketPlayerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PlayerID == "" {
+		http.Error(w, `{"error": "Invalid payload: 'playerId' is required"}`, http.StatusBadRequest)
+		return
+	}
+	report, err := qm.Custody(req.PlayerID)
+	if err != nil {
+		writeMarketError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// ============================================================================
+// Handlers do Mercado de Trocas
+// ============================================================================
+
+// handleListings: POST publica uma oferta; GET lista as ofertas do mercado.
+func handleListings(w http.ResponseWriter, r *http.Request, qm *QueueMaster) {
+	switch r.Method {
+	case http.MethodPost:
+		var req CreateListingRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PlayerID == "" || req.Offer == "" {
+			http.Error(w, `{"error": "Invalid payload: 'playerId' and 'offer' are required"}`, http.StatusBadRequest)
+			return
+		}
+		listing := &TradeListing{
+			PlayerID:    req.PlayerID,
+			CallbackURL: req.CallbackURL,
+			Offer:       req.Offer,
+			Want:        req.Want,
+		}
+		if err := qm.CreateListing(listing); err != nil {
+			writeMarketError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(CreateListingResponse{ListingID: listing.ID, ExpiresAt: listing.ExpiresAt})
+
+	case http.MethodGet:
+		listings, err := qm.BrowseListings()
+		if err != nil {
+			writeMarketError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(listings)
+
+	default:
+		http.Error(w, `{"error": "Method not allowed"}`, http.StatusMethodNotAllowed)
+	}
+}
+
+// handleListingAction: DELETE /market/listings/{id} cancela a oferta do jogador.
+func handleListingAction(w http.ResponseWriter, r *http.Request, qm *QueueMaster) {
+	listingID := strings.TrimPrefix(r.URL.Path, "/market/listings/")
+	if r.Method != http.MethodDelete {
+		http.Error(w, `{"error": "Method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	var req MarketPlayerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PlayerID == "" || listingID == "" {
+		http.Error(w, `{"error": "Invalid payload: 'playerId' is required"}`, http.StatusBadRequest)
+		return
+	}
+	if err := qm.CancelListing(req.PlayerID, listingID); err != nil {
+		writeMarketError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleListingMatchAction: POST /market/matches/{id}/accept ou /decline.
+func handleListingMatchAction(w http.ResponseWriter, r *http.Request, qm *QueueMaster) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/market/matches/"), "/")
+	if len(parts) != 2 || parts[0] == "" || (parts[1] != "accept" && parts[1] != "decline") {
+		http.Error(w, `{"error": "Malformed URL, expecting /market/matches/{id}/accept or /decline"}`, http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, `{"error": "Method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	var req MarketPlayerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PlayerID == "" {
+		http.Error(w, `{"error": "Invalid payload: 'playerId' is required"}`, http.StatusBadRequest)
+		return
+	}
+	if err := qm.RespondMatch(req.PlayerID, parts[0], parts[1] == "accept"); err != nil {
+		writeMarketError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleCollectListingOutcomes: POST /market/outcomes lista para o Session os
+// resultados das ofertas do jogador. Eles continuam aqui até o POST /market/outcomes/ack.
+func handleCollectListingOutcomes(w http.ResponseWriter, r *http.Request, qm *QueueMaster) {
+	if r.Method != http.MethodPost {
+		http.Error(w, `{"error": "Method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	var req MarketPlayerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PlayerID == "" {
+		http.Error(w, `{"error": "Invalid payload: 'playerId' is required"}`, http.StatusBadRequest)
+		return
+	}
+	outcomes, err := qm.ListListingOutcomes(req.PlayerID)
+	if err != nil {
+		writeMarketError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(outcomes)
+}
+
+// ============================================================================
+// Handlers de Trocas Diretas
+// ============================================================================
+
+// handleProposeTrade: POST /trades/proposals registra uma proposta e avisa o destinatário.
+func handleProposeTrade(w http.ResponseWriter, r *http.Request, qm *QueueMaster) {
+	if r.Method != http.MethodPost {
+		http.Error(w, `{"error": "Method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	var req ProposeTradeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error": "Invalid payload for trade proposal"}`, http.StatusBadRequest)
+		return
+	}
+	proposal := &TradeProposal{
+		ID:      req.ProposalID,
+		FromID:  req.FromID,
+		ToID:    req.ToID,
+		Give:    req.Give,
+		Receive: req.Receive,
+	}
+	if err := qm.ProposeTrade(proposal); err != nil {
+		writeMarketError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(ProposeTradeResponse{ProposalID: proposal.ID, ExpiresAt: proposal.ExpiresAt})
+}
+
+// handleProposalAction: POST /trades/proposals/{id}/accept, /reject ou /cancel.
+func handleProposalAction(w http.ResponseWriter, r *http.Request, qm *QueueMaster) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/trades/proposals/"), "/")
+	if len(parts) != 2 || parts[0] == "" {
+		http.Error(w, `{"error": "Malformed URL, expecting /trades/proposals/{id}/accept, /reject or /cancel"}`, http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, `{"error": "Method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	var req MarketPlayerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PlayerID == "" {
+		http.Error(w, `{"error": "Invalid payload: 'playerId' is required"}`, http.StatusBadRequest)
+		return
+	}
+	var err error
+	switch parts[1] {
+	case "accept":
+		err = qm.AcceptTrade(req.PlayerID, parts[0])
+	case "reject":
+		err = qm.RejectTrade(req.PlayerID, parts[0])
+	case "cancel":
+		err = qm.CancelTrade(req.PlayerID, parts[0])
+	default:
+		http.Error(w, `{"error": "Malformed URL, expecting /trades/proposals/{id}/accept, /reject or /cancel"}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeMarketError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleCollectTradeOutcomes: POST /trades/outcomes lista para o Session os resultados
+// de trocas diretas do jogador. Eles continuam aqui até o POST /trades/outcomes/ack.
+func handleCollectTradeOutcomes(w http.ResponseWriter, r *http.Request, qm *QueueMaster) {
+	if r.Method != http.MethodPost {
+		http.Error(w, `{"error": "Method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	var req MarketPlayerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PlayerID == "" {
+		http.Error(w, `{"error": "Invalid payload: 'playerId' is required"}`, http.StatusBadRequest)
+		return
+	}
+	outcomes, err := qm.ListTradeOutcomes(req.PlayerID)
+	if err != nil {
+		writeMarketError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(outcomes)
+}
+
+// handleAckDelivery: POST .../ack apaga os registros que o Session já creditou e
+// gravou no inventário (ver delivery.go). IDs desconhecidos são ignorados, então
+// um ack repetido também responde 200.
+func handleAckDelivery(w http.ResponseWriter, r *http.Request, ack func(playerID string, ids []string) error) {
+	if r.Method != http.MethodPost {
+		http.Error(w, `{"error": "Method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	var req AckDeliveryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PlayerID == "" || len(req.IDs) == 0 {
+		http.Error(w, `{"error": "Invalid payload: 'playerId' and 'ids' are required"}`, http.StatusBadRequest)
+		return
+	}
+	if err := ack(req.PlayerID, req.IDs); err != nil {
+		writeMarketError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func writeMarketError(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, ErrQueueUnavailable):
+		status = http.StatusServiceUnavailable
+	case errors.Is(err, ErrListingNotFound), errors.Is(err, ErrMatchNotFound), errors.Is(err, ErrProposalNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrListingLimit), errors.Is(err, ErrListingSettling), errors.Is(err, ErrProposalLimit), errors.Is(err, ErrProposalSettling), errors.Is(err, ErrProposalClosed), errors.Is(err, ErrEscrowSettling):
+		status = http.StatusConflict
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
 //END OF FILE jokenpo/internal/services/queue/api.go
\ No newline at end of file
@@ -0,0 +1,195 @@
+//START OF FILE jokenpo/internal/services/queue/delivery.go
+package queue
+
+import (
+	"log"
+	"time"
+)
+
+// Entrega ao Session dos registros que devolvem cartas ao jogador: escrows do
+// Wonder Trade, resultados de ofertas do mercado e de trocas diretas. A entrega
+// tem duas etapas. O list devolve os registros prontos sem apagá-los; o Session
+// credita as cartas, grava o inventário e só então manda o ack com os IDs, que
+// apaga os registros aqui. Se o Session cair (ou a resposta se perder) entre as
+// duas etapas, o próximo list devolve os mesmos registros; o Session guarda no
+// inventário os IDs já creditados e não os credita de novo.
+
+// deliveryKind identifica qual coleção de registros um ack confirma.
+type deliveryKind int
+
+const (
+	deliveryEscrows deliveryKind = iota
+	deliveryListingOutcomes
+	deliveryTradeOutcomes
+)
+
+func (k deliveryKind) String() string {
+	switch k {
+	case deliveryEscrows:
+		return "trade escrows"
+	case deliveryListingOutcomes:
+		return "market outcomes"
+	default:
+		return "trade outcomes"
+	}
+}
+
+// AckDeliveryRequest é o corpo dos POST .../ack: os registros que o Session já
+// creditou e gravou.
+type AckDeliveryRequest struct {
+	PlayerID string   `json:"playerId"`
+	IDs      []string `json:"ids"`
+}
+
+type ackDeliveryRequest struct {
+	kind     deliveryKind
+	playerID string
+	ids      []string
+	reply    chan struct{}
+}
+
+func (ackDeliveryRequest) isActorMessage() {}
+
+// CustodyReport conta o que ainda prende cartas de um jogador no Queue: cartas em
+// custódia (ofertas, propostas, Wonder Trade) e registros ainda sem ack. Essas
+// cartas estão fora da coleção local, mas o ledger só as move na liquidação.
+type CustodyReport struct {
+	Listings    int `json:"listings"`    // Ofertas do mercado ainda abertas ou em liquidação
+	Proposals   int `json:"proposals"`   // Propostas com cartas do jogador em custódia
+	Escrows     int `json:"escrows"`     // Ofertas do Wonder Trade na fila ou em liquidação
+	Undelivered int `json:"undelivered"` // Escrows e resultados esperando o ack do Session
+}
+
+// Held diz se alguma carta do jogador ainda depende do Queue.
+func (c CustodyReport) Held() bool {
+	return c.Listings+c.Proposals+c.Escrows+c.Undelivered > 0
+}
+
+type custodyRequest struct {
+	playerID string
+	reply    chan CustodyReport
+}
+
+func (custodyRequest) isActorMessage() {}
+
+// listDelivery devolve cópias dos registros prontos (ready) sem tirá-los do mapa.
+func listDelivery[T any](records map[string]*T, ready func(*T) bool) []T {
+	listed := make([]T, 0)
+	for _, r := range records {
+		if ready(r) {
+			listed = append(listed, *r)
+		}
+	}
+	return listed
+}
+
+// ackDelivery apaga os registros ids que ainda estão prontos (ready). IDs de
+// outro jogador ou já apagados são ignorados. Retorna quantos foram apagados.
+func ackDelivery[T any](records map[string]*T, ids []string, ready func(*T) bool) int {
+	acked := 0
+	for _, id := range ids {
+		if r, ok := records[id]; ok && ready(r) {
+			delete(records, id)
+			acked++
+		}
+	}
+	return acked
+}
+
+// ackDelivered manda o ack ao ator e espera a confirmação.
+func (m *QueueMaster) ackDelivered(kind deliveryKind, playerID string, ids []string) error {
+	reply := make(chan struct{}, 1)
+	if err := m.send(ackDeliveryRequest{kind: kind, playerID: playerID, ids: ids, reply: reply}); err != nil {
+		return err
+	}
+	select {
+	case <-reply:
+		return nil
+	case <-time.After(2 * time.Second):
+		return ErrQueueUnavailable
+	}
+}
+
+// AckEscrows apaga os escrows terminados que o Session do jogador já creditou.
+func (m *QueueMaster) AckEscrows(playerID string, ids []string) error {
+	return m.ackDelivered(deliveryEscrows, playerID, ids)
+}
+
+// AckListingOutcomes apaga os resultados de ofertas que o Session do jogador já creditou.
+func (m *QueueMaster) AckListingOutcomes(playerID string, ids []string) error {
+	return m.ackDelivered(deliveryListingOutcomes, playerID, ids)
+}
+
+// AckTradeOutcomes apaga os resultados de trocas diretas que o Session do jogador já creditou.
+func (m *QueueMaster) AckTradeOutcomes(playerID string, ids []string) error {
+	return m.ackDelivered(deliveryTradeOutcomes, playerID, ids)
+}
+
+// Custody devolve o que ainda prende cartas do jogador no Queue.
+func (m *QueueMaster) Custody(playerID string) (CustodyReport, error) {
+	reply := make(chan CustodyReport, 1)
+	if err := m.send(custodyRequest{playerID: playerID, reply: reply}); err != nil {
+		return CustodyReport{}, err
+	}
+	select {
+	case report := <-reply:
+		return report, nil
+	case <-time.After(2 * time.Second):
+		return CustodyReport{}, ErrQueueUnavailable
+	}
+}
+
+// handleCustody roda dentro do ator.
+func (m *QueueMaster) handleCustody(req custodyRequest) {
+	var report CustodyReport
+	for _, l := range m.listings {
+		if l.PlayerID == req.playerID {
+			report.Listings++
+		}
+	}
+	for _, p := range m.proposals {
+		// O destinatário só entrega as cartas à custódia quando aceita.
+		if p.FromID == req.playerID || (p.ToID == req.playerID && p.State == ProposalSettling) {
+			report.Proposals++
+		}
+	}
+	for _, e := range m.escrows {
+		switch {
+		case e.PlayerID != req.playerID:
+		case e.finished():
+			report.Undelivered++
+		default:
+			report.Escrows++
+		}
+	}
+	for _, o := range m.listingOutcomes {
+		if o.PlayerID == req.playerID {
+			report.Undelivered++
+		}
+	}
+	for _, o := range m.outcomes {
+		if o.PlayerID == req.playerID {
+			report.Undelivered++
+		}
+	}
+	req.reply <- report
+}
+
+// handleAckDelivery roda dentro do ator.
+func (m *QueueMaster) handleAckDelivery(req ackDeliveryRequest) {
+	acked := 0
+	switch req.kind {
+	case deliveryEscrows:
+		acked = ackDelivery(m.escrows, req.ids, func(e *TradeEscrow) bool { return e.PlayerID == req.playerID && e.finished() })
+	case deliveryListingOutcomes:
+		acked = ackDelivery(m.listingOutcomes, req.ids, func(o *ListingOutcome) bool { return o.PlayerID == req.playerID })
+	case deliveryTradeOutcomes:
+		acked = ackDelivery(m.outcomes, req.ids, func(o *TradeOutcome) bool { return o.PlayerID == req.playerID })
+	}
+	if acked > 0 {
+		log.Printf("[QueueMaster] %s delivered %d %s.", req.playerID, acked, req.kind)
+	}
+	req.reply <- struct{}{}
+}
+
+//END OF FILE jokenpo/internal/services/queue/delivery.go
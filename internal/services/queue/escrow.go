@@ -0,0 +1,409 @@
+//START OF FILE jokenpo/internal/services/queue/escrow.go
+package queue
+
+import (
+	"context"
+	"errors"
+	"jokenpo/internal/services/blockchain"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Custódia do Wonder Trade: o Session tira a carta oferecida da coleção antes de
+// entrar na fila, e o líder guarda um TradeEscrow por oferta. O escrow só sai do
+// estado persistido quando o Session do jogador credita a carta (list + ack, ver
+// delivery.go): a recebida, se a troca foi liquidada, ou a oferecida, se foi devolvida.
+// Assim um jogador que caiu no meio da troca recebe a carta no próximo login.
+//
+//	pending --(pareado)--> matched --(ledger ok)--> settled
+//	   |                      '------(ledger recusou)--> refunded
+//	   '--(saiu da fila / prazo vencido)--> refunded
+
+// Estados de um escrow (TradeEscrow.State).
+const (
+	EscrowPending  = "pending"  // Oferta na fila, esperando um par
+	EscrowMatched  = "matched"  // Pareada; registrando a troca no ledger
+	EscrowSettled  = "settled"  // Troca feita: o jogador recebe ReceivedCard
+	EscrowRefunded = "refunded" // Troca desfeita: o jogador recebe OfferCard de volta
+)
+
+const (
+	TradeOfferTTLEnv = "QUEUE_TRADE_OFFER_TTL_SECONDS" // Tempo máximo de uma oferta na fila do Wonder Trade
+
+	defaultTradeOfferTTL = 300.0
+)
+
+var ErrEscrowSettling = errors.New("the trade was already matched and is being settled")
+
+// TradeEscrow é a custódia de uma oferta do Wonder Trade.
+type TradeEscrow struct {
+	ID           string    `json:"id"`
+	PlayerID     string    `json:"playerId"`
+	OfferCard    string    `json:"offerCard"`
+	State        string    `json:"state"`
+	MatchID      string    `json:"matchId,omitempty"` // O mesmo nos dois escrows de um par
+	PartnerID    string    `json:"partnerId,omitempty"`
+	ReceivedCard string    `json:"receivedCard,omitempty"`
+	Reason       string    `json:"reason,omitempty"` // Motivo da devolução
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+
+	// Token do ledger da carta oferecida. É persistido antes da transação: um
+	// novo líder confere se ela já entrou antes de repetir.
+	Token string `json:"token,omitempty"`
+}
+
+// finished indica que o escrow já tem resultado e só espera ser recolhido.
+func (e *TradeEscrow) finished() bool {
+	return e.State == EscrowSettled || e.State == EscrowRefunded
+}
+
+// TradeFoundPayload é o corpo do callback /trade-found: avisa que um escrow do
+// jogador terminou. As cartas só mudam de mão pelo list + ack (delivery.go).
+type TradeFoundPayload struct {
+	PlayerID     string `json:"playerId"`
+	EscrowID     string `json:"escrowId"`
+	State        string `json:"state"`
+	CardSent     string `json:"cardSent"`
+	CardReceived string `json:"cardReceived,omitempty"`
+	PartnerID    string `json:"partnerId,omitempty"`
+	Reason       string `json:"reason,omitempty"`
+}
+
+// --- Mensagens do ator ---
+
+// escrowPrepared guarda os tokens escolhidos antes da transação no ledger.
+type escrowPrepared struct {
+	matchID string
+	tokens  map[string]string // escrowID -> token
+	reply   chan error
+}
+
+func (escrowPrepared) isActorMessage() {}
+
+// escrowSwapped é o resultado da transação no ledger de um par.
+type escrowSwapped struct {
+	matchID string
+	err     error
+}
+
+func (escrowSwapped) isActorMessage() {}
+
+type listEscrowsRequest struct {
+	playerID string
+	reply    chan []TradeEscrow
+}
+
+func (listEscrowsRequest) isActorMessage() {}
+
+// --- API pública ---
+
+// ListEscrows devolve os escrows terminados do jogador. Eles só saem do estado
+// com o AckEscrows (delivery.go).
+func (m *QueueMaster) ListEscrows(playerID string) ([]TradeEscrow, error) {
+	reply := make(chan []TradeEscrow, 1)
+	if err := m.send(listEscrowsRequest{playerID: playerID, reply: reply}); err != nil {
+		return nil, err
+	}
+	select {
+	case escrows := <-reply:
+		return escrows, nil
+	case <-time.After(2 * time.Second):
+		return nil, ErrQueueUnavailable
+	}
+}
+
+// --- Lógica do ator (só roda dentro de Run) ---
+
+// openEscrow põe a oferta na fila com um escrow novo. Uma oferta anterior do
+// mesmo jogador ainda na fila é substituída e a carta dela é devolvida.
+func (m *QueueMaster) openEscrow(trade *TradeInfo) {
+	m.refundQueuedOffer(trade.ID, "replaced by a new offer")
+	now := time.Now()
+	escrow := &TradeEscrow{
+		ID:        uuid.NewString(),
+		PlayerID:  trade.ID,
+		OfferCard: trade.OfferCard,
+		State:     EscrowPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	m.escrows[escrow.ID] = escrow
+	trade.EscrowID = escrow.ID
+	trade.EnqueuedAt = now
+	m.tradeQueue = append(m.tradeQueue, trade)
+}
+
+// closeTradeOffer tira o jogador da fila do Wonder Trade devolvendo a carta.
+// Se a oferta já foi pareada, a troca segue e o pedido é recusado.
+func (m *QueueMaster) closeTradeOffer(playerID string) error {
+	if m.refundQueuedOffer(playerID, "left the trade queue") {
+		return nil
+	}
+	for _, e := range m.escrows {
+		if e.PlayerID == playerID && e.State == EscrowMatched {
+			return ErrEscrowSettling
+		}
+	}
+	return nil
+}
+
+// refundQueuedOffer devolve a oferta do jogador que ainda está na fila.
+func (m *QueueMaster) refundQueuedOffer(playerID, reason string) bool {
+	i := slices.IndexFunc(m.tradeQueue, func(t *TradeInfo) bool { return t.ID == playerID })
+	if i < 0 {
+		return false
+	}
+	trade := m.tradeQueue[i]
+	m.tradeQueue = slices.Delete(m.tradeQueue, i, i+1)
+	if e, ok := m.escrows[trade.EscrowID]; ok {
+		m.finishEscrow(e, EscrowRefunded, "", reason)
+	}
+	return true
+}
+
+// finishEscrow fecha o escrow e avisa o jogador. Se ele não estiver conectado, o
+// escrow fica guardado até o próximo login.
+func (m *QueueMaster) finishEscrow(e *TradeEscrow, state, received, reason string) {
+	e.State, e.ReceivedCard, e.Reason = state, received, reason
+	e.UpdatedAt = time.Now()
+	log.Printf("[WonderTrade] Escrow %s of %s (%s) is %s. %s", e.ID, e.PlayerID, e.OfferCard, state, reason)
+	payload := TradeFoundPayload{
+		PlayerID:     e.PlayerID,
+		EscrowID:     e.ID,
+		State:        e.State,
+		CardSent:     e.OfferCard,
+		CardReceived: e.ReceivedCard,
+		PartnerID:    e.PartnerID,
+		Reason:       e.Reason,
+	}
+	go m.sendTradeFound(payload)
+}
+
+// sendTradeFound acha o nó do jogador pela presença e grava o aviso no outbox.
+// Offline, o aviso não é enviado: o escrow espera o próximo login.
+func (m *QueueMaster) sendTradeFound(payload TradeFoundPayload) {
+	nodeURL, err := m.presence.Locate(payload.PlayerID)
+	if err != nil || nodeURL == "" {
+		log.Printf("[WonderTrade] %s of %s kept for the next login: player is offline (%v).", payload.EscrowID, payload.PlayerID, err)
+		return
+	}
+	m.sendCallback(payload.PlayerID, nodeURL+"/trade-found", "TRADE_FOUND", payload)
+}
+
+// tryPairingTrades pareia as duas ofertas mais antigas e começa a liquidação.
+func (m *QueueMaster) tryPairingTrades(ctx context.Context) bool {
+	if len(m.tradeQueue) < 2 {
+		return false
+	}
+	trade1 := m.tradeQueue[0]
+	trade2 := m.tradeQueue[1]
+	m.tradeQueue = m.tradeQueue[2:]
+	log.Printf("[QueueMaster] TRADE MATCH: %s <-> %s", trade1.ID, trade2.ID)
+
+	matchID := uuid.NewString()
+	a, b := m.escrows[trade1.EscrowID], m.escrows[trade2.EscrowID]
+	now := time.Now()
+	for _, pair := range [][2]*TradeEscrow{{a, b}, {b, a}} {
+		pair[0].State = EscrowMatched
+		pair[0].MatchID = matchID
+		pair[0].PartnerID = pair[1].PlayerID
+		pair[0].UpdatedAt = now
+	}
+	m.settleWonderTrade(ctx, *a, *b)
+	return true
+}
+
+// expireTradeOffers devolve as ofertas que passaram do prazo sem par.
+func (m *QueueMaster) expireTradeOffers(now time.Time) bool {
+	changed := false
+	for _, trade := range slices.Clone(m.tradeQueue) {
+		if now.Sub(trade.EnqueuedAt) > m.tradeOfferTTL {
+			m.refundQueuedOffer(trade.ID, "no trade partner was found in time")
+			changed = true
+		}
+	}
+	return changed
+}
+
+func (m *QueueMaster) handleEscrowMessage(msg actorMessage) {
+	switch req := msg.(type) {
+	case escrowPrepared:
+		pair := m.escrowPair(req.matchID)
+		if len(pair) != 2 {
+			req.reply <- ErrEscrowSettling
+			return
+		}
+		for _, e := range pair {
+			e.Token = req.tokens[e.ID]
+		}
+		req.reply <- nil
+
+	case escrowSwapped:
+		pair := m.escrowPair(req.matchID)
+		if len(pair) != 2 {
+			return
+		}
+		if req.err != nil {
+			log.Printf("[WonderTrade] Match %s FAILED on the ledger: %v. Returning offered cards.", req.matchID, req.err)
+			for _, e := range pair {
+				m.finishEscrow(e, EscrowRefunded, "", "the trade could not be recorded on the ledger")
+			}
+			return
+		}
+		m.finishEscrow(pair[0], EscrowSettled, pair[1].OfferCard, "")
+		m.finishEscrow(pair[1], EscrowSettled, pair[0].OfferCard, "")
+
+	case listEscrowsRequest:
+		req.reply <- listDelivery(m.escrows, func(e *TradeEscrow) bool { return e.PlayerID == req.playerID && e.finished() })
+	}
+}
+
+// escrowPair devolve os dois escrows ainda em liquidação do par matchID.
+func (m *QueueMaster) escrowPair(matchID string) []*TradeEscrow {
+	pair := make([]*TradeEscrow, 0, 2)
+	for _, e := range m.escrows {
+		if e.MatchID == matchID && e.State == EscrowMatched {
+			pair = append(pair, e)
+		}
+	}
+	slices.SortFunc(pair, func(x, y *TradeEscrow) int { return x.CreatedAt.Compare(y.CreatedAt) })
+	return pair
+}
+
+// resumeWonderTrades retoma os pares que o líder anterior deixou em liquidação.
+func (m *QueueMaster) resumeWonderTrades(ctx context.Context) {
+	resumed := make(map[string]bool)
+	for _, e := range m.escrows {
+		if e.State != EscrowMatched || resumed[e.MatchID] {
+			continue
+		}
+		resumed[e.MatchID] = true
+		pair := m.escrowPair(e.MatchID)
+		if len(pair) != 2 {
+			continue
+		}
+		log.Printf("[WonderTrade] Resuming settlement of match %s.", e.MatchID)
+		m.settleWonderTrade(ctx, *pair[0], *pair[1])
+	}
+}
+
+// settleWonderTrade registra a troca no ledger fora do ator e devolve o
+// resultado como escrowSwapped, como settleProposal.
+func (m *QueueMaster) settleWonderTrade(ctx context.Context, a, b TradeEscrow) {
+	go func() {
+		err := m.swapEscrowsOnLedger(ctx, &a, &b)
+		if ctx.Err() != nil {
+			return
+		}
+		select {
+		case m.requestCh <- escrowSwapped{matchID: a.MatchID, err: err}:
+		case <-ctx.Done():
+		}
+	}()
+}
+
+func (m *QueueMaster) swapEscrowsOnLedger(ctx context.Context, a, b *TradeEscrow) error {
+	if m.blockchain == nil {
+		log.Printf("[WonderTrade] WARN: Ledger unavailable; match %s settled without an audit record.", a.MatchID)
+		return nil
+	}
+
+	if a.Token == "" || b.Token == "" {
+		tokenA, err := m.blockchain.FindTokenForCard(a.PlayerID, a.OfferCard)
+		if err != nil {
+			return err
+		}
+		tokenB, err := m.blockchain.FindTokenForCard(b.PlayerID, b.OfferCard)
+		if err != nil {
+			return err
+		}
+		reply := make(chan error, 1)
+		select {
+		case m.requestCh <- escrowPrepared{matchID: a.MatchID, tokens: map[string]string{a.ID: tokenA, b.ID: tokenB}, reply: reply}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if err := awaitReply(reply); err != nil {
+			return err
+		}
+		a.Token, b.Token = tokenA, tokenB
+	} else if swapped, err := m.tokensSwapped(a.PlayerID, a.Token, b.PlayerID, b.Token); err == nil && swapped {
+		log.Printf("[WonderTrade] Match %s was already recorded on the ledger by the previous leader.", a.MatchID)
+		return nil
+	}
+
+	log.Printf("[WonderTrade] LEDGER: %s [%s] <-> %s [%s]", a.PlayerID, a.Token, b.PlayerID, b.Token)
+	return m.recordSwap(ctx, "Wonder Trade match "+a.MatchID,
+		func() error { return m.blockchain.LogSwap(a.PlayerID, b.PlayerID, a.Token, b.Token) },
+		func() (bool, error) { return m.tokensSwapped(a.PlayerID, a.Token, b.PlayerID, b.Token) })
+}
+
+// tokensSwapped confere se tokenA já é de playerB e tokenB de playerA. Um erro
+// quer dizer que o ledger não pôde ser lido.
+func (m *QueueMaster) tokensSwapped(playerA, tokenA, playerB, tokenB string) (bool, error) {
+	assetsA, err := m.blockchain.PlayerAssets(playerA)
+	if err != nil {
+		return false, err
+	}
+	assetsB, err := m.blockchain.PlayerAssets(playerB)
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(assetsA, tokenB) && slices.Contains(assetsB, tokenA), nil
+}
+
+// Espera entre as conferências de uma troca cujo resultado no ledger é incerto.
+const (
+	ledgerRecheckMin = 2 * time.Second
+	ledgerRecheckMax = 30 * time.Second
+)
+
+// recordSwap envia a troca (send) e só devolve erro quando ela com certeza não
+// entrou no ledger (blockchain.ErrNotRecorded): é esse erro que faz o chamador
+// devolver as cartas. Um erro ambíguo (RPC caiu depois do envio, recibo não veio)
+// não desfaz nada: o par continua em liquidação e applied confere o ledger até
+// haver resposta, reenviando a troca se ela não entrou. Um revert também passa
+// pelo applied, porque pode ser o reenvio de uma troca que já tinha entrado.
+// Se a liderança cair, devolve ctx.Err() e o próximo líder retoma.
+func (m *QueueMaster) recordSwap(ctx context.Context, label string, send func() error, applied func() (bool, error)) error {
+	wait := ledgerRecheckMin
+	for {
+		err := send()
+		if err == nil {
+			return nil
+		}
+		for {
+			swapped, checkErr := applied()
+			if checkErr == nil && swapped {
+				log.Printf("[Ledger] %s is on the ledger despite the error (%v).", label, err)
+				return nil
+			}
+			if checkErr == nil && errors.Is(err, blockchain.ErrNotRecorded) {
+				return err
+			}
+			if checkErr == nil {
+				break // Não entrou (ainda): reenvia.
+			}
+			log.Printf("[Ledger] WARN: %s: could not read the ledger (%v). Checking again in %v.", label, checkErr, wait)
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			wait = min(wait*2, ledgerRecheckMax)
+		}
+		log.Printf("[Ledger] WARN: %s: outcome unknown (%v). Sending it again in %v.", label, err, wait)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		wait = min(wait*2, ledgerRecheckMax)
+	}
+}
+
+//END OF FILE jokenpo/internal/services/queue/escrow.go
@@ -0,0 +1,577 @@
+//START OF FILE jokenpo/internal/services/queue/market.go
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// O mercado de trocas substitui a troca às cegas: cada oferta diz qual carta o
+// jogador dá e quais aceita receber. O líder casa ofertas compatíveis, mas a troca
+// só acontece depois que os dois lados aceitam explicitamente o par encontrado.
+// Com os dois aceites a troca é registrada no ledger (LogSwap) e só então as
+// ofertas saem do mercado; se o ledger recusar, as duas cartas voltam aos donos.
+//
+// Todo desfecho que devolve ou entrega uma carta (troca feita, falha, prazo,
+// cancelamento) vira um ListingOutcome do dono, persistido até o Session dele
+// creditar (POST /market/outcomes e o ack), como os escrows do Wonder Trade: quem estava
+// offline recebe a carta no próximo login.
+
+// Estados de uma oferta (TradeListing.State).
+const (
+	ListingOpen    = "open"    // Esperando uma oferta compatível
+	ListingMatched = "matched" // Casada com outra; aguardando o aceite dos dois lados
+)
+
+// Eventos enviados ao Session (POST no CallbackURL da oferta).
+const (
+	MarketEventMatched   = "LISTING_MATCHED"   // Par encontrado, aceite pendente
+	MarketEventReopened  = "LISTING_REOPENED"  // O par foi desfeito; a oferta voltou a ficar aberta
+	MarketEventSettled   = "LISTING_SETTLED"   // Os dois aceitaram e o ledger registrou a troca
+	MarketEventExpired   = "LISTING_EXPIRED"   // A oferta venceu sem troca; a carta volta ao dono
+	MarketEventCancelled = "LISTING_CANCELLED" // O dono retirou a oferta; a carta volta
+	MarketEventFailed    = "LISTING_FAILED"    // O ledger recusou a troca; a carta volta ao dono
+)
+
+const (
+	ListingTTLEnv    = "QUEUE_LISTING_TTL_SECONDS"    // Validade de uma oferta aberta
+	AcceptTimeoutEnv = "QUEUE_LISTING_ACCEPT_SECONDS" // Prazo para os dois lados aceitarem um par
+
+	defaultListingTTL    = 600.0
+	defaultAcceptTimeout = 60.0
+	maxListingsPerPlayer = 5
+)
+
+var (
+	ErrListingNotFound = errors.New("listing not found")
+	ErrMatchNotFound   = errors.New("trade match not found")
+	ErrListingLimit    = fmt.Errorf("a player can have at most %d listings", maxListingsPerPlayer)
+	ErrListingSettling = errors.New("the trade was accepted by both players and is being settled")
+)
+
+// TradeListing é uma oferta "dou Offer, aceito qualquer uma de Want". Want vazio aceita qualquer carta.
+type TradeListing struct {
+	ID          string    `json:"id"`
+	PlayerID    string    `json:"playerId"`
+	CallbackURL string    `json:"callbackUrl"`
+	Offer       string    `json:"offer"`
+	Want        []string  `json:"want,omitempty"`
+	State       string    `json:"state"`
+	MatchID     string    `json:"matchId,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+	Skip        []string  `json:"skip,omitempty"` // Ofertas com que o par já foi recusado ou expirou
+}
+
+// accepts indica se a oferta aceita receber a carta 'key'.
+func (l *TradeListing) accepts(key string) bool {
+	return len(l.Want) == 0 || slices.Contains(l.Want, key)
+}
+
+// ListingMatch é um par de ofertas compatíveis aguardando o aceite dos donos.
+type ListingMatch struct {
+	ID        string          `json:"id"`
+	Listings  [2]string       `json:"listings"`
+	Accepted  map[string]bool `json:"accepted"` // playerId -> aceitou
+	ExpiresAt time.Time       `json:"expiresAt"`
+
+	// Os dois aceitaram e a troca está sendo registrada no ledger. Os tokens
+	// (listingID -> token) são persistidos antes da transação, como no Wonder Trade.
+	Settling bool              `json:"settling,omitempty"`
+	Tokens   map[string]string `json:"tokens,omitempty"`
+}
+
+// ListingOutcome é o resultado de uma oferta para o dono. Credit é a carta que o
+// Session credita ao recolher: a recebida na troca ou a oferecida de volta.
+type ListingOutcome struct {
+	ID        string    `json:"id"`
+	PlayerID  string    `json:"playerId"`
+	ListingID string    `json:"listingId"`
+	EventType string    `json:"eventType"`
+	Give      string    `json:"give"`
+	Receive   string    `json:"receive,omitempty"`
+	PartnerID string    `json:"partnerId,omitempty"`
+	Credit    string    `json:"credit"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// MarketEvent é o corpo dos callbacks do mercado.
+type MarketEvent struct {
+	EventType string `json:"eventType"`
+	PlayerID  string `json:"playerId"`
+	ListingID string `json:"listingId"`
+	MatchID   string `json:"matchId,omitempty"`
+	Give      string `json:"give"`
+	Receive   string `json:"receive,omitempty"`
+	PartnerID string `json:"partnerId,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	OutcomeID string `json:"outcomeId,omitempty"` // Resultado a recolher em POST /market/outcomes
+}
+
+func envSeconds(name string, fallback float64) time.Duration {
+	return time.Duration(envFloat(name, fallback) * float64(time.Second))
+}
+
+// --- Mensagens do ator ---
+
+type createListingRequest struct {
+	listing *TradeListing
+	reply   chan error
+}
+
+func (createListingRequest) isActorMessage() {}
+
+type cancelListingRequest struct {
+	playerID, listingID string
+	reply               chan error
+}
+
+func (cancelListingRequest) isActorMessage() {}
+
+type respondMatchRequest struct {
+	playerID, matchID string
+	accept            bool
+	reply             chan error
+}
+
+func (respondMatchRequest) isActorMessage() {}
+
+type browseListingsRequest struct {
+	reply chan []TradeListing
+}
+
+func (browseListingsRequest) isActorMessage() {}
+
+// listingPrepared guarda os tokens escolhidos antes da transação no ledger.
+type listingPrepared struct {
+	matchID string
+	tokens  map[string]string // listingID -> token
+	reply   chan error
+}
+
+func (listingPrepared) isActorMessage() {}
+
+// listingSwapped é o resultado da transação no ledger de um par aceito.
+type listingSwapped struct {
+	matchID string
+	err     error
+}
+
+func (listingSwapped) isActorMessage() {}
+
+type listListingOutcomesRequest struct {
+	playerID string
+	reply    chan []ListingOutcome
+}
+
+func (listListingOutcomesRequest) isActorMessage() {}
+
+// --- API pública ---
+
+// CreateListing publica uma oferta. O ID, o estado e a validade são definidos aqui.
+func (m *QueueMaster) CreateListing(listing *TradeListing) error {
+	now := time.Now()
+	listing.ID = uuid.NewString()
+	listing.State = ListingOpen
+	listing.MatchID = ""
+	listing.Skip = nil
+	listing.CreatedAt = now
+	listing.ExpiresAt = now.Add(m.listingTTL)
+	reply := make(chan error, 1)
+	if err := m.send(createListingRequest{listing: listing, reply: reply}); err != nil {
+		return err
+	}
+	return awaitReply(reply)
+}
+
+// CancelListing retira uma oferta do dono. Se ela estava casada, o par é desfeito.
+// A carta volta num ListingOutcome. Retorna ErrListingSettling se os dois já aceitaram.
+func (m *QueueMaster) CancelListing(playerID, listingID string) error {
+	reply := make(chan error, 1)
+	if err := m.send(cancelListingRequest{playerID: playerID, listingID: listingID, reply: reply}); err != nil {
+		return err
+	}
+	return awaitReply(reply)
+}
+
+// RespondMatch registra o aceite (ou a recusa) de um jogador para um par encontrado.
+func (m *QueueMaster) RespondMatch(playerID, matchID string, accept bool) error {
+	reply := make(chan error, 1)
+	if err := m.send(respondMatchRequest{playerID: playerID, matchID: matchID, accept: accept, reply: reply}); err != nil {
+		return err
+	}
+	return awaitReply(reply)
+}
+
+// BrowseListings devolve uma cópia de todas as ofertas, abertas e casadas.
+func (m *QueueMaster) BrowseListings() ([]TradeListing, error) {
+	reply := make(chan []TradeListing, 1)
+	if err := m.send(browseListingsRequest{reply: reply}); err != nil {
+		return nil, err
+	}
+	select {
+	case listings := <-reply:
+		return listings, nil
+	case <-time.After(2 * time.Second):
+		return nil, ErrQueueUnavailable
+	}
+}
+
+// ListListingOutcomes devolve os resultados das ofertas do jogador. Eles só saem
+// do estado com o AckListingOutcomes (delivery.go).
+func (m *QueueMaster) ListListingOutcomes(playerID string) ([]ListingOutcome, error) {
+	reply := make(chan []ListingOutcome, 1)
+	if err := m.send(listListingOutcomesRequest{playerID: playerID, reply: reply}); err != nil {
+		return nil, err
+	}
+	select {
+	case outcomes := <-reply:
+		return outcomes, nil
+	case <-time.After(2 * time.Second):
+		return nil, ErrQueueUnavailable
+	}
+}
+
+// sendMarketEvent entrega o evento pelo outbox, na ordem dos eventos do jogador.
+func (m *QueueMaster) sendMarketEvent(url string, event MarketEvent) {
+	m.sendCallback(event.PlayerID, url, event.EventType, event)
+}
+
+// awaitReply espera a resposta do ator. O canal tem buffer, então o ator nunca trava ao responder.
+func awaitReply(reply chan error) error {
+	select {
+	case err := <-reply:
+		return err
+	case <-time.After(2 * time.Second):
+		return ErrQueueUnavailable
+	}
+}
+
+// --- Lógica do ator (só roda dentro de Run) ---
+
+func (m *QueueMaster) handleMarketMessage(ctx context.Context, msg actorMessage) {
+	switch req := msg.(type) {
+	case createListingRequest:
+		owned := 0
+		for _, l := range m.listings {
+			if l.PlayerID == req.listing.PlayerID {
+				owned++
+			}
+		}
+		if owned >= maxListingsPerPlayer {
+			req.reply <- ErrListingLimit
+			return
+		}
+		m.listings[req.listing.ID] = req.listing
+		log.Printf("[Market] +Listing %s: %s offers %s for %v", req.listing.ID, req.listing.PlayerID, req.listing.Offer, req.listing.Want)
+		req.reply <- nil
+
+	case cancelListingRequest:
+		listing, ok := m.listings[req.listingID]
+		if !ok || listing.PlayerID != req.playerID {
+			req.reply <- ErrListingNotFound
+			return
+		}
+		if match, ok := m.listingMatches[listing.MatchID]; ok && match.Settling {
+			req.reply <- ErrListingSettling
+			return
+		}
+		// Removida antes de desfazer o par: só a oferta do outro jogador é reaberta.
+		delete(m.listings, listing.ID)
+		if listing.State == ListingMatched {
+			m.dissolveMatch(listing.MatchID, "the other player cancelled the listing")
+		}
+		log.Printf("[Market] -Listing %s cancelled by %s", listing.ID, listing.PlayerID)
+		m.recordListingOutcome(listing, MarketEventCancelled, listing.Offer, nil, "the listing was cancelled")
+		req.reply <- nil
+
+	case respondMatchRequest:
+		match, ok := m.listingMatches[req.matchID]
+		if !ok {
+			req.reply <- ErrMatchNotFound
+			return
+		}
+		if _, isParty := match.Accepted[req.playerID]; !isParty {
+			req.reply <- ErrMatchNotFound
+			return
+		}
+		if match.Settling {
+			if req.accept {
+				req.reply <- nil // Aceite repetido: a troca já está sendo liquidada.
+			} else {
+				req.reply <- ErrListingSettling
+			}
+			return
+		}
+		req.reply <- nil
+		if !req.accept {
+			m.dissolveMatch(match.ID, "the trade was declined")
+			return
+		}
+		match.Accepted[req.playerID] = true
+		for _, accepted := range match.Accepted {
+			if !accepted {
+				return
+			}
+		}
+		m.settleMatch(ctx, match)
+
+	case browseListingsRequest:
+		listings := make([]TradeListing, 0, len(m.listings))
+		for _, l := range m.listings {
+			listings = append(listings, *l)
+		}
+		sort.Slice(listings, func(i, j int) bool { return listings[i].CreatedAt.Before(listings[j].CreatedAt) })
+		req.reply <- listings
+
+	case listingPrepared:
+		match, ok := m.listingMatches[req.matchID]
+		if !ok || !match.Settling {
+			req.reply <- ErrMatchNotFound
+			return
+		}
+		match.Tokens = req.tokens
+		req.reply <- nil
+
+	case listingSwapped:
+		match, ok := m.listingMatches[req.matchID]
+		if !ok || !match.Settling {
+			return
+		}
+		m.finishMatch(match, req.err)
+
+	case listListingOutcomesRequest:
+		collected := listDelivery(m.listingOutcomes, func(o *ListingOutcome) bool { return o.PlayerID == req.playerID })
+		sort.Slice(collected, func(i, j int) bool { return collected[i].CreatedAt.Before(collected[j].CreatedAt) })
+		req.reply <- collected
+	}
+}
+
+// tryMarket vence ofertas e pares expirados e casa ofertas abertas compatíveis.
+// Roda no ticker do ator. Retorna true se o mercado mudou.
+func (m *QueueMaster) tryMarket(now time.Time) bool {
+	changed := false
+
+	for _, match := range m.listingMatches {
+		if !match.Settling && now.After(match.ExpiresAt) {
+			m.dissolveMatch(match.ID, "not every player accepted in time")
+			changed = true
+		}
+	}
+	for _, l := range m.listings {
+		if l.State == ListingOpen && now.After(l.ExpiresAt) {
+			delete(m.listings, l.ID)
+			log.Printf("[Market] Listing %s of %s expired.", l.ID, l.PlayerID)
+			m.recordListingOutcome(l, MarketEventExpired, l.Offer, nil, "the listing expired without a trade")
+			changed = true
+		}
+	}
+
+	// Ordem de chegada: quem publicou antes tem prioridade.
+	open := make([]*TradeListing, 0, len(m.listings))
+	for _, l := range m.listings {
+		if l.State == ListingOpen {
+			open = append(open, l)
+		}
+	}
+	sort.Slice(open, func(i, j int) bool { return open[i].CreatedAt.Before(open[j].CreatedAt) })
+
+	for i, a := range open {
+		if a.State != ListingOpen {
+			continue
+		}
+		for _, b := range open[i+1:] {
+			if b.State != ListingOpen || a.PlayerID == b.PlayerID {
+				continue
+			}
+			if slices.Contains(a.Skip, b.ID) || slices.Contains(b.Skip, a.ID) {
+				continue
+			}
+			if a.accepts(b.Offer) && b.accepts(a.Offer) {
+				m.proposeMatch(a, b, now)
+				changed = true
+				break
+			}
+		}
+	}
+	return changed
+}
+
+func (m *QueueMaster) proposeMatch(a, b *TradeListing, now time.Time) {
+	match := &ListingMatch{
+		ID:        uuid.NewString(),
+		Listings:  [2]string{a.ID, b.ID},
+		Accepted:  map[string]bool{a.PlayerID: false, b.PlayerID: false},
+		ExpiresAt: now.Add(m.acceptTimeout),
+	}
+	m.listingMatches[match.ID] = match
+	a.State, a.MatchID = ListingMatched, match.ID
+	b.State, b.MatchID = ListingMatched, match.ID
+	log.Printf("[Market] MATCH %s: %s (%s) <-> %s (%s). Waiting for both to accept.", match.ID, a.PlayerID, a.Offer, b.PlayerID, b.Offer)
+
+	m.sendMarketEvent(a.CallbackURL, MarketEvent{EventType: MarketEventMatched, PlayerID: a.PlayerID, ListingID: a.ID, MatchID: match.ID, Give: a.Offer, Receive: b.Offer, PartnerID: b.PlayerID})
+	m.sendMarketEvent(b.CallbackURL, MarketEvent{EventType: MarketEventMatched, PlayerID: b.PlayerID, ListingID: b.ID, MatchID: match.ID, Give: b.Offer, Receive: a.Offer, PartnerID: a.PlayerID})
+}
+
+// dissolveMatch desfaz um par: as ofertas que ainda existem voltam a ficar abertas
+// e não são mais casadas uma com a outra.
+func (m *QueueMaster) dissolveMatch(matchID, reason string) {
+	match, ok := m.listingMatches[matchID]
+	if !ok {
+		return
+	}
+	delete(m.listingMatches, matchID)
+	log.Printf("[Market] Match %s dissolved: %s", matchID, reason)
+	for i, id := range match.Listings {
+		l, ok := m.listings[id]
+		if !ok {
+			continue
+		}
+		l.State, l.MatchID = ListingOpen, ""
+		l.Skip = append(l.Skip, match.Listings[1-i])
+		m.sendMarketEvent(l.CallbackURL, MarketEvent{EventType: MarketEventReopened, PlayerID: l.PlayerID, ListingID: l.ID, MatchID: matchID, Give: l.Offer, Reason: reason})
+	}
+}
+
+// settleMatch começa a liquidação do par aceito pelos dois lados. As ofertas só
+// saem do mercado quando o ledger responder (listingSwapped).
+func (m *QueueMaster) settleMatch(ctx context.Context, match *ListingMatch) {
+	match.Settling = true
+	log.Printf("[Market] Match %s accepted by both players. Settling on the ledger...", match.ID)
+	m.settleListingSwap(ctx, *match, *m.listings[match.Listings[0]], *m.listings[match.Listings[1]])
+}
+
+// finishMatch fecha o par com o resultado do ledger: troca feita, ou as duas
+// cartas de volta aos donos.
+func (m *QueueMaster) finishMatch(match *ListingMatch, err error) {
+	a, b := m.listings[match.Listings[0]], m.listings[match.Listings[1]]
+	delete(m.listingMatches, match.ID)
+	delete(m.listings, a.ID)
+	delete(m.listings, b.ID)
+	if err != nil {
+		log.Printf("[Market] Match %s FAILED on the ledger: %v. Returning listed cards.", match.ID, err)
+		reason := "the trade could not be recorded on the ledger"
+		m.recordListingOutcome(a, MarketEventFailed, a.Offer, b, reason)
+		m.recordListingOutcome(b, MarketEventFailed, b.Offer, a, reason)
+		return
+	}
+	log.Printf("[Market] SETTLED %s: %s gets %s, %s gets %s", match.ID, a.PlayerID, b.Offer, b.PlayerID, a.Offer)
+	m.recordListingOutcome(a, MarketEventSettled, b.Offer, b, "")
+	m.recordListingOutcome(b, MarketEventSettled, a.Offer, a, "")
+}
+
+// recordListingOutcome guarda o resultado da oferta e avisa o Session do dono.
+// partner é a oferta do outro lado, se houve par.
+func (m *QueueMaster) recordListingOutcome(l *TradeListing, eventType, credit string, partner *TradeListing, reason string) {
+	o := &ListingOutcome{
+		ID:        uuid.NewString(),
+		PlayerID:  l.PlayerID,
+		ListingID: l.ID,
+		EventType: eventType,
+		Give:      l.Offer,
+		Credit:    credit,
+		Reason:    reason,
+		CreatedAt: time.Now(),
+	}
+	if partner != nil {
+		o.Receive, o.PartnerID = partner.Offer, partner.PlayerID
+	}
+	m.listingOutcomes[o.ID] = o
+	go m.notifyListingOutcome(*o, l.CallbackURL)
+}
+
+// notifyListingOutcome avisa o nó onde o dono está agora (pela presença) ou, sem
+// presença, o nó de onde ele publicou. Se o aviso não for aceito, o resultado
+// espera o próximo login.
+func (m *QueueMaster) notifyListingOutcome(o ListingOutcome, callbackURL string) {
+	url := callbackURL
+	if nodeURL, err := m.presence.Locate(o.PlayerID); err == nil && nodeURL != "" {
+		url = nodeURL + "/market-event"
+	}
+	m.sendMarketEvent(url, MarketEvent{
+		EventType: o.EventType,
+		PlayerID:  o.PlayerID,
+		ListingID: o.ListingID,
+		Give:      o.Give,
+		Receive:   o.Receive,
+		PartnerID: o.PartnerID,
+		Reason:    o.Reason,
+		OutcomeID: o.ID,
+	})
+}
+
+// resumeMarketSettlements retoma os pares que o líder anterior deixou em liquidação.
+func (m *QueueMaster) resumeMarketSettlements(ctx context.Context) {
+	for _, match := range m.listingMatches {
+		if !match.Settling {
+			continue
+		}
+		a, okA := m.listings[match.Listings[0]]
+		b, okB := m.listings[match.Listings[1]]
+		if !okA || !okB {
+			continue
+		}
+		log.Printf("[Market] Resuming settlement of match %s.", match.ID)
+		m.settleListingSwap(ctx, *match, *a, *b)
+	}
+}
+
+// settleListingSwap registra a troca no ledger fora do ator e devolve o
+// resultado como listingSwapped, como settleWonderTrade.
+func (m *QueueMaster) settleListingSwap(ctx context.Context, match ListingMatch, a, b TradeListing) {
+	go func() {
+		err := m.swapListingsOnLedger(ctx, &match, &a, &b)
+		if ctx.Err() != nil {
+			return
+		}
+		select {
+		case m.requestCh <- listingSwapped{matchID: match.ID, err: err}:
+		case <-ctx.Done():
+		}
+	}()
+}
+
+func (m *QueueMaster) swapListingsOnLedger(ctx context.Context, match *ListingMatch, a, b *TradeListing) error {
+	if m.blockchain == nil {
+		log.Printf("[Market] WARN: Ledger unavailable; match %s settled without an audit record.", match.ID)
+		return nil
+	}
+
+	tokenA, tokenB := match.Tokens[a.ID], match.Tokens[b.ID]
+	if tokenA == "" || tokenB == "" {
+		var err error
+		if tokenA, err = m.blockchain.FindTokenForCard(a.PlayerID, a.Offer); err != nil {
+			return err
+		}
+		if tokenB, err = m.blockchain.FindTokenForCard(b.PlayerID, b.Offer); err != nil {
+			return err
+		}
+		reply := make(chan error, 1)
+		select {
+		case m.requestCh <- listingPrepared{matchID: match.ID, tokens: map[string]string{a.ID: tokenA, b.ID: tokenB}, reply: reply}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if err := awaitReply(reply); err != nil {
+			return err
+		}
+	} else if swapped, err := m.tokensSwapped(a.PlayerID, tokenA, b.PlayerID, tokenB); err == nil && swapped {
+		log.Printf("[Market] Match %s was already recorded on the ledger by the previous leader.", match.ID)
+		return nil
+	}
+
+	log.Printf("[Market] LEDGER: %s [%s] <-> %s [%s]", a.PlayerID, tokenA, b.PlayerID, tokenB)
+	return m.recordSwap(ctx, "market match "+match.ID,
+		func() error { return m.blockchain.LogSwap(a.PlayerID, b.PlayerID, tokenA, tokenB) },
+		func() (bool, error) { return m.tokensSwapped(a.PlayerID, tokenA, b.PlayerID, tokenB) })
+}
+
+//END OF FILE jokenpo/internal/services/queue/market.go
@@ -0,0 +1,133 @@
+//START OF FILE jokenpo/internal/services/queue/matchmaking.go
+package queue
+
+import (
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Janela de rating do pareamento. Começa estreita e alarga enquanto o jogador espera,
+// até o teto, para que ninguém fique preso na fila por falta de oponente parecido.
+const (
+	RatingWindowEnv       = "QUEUE_RATING_WINDOW"        // Diferença máxima ao entrar na fila
+	RatingWindowGrowthEnv = "QUEUE_RATING_WINDOW_GROWTH" // Pontos acrescentados por segundo de espera
+	RatingWindowMaxEnv    = "QUEUE_RATING_WINDOW_MAX"    // Teto da janela
+
+	defaultRatingWindow       = 100.0
+	defaultRatingWindowGrowth = 10.0
+	defaultRatingWindowMax    = 800.0
+)
+
+// Faixas de poder do deck (soma dos valores das cartas, 0 a 80). Os jogadores só
+// se enfrentam se as faixas estiverem a no máximo N faixas de distância; N começa em
+// QUEUE_POWER_DISPARITY, sobe uma faixa a cada QUEUE_POWER_RELAX_SECONDS de espera
+// e nunca passa de QUEUE_POWER_DISPARITY_MAX. Assim um deck inicial não cai contra
+// um deck de 80 de poder, por mais que a fila demore.
+const (
+	PowerBracketSizeEnv   = "QUEUE_POWER_BRACKET_SIZE"
+	PowerDisparityEnv     = "QUEUE_POWER_DISPARITY"
+	PowerDisparityMaxEnv  = "QUEUE_POWER_DISPARITY_MAX"
+	PowerRelaxIntervalEnv = "QUEUE_POWER_RELAX_SECONDS"
+
+	defaultPowerBracketSize   = 20.0
+	defaultPowerDisparity     = 0.0
+	defaultPowerDisparityMax  = 2.0
+	defaultPowerRelaxInterval = 30.0
+)
+
+type MatchmakingConfig struct {
+	RatingWindow       float64
+	RatingWindowGrowth float64
+	RatingWindowMax    float64
+
+	PowerBracketSize   int
+	PowerDisparity     int
+	PowerDisparityMax  int
+	PowerRelaxInterval time.Duration
+}
+
+// LoadMatchmakingConfig lê a configuração do pareamento do ambiente; valores ausentes ou inválidos usam o padrão.
+func LoadMatchmakingConfig() MatchmakingConfig {
+	c := MatchmakingConfig{
+		RatingWindow:       envFloat(RatingWindowEnv, defaultRatingWindow),
+		RatingWindowGrowth: envFloat(RatingWindowGrowthEnv, defaultRatingWindowGrowth),
+		RatingWindowMax:    envFloat(RatingWindowMaxEnv, defaultRatingWindowMax),
+		PowerBracketSize:   int(envFloat(PowerBracketSizeEnv, defaultPowerBracketSize)),
+		PowerDisparity:     int(envFloat(PowerDisparityEnv, defaultPowerDisparity)),
+		PowerDisparityMax:  int(envFloat(PowerDisparityMaxEnv, defaultPowerDisparityMax)),
+		PowerRelaxInterval: time.Duration(envFloat(PowerRelaxIntervalEnv, defaultPowerRelaxInterval) * float64(time.Second)),
+	}
+	if c.PowerBracketSize < 1 {
+		c.PowerBracketSize = int(defaultPowerBracketSize)
+	}
+	return c
+}
+
+// powerBracket é a faixa de um deck: 0 para poder 0..size-1, 1 para size..2*size-1, etc.
+func (c MatchmakingConfig) powerBracket(power int) int {
+	return power / c.PowerBracketSize
+}
+
+// powerDisparity é quantas faixas de distância um jogador aceita depois de esperar 'waited'.
+func (c MatchmakingConfig) powerDisparity(waited time.Duration) int {
+	d := c.PowerDisparity
+	if c.PowerRelaxInterval > 0 {
+		d += int(waited / c.PowerRelaxInterval)
+	}
+	return max(min(d, c.PowerDisparityMax), c.PowerDisparity)
+}
+
+// window é a diferença de rating que um jogador aceita depois de esperar 'waited'.
+func (c MatchmakingConfig) window(waited time.Duration) float64 {
+	w := c.RatingWindow + c.RatingWindowGrowth*waited.Seconds()
+	return math.Min(math.Max(w, c.RatingWindow), c.RatingWindowMax)
+}
+
+// findOpponent procura, para o jogador da posição i, o oponente de mesmo ruleset e formato de série
+// com rating mais próximo dentro da janela e com deck numa faixa compatível. Quem
+// espera há mais tempo dita as tolerâncias, então um recém-chegado não trava quem
+// já está na fila há minutos. Retorna -1 se não houver oponente aceitável.
+func (c MatchmakingConfig) findOpponent(queue []*PlayerInfo, i int, now time.Time) int {
+	p := queue[i]
+	best, bestDiff := -1, math.Inf(1)
+	for j, candidate := range queue {
+		if j == i || candidate.Ruleset != p.Ruleset || candidate.bestOf() != p.bestOf() {
+			continue
+		}
+		oldest := p.EnqueuedAt
+		if candidate.EnqueuedAt.Before(oldest) {
+			oldest = candidate.EnqueuedAt
+		}
+		waited := now.Sub(oldest)
+		bracketGap := c.powerBracket(p.deckPower()) - c.powerBracket(candidate.deckPower())
+		if bracketGap < 0 {
+			bracketGap = -bracketGap
+		}
+		if bracketGap > c.powerDisparity(waited) {
+			continue
+		}
+		diff := math.Abs(p.Rating - candidate.Rating)
+		if diff <= c.window(waited) && diff < bestDiff {
+			best, bestDiff = j, diff
+		}
+	}
+	return best
+}
+
+func envFloat(name string, fallback float64) float64 {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return fallback
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || v < 0 {
+		log.Printf("[QueueMaster] WARN: Invalid %s=%q, using %.0f.", name, raw, fallback)
+		return fallback
+	}
+	return v
+}
+
+//END OF FILE jokenpo/internal/services/queue/matchmaking.go
@@ -0,0 +1,558 @@
+//START OF FILE jokenpo/internal/services/queue/proposals.go
+package queue
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Trocas diretas: um jogador propõe a outro "dou estas cartas, quero aquelas".
+// O líder guarda a proposta e roteia os eventos para o nó do Session de cada
+// jogador (via presença no Consul). As cartas ficam em custódia no Session:
+// as do proponente desde a proposta, as do destinatário desde o aceite. Aceita,
+// a troca é registrada no ledger numa única transação (logBundleSwap) e só então
+// as cartas são entregues; se o ledger recusar, as duas custódias são devolvidas.
+//
+// O resultado de cada proposta vira um TradeOutcome por jogador, persistido até o
+// Session dele creditar (list + ack), como os escrows do Wonder Trade: as cartas não
+// se perdem se o jogador estava offline ou se o aviso não chegou. O ID da proposta
+// vem do Session, e uma proposta encerrada fica lembrada por closedProposalRetention,
+// para que um propose, accept ou cancel repetido depois de um timeout tenha resposta
+// definitiva em vez de criar ou devolver algo duas vezes.
+
+// Estados de uma proposta (TradeProposal.State).
+const (
+	ProposalPending  = "pending"  // Aguardando a resposta do destinatário
+	ProposalSettling = "settling" // Aceita; registrando a troca no ledger
+)
+
+// Eventos enviados ao Session (POST em <nó do jogador>/trade-event).
+const (
+	TradeEventProposed  = "TRADE_PROPOSED"  // Ao destinatário: há uma proposta nova
+	TradeEventRejected  = "TRADE_REJECTED"  // Ao proponente: recusada (ou destinatário offline)
+	TradeEventCancelled = "TRADE_CANCELLED" // Ao destinatário: o proponente desistiu
+	TradeEventExpired   = "TRADE_EXPIRED"   // Aos dois: ninguém respondeu a tempo
+	TradeEventSettled   = "TRADE_SETTLED"   // Aos dois: troca feita
+	TradeEventFailed    = "TRADE_FAILED"    // Aos dois: o ledger recusou; as custódias voltam
+)
+
+const (
+	ProposalTimeoutEnv = "QUEUE_PROPOSAL_TIMEOUT_SECONDS" // Prazo para o destinatário responder
+
+	defaultProposalTimeout  = 120.0
+	maxProposalsPerPlayer   = 5
+	maxBundleSize           = 10
+	closedProposalRetention = time.Hour
+)
+
+var (
+	ErrProposalNotFound = errors.New("trade proposal not found")
+	ErrProposalLimit    = fmt.Errorf("a player can have at most %d pending trade proposals", maxProposalsPerPlayer)
+	ErrProposalInvalid  = fmt.Errorf("a trade proposal needs 1 to %d cards on each side and two different players", maxBundleSize)
+	ErrProposalSettling = errors.New("the trade was already accepted and is being settled")
+	ErrProposalClosed   = errors.New("the trade proposal was already closed")
+)
+
+// TradeProposal é uma troca direta entre FromID e ToID. Give sai de FromID, Receive de ToID.
+type TradeProposal struct {
+	ID        string    `json:"id"`
+	FromID    string    `json:"fromId"`
+	ToID      string    `json:"toId"`
+	Give      []string  `json:"give"`
+	Receive   []string  `json:"receive"`
+	State     string    `json:"state"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+
+	// Tokens do ledger escolhidos para a troca. São persistidos antes de a
+	// transação ser enviada: um novo líder confere se ela já entrou antes de repetir.
+	GiveTokens    []string `json:"giveTokens,omitempty"`
+	ReceiveTokens []string `json:"receiveTokens,omitempty"`
+}
+
+// TradeEvent é o corpo dos callbacks de trocas diretas.
+type TradeEvent struct {
+	EventType string        `json:"eventType"`
+	PlayerID  string        `json:"playerId"` // Jogador a quem o evento se destina
+	Proposal  TradeProposal `json:"proposal"`
+	Reason    string        `json:"reason,omitempty"`
+	OutcomeID string        `json:"outcomeId,omitempty"` // Resultado a recolher em POST /trades/outcomes
+}
+
+// TradeOutcome é o resultado de uma proposta para um dos jogadores. Credit são as
+// cartas que o Session credita ao recolher: as devolvidas ou as recebidas.
+type TradeOutcome struct {
+	ID        string        `json:"id"`
+	PlayerID  string        `json:"playerId"`
+	EventType string        `json:"eventType"`
+	Proposal  TradeProposal `json:"proposal"`
+	Credit    []string      `json:"credit,omitempty"`
+	Reason    string        `json:"reason,omitempty"`
+	CreatedAt time.Time     `json:"createdAt"`
+}
+
+// ClosedProposal lembra uma proposta encerrada (ou um ID cancelado antes de a
+// proposta existir) para responder às repetições do Session.
+type ClosedProposal struct {
+	FromID    string    `json:"fromId"`
+	ToID      string    `json:"toId,omitempty"`
+	Accepted  bool      `json:"accepted,omitempty"`  // As cartas do destinatário entraram na troca
+	Withdrawn bool      `json:"withdrawn,omitempty"` // Cancelada antes de existir: nenhum TradeOutcome foi criado
+	ClosedAt  time.Time `json:"closedAt"`
+}
+
+// --- Mensagens do ator ---
+
+type createProposalRequest struct {
+	proposal *TradeProposal
+	reply    chan error
+}
+
+func (createProposalRequest) isActorMessage() {}
+
+// Ações de respondProposalRequest.
+const (
+	proposalAccept = "accept"
+	proposalReject = "reject"
+	proposalCancel = "cancel"
+)
+
+type respondProposalRequest struct {
+	playerID, proposalID, action string
+	reply                        chan error
+}
+
+func (respondProposalRequest) isActorMessage() {}
+
+// proposalUndelivered: o nó do destinatário não recebeu a proposta.
+type proposalUndelivered struct{ proposalID string }
+
+func (proposalUndelivered) isActorMessage() {}
+
+// proposalPrepared guarda os tokens escolhidos antes da transação no ledger.
+type proposalPrepared struct {
+	proposalID             string
+	giveTokens, recvTokens []string
+	reply                  chan error
+}
+
+func (proposalPrepared) isActorMessage() {}
+
+// proposalSettled é o resultado da transação no ledger.
+type proposalSettled struct {
+	proposalID string
+	err        error
+}
+
+func (proposalSettled) isActorMessage() {}
+
+type listTradeOutcomesRequest struct {
+	playerID string
+	reply    chan []TradeOutcome
+}
+
+func (listTradeOutcomesRequest) isActorMessage() {}
+
+// --- API pública ---
+
+// ProposeTrade registra uma proposta. O estado e o prazo são definidos aqui; o ID
+// vem do Session (ou é gerado, se vazio). Repetir o pedido com o ID de uma proposta
+// pendente devolve a mesma proposta; com o de uma encerrada, ErrProposalClosed.
+func (m *QueueMaster) ProposeTrade(proposal *TradeProposal) error {
+	if proposal.FromID == "" || proposal.ToID == "" || proposal.FromID == proposal.ToID ||
+		len(proposal.Give) == 0 || len(proposal.Give) > maxBundleSize ||
+		len(proposal.Receive) == 0 || len(proposal.Receive) > maxBundleSize {
+		return ErrProposalInvalid
+	}
+	if proposal.ID == "" {
+		proposal.ID = uuid.NewString()
+	} else if uuid.Validate(proposal.ID) != nil {
+		return ErrProposalInvalid
+	}
+	now := time.Now()
+	proposal.State = ProposalPending
+	proposal.CreatedAt = now
+	proposal.ExpiresAt = now.Add(m.proposalTimeout)
+	proposal.GiveTokens, proposal.ReceiveTokens = nil, nil
+	reply := make(chan error, 1)
+	if err := m.send(createProposalRequest{proposal: proposal, reply: reply}); err != nil {
+		return err
+	}
+	return awaitReply(reply)
+}
+
+// AcceptTrade, RejectTrade e CancelTrade respondem a uma proposta pendente.
+// Só o destinatário aceita ou recusa; só o proponente cancela. Repetidos depois
+// de um timeout, AcceptTrade e CancelTrade retornam nil se o resultado já está (ou
+// vai estar) num TradeOutcome, e ErrProposalNotFound se as cartas do jogador nunca
+// entraram na troca. Um cancel de ID desconhecido bloqueia o ID para sempre.
+func (m *QueueMaster) AcceptTrade(playerID, proposalID string) error {
+	return m.respondProposal(playerID, proposalID, proposalAccept)
+}
+
+func (m *QueueMaster) RejectTrade(playerID, proposalID string) error {
+	return m.respondProposal(playerID, proposalID, proposalReject)
+}
+
+func (m *QueueMaster) CancelTrade(playerID, proposalID string) error {
+	return m.respondProposal(playerID, proposalID, proposalCancel)
+}
+
+func (m *QueueMaster) respondProposal(playerID, proposalID, action string) error {
+	reply := make(chan error, 1)
+	if err := m.send(respondProposalRequest{playerID: playerID, proposalID: proposalID, action: action, reply: reply}); err != nil {
+		return err
+	}
+	return awaitReply(reply)
+}
+
+// ListTradeOutcomes devolve os resultados de propostas do jogador. Eles só saem
+// do estado com o AckTradeOutcomes (delivery.go).
+func (m *QueueMaster) ListTradeOutcomes(playerID string) ([]TradeOutcome, error) {
+	reply := make(chan []TradeOutcome, 1)
+	if err := m.send(listTradeOutcomesRequest{playerID: playerID, reply: reply}); err != nil {
+		return nil, err
+	}
+	select {
+	case outcomes := <-reply:
+		return outcomes, nil
+	case <-time.After(2 * time.Second):
+		return nil, ErrQueueUnavailable
+	}
+}
+
+// --- Lógica do ator (só roda dentro de Run) ---
+
+func (m *QueueMaster) handleProposalMessage(ctx context.Context, msg actorMessage) {
+	switch req := msg.(type) {
+	case createProposalRequest:
+		if existing, ok := m.proposals[req.proposal.ID]; ok {
+			if existing.FromID != req.proposal.FromID {
+				req.reply <- ErrProposalClosed
+				return
+			}
+			*req.proposal = *existing // Repetição do mesmo pedido
+			req.reply <- nil
+			return
+		}
+		if _, ok := m.closedProposals[req.proposal.ID]; ok {
+			req.reply <- ErrProposalClosed
+			return
+		}
+		pending := 0
+		for _, p := range m.proposals {
+			if p.FromID == req.proposal.FromID {
+				pending++
+			}
+		}
+		if pending >= maxProposalsPerPlayer {
+			req.reply <- ErrProposalLimit
+			return
+		}
+		p := req.proposal
+		m.proposals[p.ID] = p
+		log.Printf("[Trades] +Proposal %s: %s gives %v to %s for %v", p.ID, p.FromID, p.Give, p.ToID, p.Receive)
+		req.reply <- nil
+		go m.deliverProposal(*p)
+
+	case respondProposalRequest:
+		p, ok := m.proposals[req.proposalID]
+		if !ok {
+			req.reply <- m.answerClosedProposal(req)
+			return
+		}
+		actor := p.ToID
+		if req.action == proposalCancel {
+			actor = p.FromID
+		}
+		if req.playerID != actor {
+			req.reply <- ErrProposalNotFound
+			return
+		}
+		if p.State != ProposalPending {
+			if req.action == proposalAccept {
+				req.reply <- nil // Aceite repetido: a troca já está sendo liquidada.
+				return
+			}
+			req.reply <- ErrProposalSettling
+			return
+		}
+		req.reply <- nil
+		switch req.action {
+		case proposalAccept:
+			p.State = ProposalSettling
+			log.Printf("[Trades] Proposal %s accepted by %s. Settling on the ledger...", p.ID, p.ToID)
+			m.settleProposal(ctx, *p)
+		case proposalReject:
+			log.Printf("[Trades] Proposal %s rejected by %s.", p.ID, p.ToID)
+			m.closeProposal(p, TradeEventRejected, "the other player rejected the trade")
+		case proposalCancel:
+			log.Printf("[Trades] Proposal %s cancelled by %s.", p.ID, p.FromID)
+			m.closeProposal(p, TradeEventCancelled, "the proposing player cancelled the trade")
+		}
+
+	case proposalUndelivered:
+		p, ok := m.proposals[req.proposalID]
+		if !ok || p.State != ProposalPending {
+			return
+		}
+		m.closeProposal(p, TradeEventRejected, "the other player is not online")
+
+	case proposalPrepared:
+		p, ok := m.proposals[req.proposalID]
+		if !ok || p.State != ProposalSettling {
+			req.reply <- ErrProposalNotFound
+			return
+		}
+		p.GiveTokens, p.ReceiveTokens = req.giveTokens, req.recvTokens
+		req.reply <- nil
+
+	case proposalSettled:
+		p, ok := m.proposals[req.proposalID]
+		if !ok {
+			return
+		}
+		if req.err != nil {
+			log.Printf("[Trades] Proposal %s FAILED on the ledger: %v. Returning escrowed cards.", p.ID, req.err)
+			m.closeProposal(p, TradeEventFailed, "the trade could not be recorded on the ledger")
+			return
+		}
+		log.Printf("[Trades] SETTLED %s: %s gets %v, %s gets %v", p.ID, p.FromID, p.Receive, p.ToID, p.Give)
+		m.closeProposal(p, TradeEventSettled, "")
+
+	case listTradeOutcomesRequest:
+		collected := listDelivery(m.outcomes, func(o *TradeOutcome) bool { return o.PlayerID == req.playerID })
+		slices.SortFunc(collected, func(x, y TradeOutcome) int { return x.CreatedAt.Compare(y.CreatedAt) })
+		req.reply <- collected
+	}
+}
+
+// answerClosedProposal responde a um accept, reject ou cancel de uma proposta que
+// não está mais pendente. nil = o resultado das cartas do jogador está num
+// TradeOutcome; ErrProposalNotFound = elas nunca entraram na troca.
+func (m *QueueMaster) answerClosedProposal(req respondProposalRequest) error {
+	closed, ok := m.closedProposals[req.proposalID]
+	if !ok {
+		if req.action == proposalCancel {
+			// O propose pode ainda estar a caminho: o ID fica bloqueado para que ele
+			// não crie a proposta depois que o Session devolveu as cartas.
+			m.closedProposals[req.proposalID] = &ClosedProposal{FromID: req.playerID, Withdrawn: true, ClosedAt: time.Now()}
+			log.Printf("[Trades] Proposal %s withdrawn by %s before it existed.", req.proposalID, req.playerID)
+		}
+		return ErrProposalNotFound
+	}
+	switch req.action {
+	case proposalCancel:
+		if closed.FromID == req.playerID && !closed.Withdrawn {
+			return nil
+		}
+	case proposalAccept:
+		if closed.ToID == req.playerID && closed.Accepted {
+			return nil
+		}
+	}
+	return ErrProposalNotFound
+}
+
+// closeProposal encerra a proposta e grava o TradeOutcome de cada jogador. O
+// proponente sempre recebe um (as cartas dele estão em custódia); o destinatário
+// só não recebe quando foi ele quem recusou.
+func (m *QueueMaster) closeProposal(p *TradeProposal, eventType, reason string) {
+	delete(m.proposals, p.ID)
+	m.closedProposals[p.ID] = &ClosedProposal{FromID: p.FromID, ToID: p.ToID, Accepted: p.State == ProposalSettling, ClosedAt: time.Now()}
+	fromCredit, toCredit := p.Give, []string(nil)
+	switch eventType {
+	case TradeEventSettled:
+		fromCredit, toCredit = p.Receive, p.Give
+	case TradeEventFailed:
+		toCredit = p.Receive
+	}
+	m.recordOutcome(p.FromID, eventType, *p, fromCredit, reason)
+	if eventType != TradeEventRejected {
+		m.recordOutcome(p.ToID, eventType, *p, toCredit, reason)
+	}
+}
+
+// recordOutcome guarda o resultado e avisa o Session do jogador pelo outbox. Se
+// ele estiver offline, o resultado espera o próximo login.
+func (m *QueueMaster) recordOutcome(playerID, eventType string, p TradeProposal, credit []string, reason string) {
+	o := &TradeOutcome{
+		ID:        uuid.NewString(),
+		PlayerID:  playerID,
+		EventType: eventType,
+		Proposal:  p,
+		Credit:    credit,
+		Reason:    reason,
+		CreatedAt: time.Now(),
+	}
+	m.outcomes[o.ID] = o
+	go m.notifyOutcome(*o)
+}
+
+func (m *QueueMaster) notifyOutcome(o TradeOutcome) {
+	nodeURL, err := m.presence.Locate(o.PlayerID)
+	if err != nil || nodeURL == "" {
+		log.Printf("[Trades] %s of proposal %s kept for the next login of %s: player is offline (%v).", o.EventType, o.Proposal.ID, o.PlayerID, err)
+		return
+	}
+	event := TradeEvent{EventType: o.EventType, PlayerID: o.PlayerID, Proposal: o.Proposal, Reason: o.Reason, OutcomeID: o.ID}
+	m.sendCallback(o.PlayerID, nodeURL+"/trade-event", o.EventType, event)
+}
+
+// tryProposals vence as propostas pendentes fora do prazo e esquece as encerradas
+// há mais de closedProposalRetention. Roda no ticker do ator.
+func (m *QueueMaster) tryProposals(now time.Time) bool {
+	changed := false
+	for _, p := range m.proposals {
+		if p.State == ProposalPending && now.After(p.ExpiresAt) {
+			log.Printf("[Trades] Proposal %s expired.", p.ID)
+			m.closeProposal(p, TradeEventExpired, "the trade was not answered in time")
+			changed = true
+		}
+	}
+	for id, closed := range m.closedProposals {
+		if now.Sub(closed.ClosedAt) > closedProposalRetention {
+			delete(m.closedProposals, id)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// resumeSettlements retoma as liquidações que o líder anterior deixou no meio.
+func (m *QueueMaster) resumeSettlements(ctx context.Context) {
+	for _, p := range m.proposals {
+		if p.State == ProposalSettling {
+			log.Printf("[Trades] Resuming settlement of proposal %s.", p.ID)
+			m.settleProposal(ctx, *p)
+		}
+	}
+}
+
+// settleProposal registra a troca no ledger fora do ator e devolve o resultado
+// como proposalSettled. Se a liderança cair no meio, o resultado é descartado e
+// o próximo líder retoma a partir do estado persistido.
+func (m *QueueMaster) settleProposal(ctx context.Context, p TradeProposal) {
+	go func() {
+		err := m.swapOnLedger(ctx, &p)
+		if ctx.Err() != nil {
+			return
+		}
+		select {
+		case m.requestCh <- proposalSettled{proposalID: p.ID, err: err}:
+		case <-ctx.Done():
+		}
+	}()
+}
+
+func (m *QueueMaster) swapOnLedger(ctx context.Context, p *TradeProposal) error {
+	if m.blockchain == nil {
+		log.Printf("[Trades] WARN: Ledger unavailable; proposal %s settled without an audit record.", p.ID)
+		return nil
+	}
+
+	if len(p.GiveTokens) == 0 {
+		giveTokens, err := m.blockchain.FindTokensForCards(p.FromID, p.Give)
+		if err != nil {
+			return err
+		}
+		recvTokens, err := m.blockchain.FindTokensForCards(p.ToID, p.Receive)
+		if err != nil {
+			return err
+		}
+		reply := make(chan error, 1)
+		select {
+		case m.requestCh <- proposalPrepared{proposalID: p.ID, giveTokens: giveTokens, recvTokens: recvTokens, reply: reply}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if err := awaitReply(reply); err != nil {
+			return err
+		}
+		p.GiveTokens, p.ReceiveTokens = giveTokens, recvTokens
+	} else if applied, err := m.swapApplied(p); err == nil && applied {
+		log.Printf("[Trades] Proposal %s was already recorded on the ledger by the previous leader.", p.ID)
+		return nil
+	}
+
+	log.Printf("[Trades] LEDGER: %s %v <-> %s %v", p.FromID, p.GiveTokens, p.ToID, p.ReceiveTokens)
+	return m.recordSwap(ctx, "proposal "+p.ID,
+		func() error { return m.blockchain.LogBundleSwap(p.FromID, p.ToID, p.GiveTokens, p.ReceiveTokens) },
+		func() (bool, error) { return m.swapApplied(p) })
+}
+
+// swapApplied confere se os tokens da proposta já estão com os novos donos. Um
+// erro quer dizer que o ledger não pôde ser lido.
+func (m *QueueMaster) swapApplied(p *TradeProposal) (bool, error) {
+	fromAssets, err := m.blockchain.PlayerAssets(p.FromID)
+	if err != nil {
+		return false, err
+	}
+	toAssets, err := m.blockchain.PlayerAssets(p.ToID)
+	if err != nil {
+		return false, err
+	}
+	for _, token := range p.GiveTokens {
+		if !slices.Contains(toAssets, token) {
+			return false, nil
+		}
+	}
+	for _, token := range p.ReceiveTokens {
+		if !slices.Contains(fromAssets, token) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// deliverProposal avisa o destinatário. Se ele não estiver conectado em nenhum
+// nó, a proposta é desfeita e as cartas do proponente voltam.
+func (m *QueueMaster) deliverProposal(p TradeProposal) {
+	if m.sendTradeEvent(p.ToID, TradeEvent{EventType: TradeEventProposed, Proposal: p}) {
+		return
+	}
+	if err := m.send(proposalUndelivered{proposalID: p.ID}); err != nil {
+		log.Printf("[Trades] WARN: Could not withdraw undelivered proposal %s: %v", p.ID, err)
+	}
+}
+
+// sendTradeEvent entrega o evento no nó do Session onde o jogador está conectado.
+// Retorna false se o jogador não foi encontrado. Só o TRADE_PROPOSED sai por aqui:
+// os resultados vão como TradeOutcome pelo outbox.
+func (m *QueueMaster) sendTradeEvent(playerID string, event TradeEvent) bool {
+	event.PlayerID = playerID
+	return m.postToPlayer(playerID, "/trade-event", event, event.EventType)
+}
+
+// postToPlayer faz o POST de payload em path no nó do Session do jogador, achado
+// pela presença no Consul. Retorna false se o jogador não recebeu.
+func (m *QueueMaster) postToPlayer(playerID, path string, payload interface{}, label string) bool {
+	nodeURL, err := m.presence.Locate(playerID)
+	if err != nil || nodeURL == "" {
+		log.Printf("[Trades] WARN: %s for %s not delivered: player is offline (%v).", label, playerID, err)
+		return false
+	}
+	data, _ := json.Marshal(payload)
+	resp, err := m.httpClient.Post(nodeURL+path, "application/json", bytes.NewBuffer(data))
+	if err != nil {
+		log.Printf("[Trades] WARN: %s for %s not delivered: %v", label, playerID, err)
+		return false
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("[Trades] WARN: %s for %s not delivered: session answered %s", label, playerID, resp.Status)
+		return false
+	}
+	return true
+}
+
+//END OF FILE jokenpo/internal/services/queue/proposals.go
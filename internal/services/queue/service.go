@@ -3,13 +3,26 @@ package queue
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"jokenpo/internal/game/card"
+	"jokenpo/internal/game/deck"
+	"jokenpo/internal/game/rules"
 	"jokenpo/internal/services/blockchain"
 	"jokenpo/internal/services/cluster"
+	"jokenpo/internal/services/outbox"
+	"jokenpo/internal/services/presence"
+	"jokenpo/internal/services/ratings"
 	"log"
+	"maps"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 // ... (Structs e NewQueueMaster permanecem iguais) ...
@@ -18,13 +31,37 @@ type PlayerInfo struct {
 	CallbackURL string   `json:"callbackUrl"`
 	MatchCallbackURL string 
 	Deck        []string `json:"deck"`
+	Ruleset     string   `json:"ruleset,omitempty"`
+	BestOf      int      `json:"bestOf,omitempty"` // Formato da série; só formatos iguais são pareados
+	Entropy     string   `json:"entropy,omitempty"`
+	Rating      float64   `json:"rating,omitempty"`     // Rating Glicko-2 no momento da entrada na fila
+	EnqueuedAt  time.Time `json:"enqueuedAt,omitempty"` // Alarga a janela de rating conforme a espera
+	DeckProfile *deck.Profile `json:"deckProfile,omitempty"`
+}
+
+// bestOf é o formato de série do jogador (entradas antigas, sem o campo, jogam partida única).
+func (p *PlayerInfo) bestOf() int {
+	return max(p.BestOf, 1)
+}
+
+// deckPower é o poder do deck do jogador (0 para entradas sem perfil).
+func (p *PlayerInfo) deckPower() int {
+	if p.DeckProfile == nil {
+		return 0
+	}
+	return p.DeckProfile.Power
 }
 type TradeInfo struct {
 	PlayerInfo
 	OfferCard string `json:"offerCard"`
+	EscrowID  string `json:"escrowId,omitempty"` // Custódia da carta oferecida (escrow.go)
 }
 type CreateRoomRequest struct {
+	RoomID      string        `json:"roomId"` // Fixado aqui: a sala não escolhe o ID depois de ver a entropia
 	PlayerInfos []*PlayerInfo `json:"playerInfos"`
+	Ruleset     string        `json:"ruleset,omitempty"`
+	BestOf      int           `json:"bestOf,omitempty"`
+	Timing      *rules.Timing `json:"timing,omitempty"`
 }
 type CreateRoomResponse struct {
 	RoomID      string `json:"roomId"`
@@ -39,15 +76,82 @@ type MatchFailedPayload struct {
 	PlayerIDs []string `json:"playerIds"`
 	Reason    string   `json:"reason"`
 }
+// QueueState é o que o líder persiste no Consul (service/jokenpo-queue/state)
+// a cada mutação das filas, para que um novo líder retome o pareamento.
+type QueueState struct {
+	MatchQueue      []*PlayerInfo              `json:"matchQueue"`
+	TradeQueue      []*TradeInfo               `json:"tradeQueue"`
+	InFlight        []*PendingMatch            `json:"inFlight,omitempty"`        // Pares já tirados da fila, sala ainda não confirmada
+	Listings        []*TradeListing            `json:"listings,omitempty"`        // Ofertas do mercado de trocas
+	ListingMatches  []*ListingMatch            `json:"listingMatches,omitempty"`  // Pares de ofertas aguardando aceite
+	Proposals       []*TradeProposal           `json:"proposals,omitempty"`       // Trocas diretas pendentes ou em liquidação
+	Escrows         []*TradeEscrow             `json:"escrows,omitempty"`         // Custódias do Wonder Trade ainda não recolhidas
+	TradeOutcomes   []*TradeOutcome            `json:"tradeOutcomes,omitempty"`   // Resultados de trocas diretas ainda não recolhidos
+	ClosedProposals map[string]*ClosedProposal `json:"closedProposals,omitempty"` // Propostas encerradas há pouco
+	ListingOutcomes []*ListingOutcome          `json:"listingOutcomes,omitempty"` // Resultados do mercado ainda não recolhidos
+}
+
+// PendingMatch é um par cuja sala está sendo criada. O ID do par é o ID da sala e,
+// junto com a instância escolhida, é gravado antes do POST /rooms. Se o líder cair
+// antes do resultado, o próximo repete a criação com o mesmo ID na mesma instância,
+// que devolve a sala se ela já existe. Os jogadores só voltam à fila quando a sala
+// com certeza não existe.
+type PendingMatch struct {
+	ID           string         `json:"id"`
+	Players      [2]*PlayerInfo `json:"players"`
+	GameRoomAddr string         `json:"gameRoomAddr,omitempty"` // Instância do GameRoom que recebe o POST /rooms
+}
+
+// Repetição do POST /rooms quando a resposta é ambígua (rede, 5xx).
+const (
+	roomCreateRetryMin = 500 * time.Millisecond
+	roomCreateRetryMax = 10 * time.Second
+)
+
 type QueueMaster struct {
 	matchQueue   []*PlayerInfo
 	tradeQueue   []*TradeInfo
 	requestCh    chan actorMessage
 	httpClient   *http.Client
 	serviceCache *cluster.ServiceCacheActor
+	catalogGuard *cluster.CatalogGuard
     blockchain   *blockchain.BlockchainClient
+	ratings      *ratings.Store
+	consul       *cluster.ConsulManager // Confere se a instância de uma sala pendente ainda existe
+	matchmaking  MatchmakingConfig
+	timing       TimingConfig // Ritmo das salas de cada modo (timing.go)
+
+	inFlight map[string]*PendingMatch
+
+	// Mercado de trocas (market.go)
+	listings        map[string]*TradeListing
+	listingMatches  map[string]*ListingMatch
+	listingTTL      time.Duration
+	acceptTimeout   time.Duration
+	listingOutcomes map[string]*ListingOutcome
+
+	// Trocas diretas (proposals.go)
+	proposals       map[string]*TradeProposal
+	proposalTimeout time.Duration
+	outcomes        map[string]*TradeOutcome
+	closedProposals map[string]*ClosedProposal
+	presence        *presence.Store
+	outbox          *outbox.Outbox // Callbacks para o Session (match-found, trade-found, market-event)
+
+	// Custódia do Wonder Trade (escrow.go)
+	escrows       map[string]*TradeEscrow
+	tradeOfferTTL time.Duration
+
+	elector  *cluster.LeaderElector
+	snapshot atomic.Pointer[QueueState] // Última cópia das filas, lida pelo GetState
+
+	// Ciclo de vida do ator: Run só roda enquanto este nó é líder.
+	lifeMu   sync.Mutex
+	stop     context.CancelFunc
+	stopped  chan struct{}
+	pairings sync.WaitGroup // orchestrateRoomCreation em andamento
 }
-func NewQueueMaster(manager *cluster.ConsulManager) *QueueMaster {
+func NewQueueMaster(manager *cluster.ConsulManager, elector *cluster.LeaderElector) *QueueMaster {
     var bcClient *blockchain.BlockchainClient
     var contractAddr string
     log.Println("QUEUE: Aguardando endereço do contrato no Consul...")
@@ -69,14 +173,39 @@ func NewQueueMaster(manager *cluster.ConsulManager) *QueueMaster {
         if err != nil { log.Printf("QUEUE AVISO: %v", err) } else { log.Printf("QUEUE: Conectado blockchain %s", contractAddr) }
     } else { log.Println("QUEUE AVISO: Timeout blockchain.") }
 
-	return &QueueMaster{
+	m := &QueueMaster{
 		matchQueue:   make([]*PlayerInfo, 0),
 		tradeQueue:   make([]*TradeInfo, 0),
 		requestCh:    make(chan actorMessage),
 		httpClient:   &http.Client{Timeout: 10 * time.Second},
 		serviceCache: cluster.NewServiceCacheActor(30*time.Second, manager),
+		catalogGuard: cluster.NewCatalogGuard(card.Fingerprint()),
         blockchain:   bcClient,
+		ratings:      ratings.NewStore(manager),
+		consul:       manager,
+		matchmaking:  LoadMatchmakingConfig(),
+		timing:       LoadTimingConfig(),
+		inFlight:     make(map[string]*PendingMatch),
+		elector:      elector,
+
+		listings:        make(map[string]*TradeListing),
+		listingMatches:  make(map[string]*ListingMatch),
+		listingTTL:      envSeconds(ListingTTLEnv, defaultListingTTL),
+		acceptTimeout:   envSeconds(AcceptTimeoutEnv, defaultAcceptTimeout),
+		listingOutcomes: make(map[string]*ListingOutcome),
+
+		proposals:       make(map[string]*TradeProposal),
+		proposalTimeout: envSeconds(ProposalTimeoutEnv, defaultProposalTimeout),
+		outcomes:        make(map[string]*TradeOutcome),
+		closedProposals: make(map[string]*ClosedProposal),
+		presence:        presence.NewStore(manager),
+		outbox:          outbox.New("jokenpo-queue", manager),
+
+		escrows:       make(map[string]*TradeEscrow),
+		tradeOfferTTL: envSeconds(TradeOfferTTLEnv, defaultTradeOfferTTL),
 	}
+	m.snapshot.Store(&QueueState{})
+	return m
 }
 
 type actorMessage interface{ isActorMessage() }
@@ -86,150 +215,473 @@ type dequeueMatchRequest struct{ playerID string }
 func (dequeueMatchRequest) isActorMessage() {}
 type enqueueTradeRequest struct{ trade *TradeInfo }
 func (enqueueTradeRequest) isActorMessage() {}
-type dequeueTradeRequest struct{ playerID string }
+type dequeueTradeRequest struct {
+	playerID string
+	reply    chan error
+}
 func (dequeueTradeRequest) isActorMessage() {}
+type roomCreationDone struct {
+	matchID string
+	requeue bool // A sala com certeza não existe: o par volta ao início da fila
+}
+func (roomCreationDone) isActorMessage() {}
+
+// ErrQueueUnavailable é retornado quando o ator não está rodando (nó não é líder
+// ou está passando a liderança adiante).
+var ErrQueueUnavailable = errors.New("queue actor is not running")
 
-func (m *QueueMaster) Run() {
+// Run é o ator do QueueMaster. Roda até o ctx ser cancelado (OnBecomeFollower);
+// antes de sair, espera as criações de sala em andamento terminarem.
+func (m *QueueMaster) Run(ctx context.Context) {
 	log.Println("[QueueMaster] Actor started.")
 	ticker := time.NewTicker(2 * time.Second)
 	defer ticker.Stop()
+	defer func() {
+		m.pairings.Wait()
+		log.Println("[QueueMaster] Actor stopped.")
+	}()
+	// Callbacks que o líder anterior não chegou a entregar saem por este nó.
+	go m.outbox.Run(ctx)
+	m.resumeSettlements(ctx)
+	m.resumeMarketSettlements(ctx)
+	m.resumeWonderTrades(ctx)
+	m.resumePendingMatches(ctx)
 	for {
 		select {
+		case <-ctx.Done():
+			return
 		case msg := <-m.requestCh:
 			switch req := msg.(type) {
 			case enqueueMatchRequest:
+				// Um reenvio (ex: sessão repetindo após failover) substitui a entrada antiga.
+				m.matchQueue = removePlayerFromMatchQueue(m.matchQueue, req.player.ID)
+				req.player.EnqueuedAt = time.Now()
 				m.matchQueue = append(m.matchQueue, req.player)
-				log.Printf("[QM] +MatchQueue: %s", req.player.ID)
+				log.Printf("[QM] +MatchQueue: %s (rating %.0f, deck power %d)", req.player.ID, req.player.Rating, req.player.deckPower())
 			case dequeueMatchRequest:
 				m.matchQueue = removePlayerFromMatchQueue(m.matchQueue, req.playerID)
 			case enqueueTradeRequest:
-				m.tradeQueue = append(m.tradeQueue, req.trade)
-				log.Printf("[QM] +TradeQueue: %s offers %s", req.trade.ID, req.trade.OfferCard)
+				m.openEscrow(req.trade)
+				log.Printf("[QM] +TradeQueue: %s offers %s (escrow %s)", req.trade.ID, req.trade.OfferCard, req.trade.EscrowID)
 			case dequeueTradeRequest:
-				m.tradeQueue = removePlayerFromTradeQueue(m.tradeQueue, req.playerID)
+				req.reply <- m.closeTradeOffer(req.playerID)
+			case roomCreationDone:
+				if pending, ok := m.inFlight[req.matchID]; ok && req.requeue {
+					m.requeuePending(pending)
+				}
+				delete(m.inFlight, req.matchID)
+			case browseListingsRequest:
+				m.handleMarketMessage(ctx, req)
+				continue // Só leitura: nada a persistir.
+			case createProposalRequest, respondProposalRequest, proposalUndelivered, proposalPrepared, proposalSettled:
+				m.handleProposalMessage(ctx, req)
+			case escrowPrepared, escrowSwapped:
+				m.handleEscrowMessage(req)
+			case listEscrowsRequest:
+				m.handleEscrowMessage(req)
+				continue // Só leitura: nada a persistir.
+			case listTradeOutcomesRequest:
+				m.handleProposalMessage(ctx, req)
+				continue
+			case listListingOutcomesRequest:
+				m.handleMarketMessage(ctx, req)
+				continue
+			case custodyRequest:
+				m.handleCustody(req)
+				continue // Só leitura: nada a persistir.
+			case ackDeliveryRequest:
+				m.handleAckDelivery(req)
+			default:
+				m.handleMarketMessage(ctx, req)
 			}
+			m.persistState()
 		case <-ticker.C:
-			m.tryPairingMatches()
-			m.tryPairingTrades()
+			matched := m.tryPairingMatches(ctx)
+			refunded := m.expireTradeOffers(time.Now())
+			traded := m.tryPairingTrades(ctx)
+			listed := m.tryMarket(time.Now())
+			expired := m.tryProposals(time.Now())
+			if matched || refunded || traded || listed || expired {
+				m.persistState()
+			}
 		}
 	}
 }
-func (m *QueueMaster) EnqueueMatch(player *PlayerInfo) { m.requestCh <- enqueueMatchRequest{player: player} }
-func (m *QueueMaster) DequeueMatch(playerID string)   { m.requestCh <- dequeueMatchRequest{playerID: playerID} }
-func (m *QueueMaster) EnqueueTrade(trade *TradeInfo)  { m.requestCh <- enqueueTradeRequest{trade: trade} }
-func (m *QueueMaster) DequeueTrade(playerID string)   { m.requestCh <- dequeueTradeRequest{playerID: playerID} }
 
+// persistState grava as filas no Consul. Só é chamado de dentro do ator, depois
+// de cada mutação; o GetState lê a cópia guardada em 'snapshot'.
+func (m *QueueMaster) persistState() {
+	state := &QueueState{
+		MatchQueue: append([]*PlayerInfo(nil), m.matchQueue...),
+		TradeQueue: append([]*TradeInfo(nil), m.tradeQueue...),
+	}
+	for _, pending := range m.inFlight {
+		state.InFlight = append(state.InFlight, pending)
+	}
+	for _, listing := range m.listings {
+		copied := *listing
+		state.Listings = append(state.Listings, &copied)
+	}
+	for _, match := range m.listingMatches {
+		copied := *match
+		copied.Accepted = maps.Clone(match.Accepted)
+		copied.Tokens = maps.Clone(match.Tokens)
+		state.ListingMatches = append(state.ListingMatches, &copied)
+	}
+	for _, proposal := range m.proposals {
+		copied := *proposal
+		state.Proposals = append(state.Proposals, &copied)
+	}
+	for _, escrow := range m.escrows {
+		copied := *escrow
+		state.Escrows = append(state.Escrows, &copied)
+	}
+	for _, outcome := range m.outcomes {
+		copied := *outcome
+		state.TradeOutcomes = append(state.TradeOutcomes, &copied)
+	}
+	for _, outcome := range m.listingOutcomes {
+		copied := *outcome
+		state.ListingOutcomes = append(state.ListingOutcomes, &copied)
+	}
+	state.ClosedProposals = make(map[string]*ClosedProposal, len(m.closedProposals))
+	for id, closed := range m.closedProposals {
+		copied := *closed
+		state.ClosedProposals[id] = &copied
+	}
+	m.snapshot.Store(state)
+	if m.elector == nil {
+		return
+	}
+	if err := m.elector.PersistState(m); err != nil {
+		log.Printf("[QueueMaster] WARN: Failed to persist queues: %v", err)
+	}
+}
+
+// applyState troca as filas em memória. Os pares que o líder anterior deixou em
+// andamento continuam InFlight: o Run repete a criação das salas deles com o mesmo ID.
+func (m *QueueMaster) applyState(state QueueState) {
+	m.matchQueue = make([]*PlayerInfo, 0, len(state.MatchQueue)+2*len(state.InFlight))
+	m.inFlight = make(map[string]*PendingMatch, len(state.InFlight))
+	for _, pending := range state.InFlight {
+		if pending.GameRoomAddr == "" {
+			// Gravado antes de a instância ser guardada: não há como conferir a sala.
+			log.Printf("[QueueMaster] Handing back in-flight match %s (%s vs %s) to the queue.", pending.ID, pending.Players[0].ID, pending.Players[1].ID)
+			m.matchQueue = append(m.matchQueue, pending.Players[0], pending.Players[1])
+			continue
+		}
+		m.inFlight[pending.ID] = pending
+	}
+	m.matchQueue = append(m.matchQueue, state.MatchQueue...)
+	m.escrows = make(map[string]*TradeEscrow, len(state.Escrows))
+	for _, escrow := range state.Escrows {
+		m.escrows[escrow.ID] = escrow
+	}
+	// Ofertas gravadas antes da custódia existir ganham um escrow ao serem restauradas.
+	m.tradeQueue = make([]*TradeInfo, 0, len(state.TradeQueue))
+	for _, trade := range state.TradeQueue {
+		if _, ok := m.escrows[trade.EscrowID]; !ok {
+			m.openEscrow(trade)
+			continue
+		}
+		m.tradeQueue = append(m.tradeQueue, trade)
+	}
+	m.listings = make(map[string]*TradeListing, len(state.Listings))
+	for _, listing := range state.Listings {
+		m.listings[listing.ID] = listing
+	}
+	m.listingMatches = make(map[string]*ListingMatch, len(state.ListingMatches))
+	for _, match := range state.ListingMatches {
+		m.listingMatches[match.ID] = match
+	}
+	m.proposals = make(map[string]*TradeProposal, len(state.Proposals))
+	for _, proposal := range state.Proposals {
+		m.proposals[proposal.ID] = proposal
+	}
+	m.outcomes = make(map[string]*TradeOutcome, len(state.TradeOutcomes))
+	for _, outcome := range state.TradeOutcomes {
+		m.outcomes[outcome.ID] = outcome
+	}
+	m.listingOutcomes = make(map[string]*ListingOutcome, len(state.ListingOutcomes))
+	for _, outcome := range state.ListingOutcomes {
+		m.listingOutcomes[outcome.ID] = outcome
+	}
+	m.closedProposals = make(map[string]*ClosedProposal, len(state.ClosedProposals))
+	maps.Copy(m.closedProposals, state.ClosedProposals)
+	m.snapshot.Store(&QueueState{MatchQueue: m.matchQueue, TradeQueue: m.tradeQueue, InFlight: state.InFlight, Listings: state.Listings, ListingMatches: state.ListingMatches, Proposals: state.Proposals, Escrows: state.Escrows, TradeOutcomes: state.TradeOutcomes, ClosedProposals: state.ClosedProposals, ListingOutcomes: state.ListingOutcomes})
+	log.Printf("[QueueMaster] Restored %d match entries, %d in-flight matches, %d trade offers, %d market listings, %d trade proposals, %d trade outcomes and %d trade escrows.", len(m.matchQueue), len(m.inFlight), len(m.tradeQueue), len(m.listings), len(m.proposals), len(m.outcomes), len(m.escrows))
+}
+
+// --- Implementação de cluster.StatefulService ---
 
-// --- MUDANÇA PRINCIPAL AQUI ---
+func (m *QueueMaster) GetState() interface{} { return m.snapshot.Load() }
 
-func (m *QueueMaster) tryPairingTrades() {
-	if len(m.tradeQueue) < 2 {
+// SetState é chamado pelo LeaderElector antes do OnBecomeLeader. Nesse momento o
+// ator está parado (OnBecomeFollower espera ele sair), então as filas são escritas direto.
+func (m *QueueMaster) SetState(data []byte) error {
+	var state QueueState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	m.lifeMu.Lock()
+	defer m.lifeMu.Unlock()
+	if m.stop != nil {
+		return errors.New("cannot restore queues while the actor is running")
+	}
+	m.applyState(state)
+	return nil
+}
+
+func (m *QueueMaster) OnBecomeLeader() {
+	m.lifeMu.Lock()
+	defer m.lifeMu.Unlock()
+	if m.stop != nil {
+		return // O ator desta liderança já está rodando.
+	}
+	log.Println("[QueueMaster] This node became the leader. Starting QueueMaster actor...")
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	m.stop, m.stopped = cancel, stopped
+	go func() {
+		m.Run(ctx)
+		close(stopped)
+	}()
+}
+
+// OnBecomeFollower para o ator e espera as criações de sala em andamento: as que
+// terminam a tempo são concluídas; as que o cancelamento interrompe ficam como
+// InFlight no estado persistido e o próximo líder as repete.
+func (m *QueueMaster) OnBecomeFollower() {
+	m.lifeMu.Lock()
+	defer m.lifeMu.Unlock()
+	if m.stop == nil {
 		return
 	}
-	trade1 := m.tradeQueue[0]
-	trade2 := m.tradeQueue[1]
-	m.tradeQueue = m.tradeQueue[2:]
-	log.Printf("[QueueMaster] TRADE MATCH: %s <-> %s", trade1.ID, trade2.ID)
-
-    // --- LÓGICA DE REGISTRO NA BLOCKCHAIN (Troca de Tokens Específicos) ---
-    if m.blockchain != nil {
-        go func() {
-            // 1. Encontrar o Token UUID real da carta do Jogador 1
-            token1, err := m.blockchain.FindTokenForCard(trade1.ID, trade1.OfferCard)
-            if err != nil {
-                log.Printf("QUEUE ERRO: Não foi possível encontrar o token blockchain para %s do player %s: %v", trade1.OfferCard, trade1.ID, err)
-                return // Aborta registro se não achar
-            }
+	log.Println("[QueueMaster] This node became a follower. Stopping QueueMaster actor...")
+	m.stop()
+	<-m.stopped
+	m.stop, m.stopped = nil, nil
 
-            // 2. Encontrar o Token UUID real da carta do Jogador 2
-            token2, err := m.blockchain.FindTokenForCard(trade2.ID, trade2.OfferCard)
-            if err != nil {
-                log.Printf("QUEUE ERRO: Não foi possível encontrar o token blockchain para %s do player %s: %v", trade2.OfferCard, trade2.ID, err)
-                return
-            }
+	// As filas em memória não valem mais: o próximo líder restaura as do Consul.
+	m.matchQueue = make([]*PlayerInfo, 0)
+	m.tradeQueue = make([]*TradeInfo, 0)
+	m.inFlight = make(map[string]*PendingMatch)
+	m.listings = make(map[string]*TradeListing)
+	m.listingMatches = make(map[string]*ListingMatch)
+	m.listingOutcomes = make(map[string]*ListingOutcome)
+	m.proposals = make(map[string]*TradeProposal)
+	m.outcomes = make(map[string]*TradeOutcome)
+	m.closedProposals = make(map[string]*ClosedProposal)
+	m.escrows = make(map[string]*TradeEscrow)
+	m.snapshot.Store(&QueueState{})
+	log.Println("[QueueMaster] QueueMaster is idle.")
+}
+func (m *QueueMaster) EnqueueMatch(player *PlayerInfo) error { return m.send(enqueueMatchRequest{player: player}) }
+func (m *QueueMaster) DequeueMatch(playerID string) error   { return m.send(dequeueMatchRequest{playerID: playerID}) }
+func (m *QueueMaster) EnqueueTrade(trade *TradeInfo) error  { return m.send(enqueueTradeRequest{trade: trade}) }
 
-            log.Printf("QUEUE BLOCKCHAIN: Trocando tokens [%s] <-> [%s]", token1, token2)
+// DequeueTrade tira o jogador da fila do Wonder Trade e devolve a carta oferecida
+// ao escrow. Retorna ErrEscrowSettling se a oferta já foi pareada.
+func (m *QueueMaster) DequeueTrade(playerID string) error {
+	reply := make(chan error, 1)
+	if err := m.send(dequeueTradeRequest{playerID: playerID, reply: reply}); err != nil {
+		return err
+	}
+	return awaitReply(reply)
+}
 
-            // 3. Executa a Troca 1: A -> B (Envia Token 1)
-            if err := m.blockchain.LogTrade(trade1.ID, trade2.ID, token1); err != nil {
-                log.Printf("QUEUE ERRO: Falha TX A->B: %v", err)
-            } else {
-                 log.Printf("QUEUE SUCESSO: %s transferido para %s", token1, trade2.ID)
-            }
+// send entrega uma mensagem ao ator. Se ele não estiver rodando, desiste em vez de travar o handler.
+func (m *QueueMaster) send(msg actorMessage) error {
+	select {
+	case m.requestCh <- msg:
+		return nil
+	case <-time.After(2 * time.Second):
+		return ErrQueueUnavailable
+	}
+}
 
-            // 4. Executa a Troca 2: B -> A (Envia Token 2)
-            if err := m.blockchain.LogTrade(trade2.ID, trade1.ID, token2); err != nil {
-                log.Printf("QUEUE ERRO: Falha TX B->A: %v", err)
-            } else {
-                log.Printf("QUEUE SUCESSO: %s transferido para %s", token2, trade1.ID)
-            }
-        }()
-    }
 
-	// Callbacks HTTP para o Jogo (Mantém a lógica de inventário funcionando)
-	payload1 := map[string]string{
-		"playerId":     trade1.ID,
-		"cardSent":     trade1.OfferCard,
-		"cardReceived": trade2.OfferCard,
-		"partnerId":    trade2.ID, 
+// tryPairingMatches pareia, por ordem de chegada, o primeiro jogador que tem um
+// oponente de mesmo ruleset e formato de série dentro da janela de rating e numa faixa de poder de
+// deck compatível. Retorna true se a fila mudou.
+func (m *QueueMaster) tryPairingMatches(ctx context.Context) bool {
+	if len(m.matchQueue) < 2 { return false }
+	now := time.Now()
+	for i := 0; i < len(m.matchQueue); i++ {
+		j := m.matchmaking.findOpponent(m.matchQueue, i, now)
+		if j < 0 {
+			continue
+		}
+		if j < i {
+			i, j = j, i
+		}
+		p1, p2 := m.matchQueue[i], m.matchQueue[j]
+		m.matchQueue = append(m.matchQueue[:j], m.matchQueue[j+1:]...)
+		m.matchQueue = append(m.matchQueue[:i], m.matchQueue[i+1:]...)
+		log.Printf("[QueueMaster] MATCH FOUND! %s (%.0f, power %d) vs %s (%.0f, power %d) (ruleset: %q, best of %d)", p1.ID, p1.Rating, p1.deckPower(), p2.ID, p2.Rating, p2.deckPower(), p1.Ruleset, p1.bestOf())
+		// Só escolhemos GameRooms com o mesmo catálogo: durante um rolling deploy
+		// as instâncias antigas ficam de fora em vez de recusar os decks.
+		opts := cluster.DiscoveryOptions{Mode: cluster.ModeAnyHealthy, CatalogFingerprint: m.catalogGuard.Fingerprint()}
+		addr := m.serviceCache.Discover("jokenpo-gameroom", opts)
+		if addr == "" {
+			go m.notifyMatchFailed(p1, p2, "GameRoom service not found (no instance with a compatible card catalog)")
+			return true
+		}
+		pending := &PendingMatch{ID: uuid.NewString(), Players: [2]*PlayerInfo{p1, p2}, GameRoomAddr: addr}
+		m.inFlight[pending.ID] = pending
+		// O par e a instância ficam gravados antes do POST /rooms sair.
+		m.persistState()
+		m.startRoomCreation(ctx, pending)
+		return true
 	}
-	go m.sendCallback(trade1.CallbackURL, payload1)
+	return false
+}
 
-	payload2 := map[string]string{
-		"playerId":     trade2.ID,
-		"cardSent":     trade2.OfferCard,
-		"cardReceived": trade1.OfferCard,
-		"partnerId":    trade1.ID,
+// playerRating busca o rating atual do jogador. Roda no handler HTTP, fora do ator;
+// se o Consul falhar, o jogador entra com o rating inicial em vez de ficar de fora.
+func (m *QueueMaster) playerRating(playerID string) float64 {
+	r, err := m.ratings.Get(playerID)
+	if err != nil {
+		log.Printf("[QueueMaster] WARN: Could not read rating of %s, using default: %v", playerID, err)
 	}
-	go m.sendCallback(trade2.CallbackURL, payload2)
+	return r.Rating
 }
 
-func (m *QueueMaster) tryPairingMatches() {
-	if len(m.matchQueue) < 2 { return }
-	p1 := m.matchQueue[0]
-	p2 := m.matchQueue[1]
-	m.matchQueue = m.matchQueue[2:]
-	log.Printf("[QueueMaster] MATCH FOUND! %s vs %s", p1.ID, p2.ID)
-	go m.orchestrateRoomCreation(p1, p2)
+// resumePendingMatches repete a criação das salas que o líder anterior deixou em andamento.
+func (m *QueueMaster) resumePendingMatches(ctx context.Context) {
+	for _, pending := range m.inFlight {
+		log.Printf("[QueueMaster] Resuming room creation of in-flight match %s (%s vs %s) at %s.", pending.ID, pending.Players[0].ID, pending.Players[1].ID, pending.GameRoomAddr)
+		m.startRoomCreation(ctx, pending)
+	}
 }
 
-func (m *QueueMaster) orchestrateRoomCreation(p1, p2 *PlayerInfo) {
-	opts := cluster.DiscoveryOptions{Mode: cluster.ModeAnyHealthy}
-	addr := m.serviceCache.Discover("jokenpo-gameroom", opts)
-	if addr == "" {
-		m.notifyMatchFailed(p1, p2, "GameRoom service not found")
-		return
+// startRoomCreation cria a sala do par em background e avisa o ator quando há um resultado.
+func (m *QueueMaster) startRoomCreation(ctx context.Context, pending *PendingMatch) {
+	m.pairings.Add(1)
+	go func() {
+		defer m.pairings.Done()
+		done, requeue := m.orchestrateRoomCreation(ctx, pending)
+		if !done {
+			return
+		}
+		// Resultado entregue aos jogadores: o par sai do estado persistido.
+		select {
+		case m.requestCh <- roomCreationDone{matchID: pending.ID, requeue: requeue}:
+		case <-ctx.Done():
+		}
+	}()
+}
+
+// requeuePending devolve os jogadores de um par ao início da fila.
+func (m *QueueMaster) requeuePending(pending *PendingMatch) {
+	p1, p2 := pending.Players[0], pending.Players[1]
+	m.matchQueue = removePlayerFromMatchQueue(m.matchQueue, p1.ID)
+	m.matchQueue = removePlayerFromMatchQueue(m.matchQueue, p2.ID)
+	m.matchQueue = append([]*PlayerInfo{p1, p2}, m.matchQueue...)
+	log.Printf("[QueueMaster] Match %s was never created. %s and %s are back at the head of the queue.", pending.ID, p1.ID, p2.ID)
+}
+
+// orchestrateRoomCreation cria a sala do par e avisa os jogadores. Como o POST /rooms
+// é idempotente para o mesmo ID e os mesmos jogadores, respostas ambíguas (rede,
+// 5xx) são repetidas enquanto a instância estiver saudável no Consul; se ela
+// sumiu, a sala não pode estar rodando e o par volta à fila (requeue). Retorna
+// done=false se foi interrompida pela perda da liderança: o par continua InFlight
+// e o próximo líder repete a criação.
+func (m *QueueMaster) orchestrateRoomCreation(ctx context.Context, pending *PendingMatch) (done, requeue bool) {
+	p1, p2 := pending.Players[0], pending.Players[1]
+	backoff := roomCreateRetryMin
+	for {
+		status, roomResp, err := m.postCreateRoom(ctx, pending)
+		if ctx.Err() != nil {
+			log.Printf("[QueueMaster] Leadership lost while creating room %s for %s vs %s. Keeping it in flight.", pending.ID, p1.ID, p2.ID)
+			return false, false
+		}
+		switch {
+		case err == nil && status == http.StatusCreated:
+			payload := MatchCreatedPayload{PlayerIDs: []string{p1.ID, p2.ID}, RoomID: roomResp.RoomID, ServiceAddr: roomResp.ServiceAddr}
+			m.sendCallback(p1.ID, p1.MatchCallbackURL, "MATCH_FOUND", payload)
+			m.sendCallback(p2.ID, p2.MatchCallbackURL, "MATCH_FOUND", payload)
+			return true, false
+		case status == http.StatusPreconditionFailed:
+			log.Printf("[QueueMaster] GameRoom rejected our fencing token: this node is a stale leader. Keeping match %s in flight.", pending.ID)
+			return false, false
+		case status == http.StatusGone:
+			// A sala foi criada, jogada e removida: os jogadores já foram avisados por um líder anterior.
+			log.Printf("[QueueMaster] Room %s was already played. Nothing left to do.", pending.ID)
+			return true, false
+		case status >= 400 && status < 500 && status != http.StatusRequestTimeout && status != http.StatusTooManyRequests:
+			// Recusa definitiva: a sala não foi criada.
+			reason := "Failed to create room"
+			if err != nil {
+				log.Printf("[QueueMaster] %v", err)
+				reason = err.Error()
+			}
+			m.notifyMatchFailed(p1, p2, reason)
+			return true, false
+		}
+
+		healthy, healthErr := cluster.InstanceHealthy(m.consul, "jokenpo-gameroom", pending.GameRoomAddr)
+		if healthErr == nil && !healthy {
+			log.Printf("[QueueMaster] GameRoom %s is gone; room %s cannot be running. Handing the match back.", pending.GameRoomAddr, pending.ID)
+			return true, true
+		}
+		log.Printf("[QueueMaster] WARN: Room %s at %s is unconfirmed (status %d, %v). Retrying in %v.", pending.ID, pending.GameRoomAddr, status, err, backoff)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return false, false
+		}
+		backoff = min(backoff*2, roomCreateRetryMax)
 	}
-	createReq := CreateRoomRequest{PlayerInfos: []*PlayerInfo{p1, p2}}
+}
+
+// postCreateRoom faz um POST /rooms com o ID do par. Retorna o status (0 se não
+// houve resposta) e, para 409 de catálogo, o erro do CatalogGuard.
+func (m *QueueMaster) postCreateRoom(ctx context.Context, pending *PendingMatch) (int, *CreateRoomResponse, error) {
+	p1, p2 := pending.Players[0], pending.Players[1]
+	timing := m.timing.forRuleset(p1.Ruleset)
+	createReq := CreateRoomRequest{RoomID: pending.ID, PlayerInfos: []*PlayerInfo{p1, p2}, Ruleset: p1.Ruleset, BestOf: p1.bestOf(), Timing: &timing}
 	reqBody, _ := json.Marshal(createReq)
-	resp, err := m.httpClient.Post(fmt.Sprintf("http://%s/rooms", addr), "application/json", bytes.NewBuffer(reqBody))
-	if err != nil || resp.StatusCode != http.StatusCreated {
-		m.notifyMatchFailed(p1, p2, "Failed to create room")
-		return
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("http://%s/rooms", pending.GameRoomAddr), bytes.NewBuffer(reqBody))
+	if err != nil {
+		return 0, nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	m.catalogGuard.Stamp(httpReq)
+	if m.elector != nil {
+		cluster.StampFencingToken(httpReq, m.elector.FencingToken())
+	}
+	resp, err := m.httpClient.Do(httpReq)
+	if err != nil {
+		return 0, nil, err
 	}
 	defer resp.Body.Close()
+	if err := m.catalogGuard.CheckResponse(resp); err != nil {
+		return resp.StatusCode, nil, err
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return resp.StatusCode, nil, fmt.Errorf("game room answered %s", resp.Status)
+	}
 	var roomResp CreateRoomResponse
-	json.NewDecoder(resp.Body).Decode(&roomResp)
-	payload := MatchCreatedPayload{ PlayerIDs: []string{p1.ID, p2.ID}, RoomID: roomResp.RoomID, ServiceAddr: roomResp.ServiceAddr }
-	go m.sendCallback(p1.MatchCallbackURL, payload)
-	go m.sendCallback(p2.MatchCallbackURL, payload)
+	if err := json.NewDecoder(resp.Body).Decode(&roomResp); err != nil {
+		return 0, nil, fmt.Errorf("invalid create room response: %w", err)
+	}
+	return resp.StatusCode, &roomResp, nil
 }
 func (m *QueueMaster) notifyMatchFailed(p1, p2 *PlayerInfo, reason string) {
 	pl := MatchFailedPayload{ PlayerIDs: []string{p1.ID, p2.ID}, Reason: reason }
-	go m.sendCallback(p1.MatchCallbackURL, pl)
-	go m.sendCallback(p2.MatchCallbackURL, pl)
+	m.sendCallback(p1.ID, p1.MatchCallbackURL, "MATCH_FAILED", pl)
+	m.sendCallback(p2.ID, p2.MatchCallbackURL, "MATCH_FAILED", pl)
 }
 func removePlayerFromMatchQueue(q []*PlayerInfo, id string) []*PlayerInfo {
 	for i, p := range q { if p.ID == id { return append(q[:i], q[i+1:]...) } }
 	return q
 }
-func removePlayerFromTradeQueue(q []*TradeInfo, id string) []*TradeInfo {
-	for i, t := range q { if t.ID == id { return append(q[:i], q[i+1:]...) } }
-	return q
-}
-func (m *QueueMaster) sendCallback(url string, payload interface{}) {
-	if url == "" { return }
-	data, _ := json.Marshal(payload)
-	m.httpClient.Post(url, "application/json", bytes.NewBuffer(data))
+// sendCallback grava o callback no outbox, que o entrega com repetições e
+// Idempotency-Key. playerID ordena os callbacks do mesmo jogador.
+func (m *QueueMaster) sendCallback(playerID, url, label string, payload interface{}) {
+	if err := m.outbox.Enqueue(playerID, url, label, payload); err != nil {
+		log.Printf("[QueueMaster] WARN: Callback %s for %s not sent: %v", label, playerID, err)
+	}
 }
 //END OF FILE jokenpo/internal/services/queue/service.go
\ No newline at end of file
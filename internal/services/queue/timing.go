@@ -0,0 +1,100 @@
+//START OF FILE jokenpo/internal/services/queue/timing.go
+package queue
+
+import (
+	"jokenpo/internal/game/rules"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Ritmo das salas de cada modo. O padrão vale para todos os rulesets; um modo
+// pode ter o próprio em QUEUE_TIMING_<RULESET> (maiúsculas, '-' vira '_'), por
+// exemplo QUEUE_TIMING_COLOR_ADVANTAGE="round=5,bank=30,delay=2" (segundos). Os
+// campos ausentes ficam com o padrão. O Queue manda o ritmo no CreateRoomRequest.
+const (
+	RoundTimeEnv     = "QUEUE_ROUND_SECONDS"       // Tempo de cada rodada
+	TimeBankEnv      = "QUEUE_TIME_BANK_SECONDS"   // Banco de tempo de cada jogador, por jogo
+	RoundDelayEnv    = "QUEUE_ROUND_DELAY_SECONDS" // Pausa entre o resultado e a próxima rodada
+	TimingModeEnvPfx = "QUEUE_TIMING_"
+)
+
+type TimingConfig struct {
+	Default   rules.Timing
+	ByRuleset map[string]rules.Timing
+}
+
+// LoadTimingConfig lê o ritmo das salas do ambiente; valores ausentes ou inválidos usam o padrão.
+func LoadTimingConfig() TimingConfig {
+	base := rules.DefaultTiming()
+	def := rules.Timing{
+		RoundMs:      envSeconds(RoundTimeEnv, float64(base.RoundMs)/1000).Milliseconds(),
+		TimeBankMs:   envSeconds(TimeBankEnv, float64(base.TimeBankMs)/1000).Milliseconds(),
+		RoundDelayMs: envSeconds(RoundDelayEnv, float64(base.RoundDelayMs)/1000).Milliseconds(),
+	}
+	if err := def.Validate(); err != nil {
+		log.Printf("[QueueMaster] WARN: Invalid room timing (%v), using %s.", err, base)
+		def = base
+	}
+
+	c := TimingConfig{Default: def, ByRuleset: make(map[string]rules.Timing)}
+	for _, name := range rules.Names() {
+		env := TimingModeEnvPfx + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+		raw := os.Getenv(env)
+		if raw == "" {
+			continue
+		}
+		timing, err := parseModeTiming(raw, def)
+		if err == nil {
+			err = timing.Validate()
+		}
+		if err != nil {
+			log.Printf("[QueueMaster] WARN: Invalid %s=%q (%v), using the default timing.", env, raw, err)
+			continue
+		}
+		c.ByRuleset[name] = timing
+	}
+	return c
+}
+
+// forRuleset é o ritmo das salas do modo.
+func (c TimingConfig) forRuleset(name string) rules.Timing {
+	if name == "" {
+		name = rules.DefaultName
+	}
+	if timing, ok := c.ByRuleset[name]; ok {
+		return timing
+	}
+	return c.Default
+}
+
+// parseModeTiming lê "round=5,bank=30,delay=2" (segundos) sobre o ritmo padrão.
+func parseModeTiming(raw string, def rules.Timing) (rules.Timing, error) {
+	timing := def
+	for _, field := range strings.Split(raw, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if !ok {
+			return timing, strconv.ErrSyntax
+		}
+		seconds, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return timing, err
+		}
+		ms := time.Duration(seconds * float64(time.Second)).Milliseconds()
+		switch strings.TrimSpace(key) {
+		case "round":
+			timing.RoundMs = ms
+		case "bank":
+			timing.TimeBankMs = ms
+		case "delay":
+			timing.RoundDelayMs = ms
+		default:
+			return timing, strconv.ErrSyntax
+		}
+	}
+	return timing, nil
+}
+
+//END OF FILE jokenpo/internal/services/queue/timing.go
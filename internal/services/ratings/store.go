@@ -0,0 +1,118 @@
+//START OF FILE jokenpo/internal/services/ratings/store.go
+package ratings
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"jokenpo/internal/game/rating"
+	"jokenpo/internal/services/cluster"
+	"log"
+
+	consul "github.com/hashicorp/consul/api"
+)
+
+// KeyPrefix é onde os ratings ficam no KV do Consul: jokenpo/ratings/<playerId>.
+// O GameRoom grava (ao fim de cada partida); o Queue e o Session só leem.
+const KeyPrefix = "jokenpo/ratings/"
+
+// maxCASAttempts limita as tentativas quando outra escrita muda o rating no meio da atualização.
+const maxCASAttempts = 5
+
+var ErrConsulUnavailable = errors.New("consul client is not available")
+
+// Store lê e grava ratings no Consul. Os dois jogadores de uma partida são
+// atualizados numa única transação com check-and-set, então nenhum resultado se perde.
+type Store struct {
+	manager *cluster.ConsulManager
+}
+
+func NewStore(manager *cluster.ConsulManager) *Store {
+	return &Store{manager: manager}
+}
+
+// Get devolve o rating do jogador, ou o rating inicial se ele nunca jogou.
+func (s *Store) Get(playerID string) (rating.Rating, error) {
+	r, _, err := s.load(playerID)
+	return r, err
+}
+
+// RecordMatch aplica o resultado de uma partida entre p1 e p2. winnerID vazio é empate.
+func (s *Store) RecordMatch(p1, p2, winnerID string) (rating.Rating, rating.Rating, error) {
+	scoreP1 := rating.Draw
+	switch winnerID {
+	case p1:
+		scoreP1 = rating.Win
+	case p2:
+		scoreP1 = rating.Loss
+	}
+
+	for attempt := 1; attempt <= maxCASAttempts; attempt++ {
+		r1, index1, err := s.load(p1)
+		if err != nil {
+			return r1, rating.Rating{}, err
+		}
+		r2, index2, err := s.load(p2)
+		if err != nil {
+			return r1, r2, err
+		}
+		new1, new2 := rating.UpdateMatch(r1, r2, scoreP1)
+
+		ok, err := s.casPair(p1, new1, index1, p2, new2, index2)
+		if err != nil {
+			return r1, r2, err
+		}
+		if ok {
+			return new1, new2, nil
+		}
+		log.Printf("[Ratings] WARN: Concurrent update on %s/%s (attempt %d/%d). Retrying.", p1, p2, attempt, maxCASAttempts)
+	}
+	return rating.Rating{}, rating.Rating{}, fmt.Errorf("could not update ratings of %s and %s: too many concurrent updates", p1, p2)
+}
+
+// load devolve o rating e o ModifyIndex da chave (0 quando ainda não existe;
+// um CAS com índice 0 só grava se a chave continuar inexistente).
+func (s *Store) load(playerID string) (rating.Rating, uint64, error) {
+	client := s.manager.GetClient()
+	if client == nil {
+		return rating.New(), 0, ErrConsulUnavailable
+	}
+	pair, _, err := client.KV().Get(KeyPrefix+playerID, nil)
+	if err != nil {
+		return rating.New(), 0, fmt.Errorf("failed to read rating of %s: %w", playerID, err)
+	}
+	if pair == nil {
+		return rating.New(), 0, nil
+	}
+	var r rating.Rating
+	if err := json.Unmarshal(pair.Value, &r); err != nil {
+		return rating.New(), 0, fmt.Errorf("corrupted rating of %s: %w", playerID, err)
+	}
+	return r, pair.ModifyIndex, nil
+}
+
+func (s *Store) casPair(p1 string, r1 rating.Rating, index1 uint64, p2 string, r2 rating.Rating, index2 uint64) (bool, error) {
+	client := s.manager.GetClient()
+	if client == nil {
+		return false, ErrConsulUnavailable
+	}
+	data1, err := json.Marshal(r1)
+	if err != nil {
+		return false, err
+	}
+	data2, err := json.Marshal(r2)
+	if err != nil {
+		return false, err
+	}
+	ops := consul.TxnOps{
+		{KV: &consul.KVTxnOp{Verb: consul.KVCAS, Key: KeyPrefix + p1, Value: data1, Index: index1}},
+		{KV: &consul.KVTxnOp{Verb: consul.KVCAS, Key: KeyPrefix + p2, Value: data2, Index: index2}},
+	}
+	ok, _, _, err := client.Txn().Txn(ops, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to write ratings: %w", err)
+	}
+	return ok, nil
+}
+
+//END OF FILE jokenpo/internal/services/ratings/store.go
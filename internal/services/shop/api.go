@@ -12,6 +12,7 @@ import (
 type PurchaseRequest struct {
 	PlayerID string `json:"playerId"` // Campo Obrigatório
 	Quantity uint64 `json:"quantity"`
+	Template string `json:"template,omitempty"` // Opcional: vazio = template padrão
 }
 
 type PurchaseResponse struct {
@@ -44,7 +45,7 @@ func CreateShopHandler(shopService *ShopService, elector *cluster.LeaderElector)
 
 		// 3. EXECUTA A LÓGICA DE NEGÓCIO (EM MEMÓRIA)
 		// Passamos o PlayerID para o serviço para registro na blockchain
-		cards, err := shopService.Purchase(req.PlayerID, req.Quantity)
+		cards, err := shopService.Purchase(req.PlayerID, req.Quantity, req.Template)
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			json.NewEncoder(w).Encode(PurchaseResponse{Error: err.Error()})
@@ -69,4 +70,23 @@ func CreateShopHandler(shopService *ShopService, elector *cluster.LeaderElector)
 		json.NewEncoder(w).Encode(PurchaseResponse{Cards: cardKeys})
 	}
 }
+
+// CreateVerifyPacksHandler expõe a verificação dos pacotes registrados no ledger.
+// Qualquer nó responde, pois a operação só lê a blockchain.
+func CreateVerifyPacksHandler(shopService *ShopService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		results, err := shopService.VerifyLedgerPacks()
+		w.Header().Set("Content-Type", "application/json")
+		if err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		json.NewEncoder(w).Encode(results)
+	}
+}
 //END OF FILE jokenpo/internal/services/shop/api.go
\ No newline at end of file
@@ -0,0 +1,151 @@
+//START OF FILE jokenpo/internal/services/shop/pack.go
+package shop
+
+import (
+	"encoding/json"
+	"fmt"
+	"jokenpo/internal/game/card"
+	"os"
+)
+
+// PackTemplatesPathEnv aponta para um arquivo JSON com os templates de pacote.
+// Sem ele, o Shop usa DefaultPackTemplates.
+const PackTemplatesPathEnv = "SHOP_PACK_TEMPLATES_PATH"
+
+// PackSlot define quantas cartas saem numa faixa de raridade.
+// A raridade de cada carta é sorteada pelos pesos do template, restritos à faixa.
+type PackSlot struct {
+	Count     int    `json:"count"`
+	MinRarity string `json:"minRarity"`
+	MaxRarity string `json:"maxRarity"`
+}
+
+// PityRule garante uma carta de raridade MinRarity (ou melhor) depois de
+// 'After' pacotes seguidos sem nenhuma. After = 0 desliga a regra.
+type PityRule struct {
+	After     int    `json:"after"`
+	MinRarity string `json:"minRarity"`
+}
+
+// PackTemplate descreve a composição de um pacote.
+type PackTemplate struct {
+	Name          string         `json:"name"`
+	Slots         []PackSlot     `json:"slots"`
+	RarityWeights map[string]int `json:"rarityWeights"`
+	Pity          PityRule       `json:"pity"`
+}
+
+// DefaultPackTemplates: 2 comuns + 1 incomum-ou-melhor, com um raro garantido
+// a cada 5 pacotes sem raro.
+func DefaultPackTemplates() []PackTemplate {
+	return []PackTemplate{
+		{
+			Name: "standard",
+			Slots: []PackSlot{
+				{Count: 2, MinRarity: card.RarityCommon, MaxRarity: card.RarityCommon},
+				{Count: 1, MinRarity: card.RarityUncommon, MaxRarity: card.RarityLegendary},
+			},
+			RarityWeights: map[string]int{
+				card.RarityCommon:    60,
+				card.RarityUncommon:  28,
+				card.RarityRare:      9,
+				card.RarityLegendary: 3,
+			},
+			Pity: PityRule{After: 5, MinRarity: card.RarityRare},
+		},
+	}
+}
+
+// LoadPackTemplates lê os templates do arquivo indicado por SHOP_PACK_TEMPLATES_PATH
+// (ou usa os padrões) e os valida contra o catálogo global já carregado.
+// O primeiro template da lista é o padrão das compras.
+func LoadPackTemplates() ([]PackTemplate, error) {
+	templates := DefaultPackTemplates()
+	if path := os.Getenv(PackTemplatesPathEnv); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read pack templates '%s': %w", path, err)
+		}
+		templates = nil
+		if err := json.Unmarshal(data, &templates); err != nil {
+			return nil, fmt.Errorf("failed to parse pack templates '%s': %w", path, err)
+		}
+	}
+	if len(templates) == 0 {
+		return nil, fmt.Errorf("at least one pack template is required")
+	}
+
+	seen := make(map[string]struct{}, len(templates))
+	for i := range templates {
+		if err := templates[i].validate(); err != nil {
+			return nil, fmt.Errorf("pack template %d ('%s'): %w", i, templates[i].Name, err)
+		}
+		if _, dup := seen[templates[i].Name]; dup {
+			return nil, fmt.Errorf("duplicated pack template '%s'", templates[i].Name)
+		}
+		seen[templates[i].Name] = struct{}{}
+	}
+	return templates, nil
+}
+
+func (t *PackTemplate) validate() error {
+	if t.Name == "" {
+		return fmt.Errorf("template name is required")
+	}
+	if len(t.Slots) == 0 {
+		return fmt.Errorf("template has no slots")
+	}
+	for rarity, weight := range t.RarityWeights {
+		if card.RarityRank(rarity) < 0 {
+			return fmt.Errorf("unknown rarity '%s' in weights", rarity)
+		}
+		if weight < 0 {
+			return fmt.Errorf("negative weight for rarity '%s'", rarity)
+		}
+	}
+	for i, slot := range t.Slots {
+		if slot.Count < 1 {
+			return fmt.Errorf("slot %d: count must be >= 1", i)
+		}
+		minRank, maxRank := card.RarityRank(slot.MinRarity), card.RarityRank(slot.MaxRarity)
+		if minRank < 0 || maxRank < 0 || minRank > maxRank {
+			return fmt.Errorf("slot %d: invalid rarity range '%s'..'%s'", i, slot.MinRarity, slot.MaxRarity)
+		}
+		if len(cardsInRarityRange(minRank, maxRank)) == 0 {
+			return fmt.Errorf("slot %d: catalog has no cards between '%s' and '%s'", i, slot.MinRarity, slot.MaxRarity)
+		}
+	}
+	if t.Pity.After > 0 {
+		pityRank := card.RarityRank(t.Pity.MinRarity)
+		if pityRank < 0 {
+			return fmt.Errorf("pity: unknown rarity '%s'", t.Pity.MinRarity)
+		}
+		if t.pitySlot() < 0 {
+			return fmt.Errorf("pity: no slot can hold rarity '%s'", t.Pity.MinRarity)
+		}
+	}
+	return nil
+}
+
+// Size é o número de cartas de um pacote deste template.
+func (t *PackTemplate) Size() int {
+	size := 0
+	for _, slot := range t.Slots {
+		size += slot.Count
+	}
+	return size
+}
+
+// pitySlot retorna o índice do último slot capaz de conter a raridade da pity.
+// É esse slot que recebe a garantia quando a pity estoura.
+func (t *PackTemplate) pitySlot() int {
+	pityRank := card.RarityRank(t.Pity.MinRarity)
+	for i := len(t.Slots) - 1; i >= 0; i-- {
+		if card.RarityRank(t.Slots[i].MaxRarity) >= pityRank {
+			return i
+		}
+	}
+	return -1
+}
+
+//END OF FILE jokenpo/internal/services/shop/pack.go
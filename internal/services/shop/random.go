@@ -2,64 +2,72 @@
 package shop
 
 import (
+	"fmt"
 	"jokenpo/internal/game/card"
 	"math/rand/v2"
 )
 
-func generateRandomCardTypo(r *rand.Rand) string {
-	randomIndexType := r.IntN(len(card.CardTypes))
-	randomType := card.CardTypes[randomIndexType]
-	return randomType
-}
-
-func generateRandomCardColor(r *rand.Rand) string {
-	randomIndexColor := r.IntN(len(card.CardColors))
-	randomColor := card.CardColors[randomIndexColor]
-	return randomColor
+// generateRandomCard sorteia uma raridade dentro da faixa [minRank, maxRank]
+// usando os pesos do template e depois escolhe, de forma uniforme, uma das
+// cartas do catálogo com aquela raridade.
+func generateRandomCard(r *rand.Rand, weights map[string]int, minRank, maxRank int) (*card.Card, error) {
+	rarity := generateRandomRarity(r, weights, minRank, maxRank)
+	candidates := cardsInRarityRange(card.RarityRank(rarity), card.RarityRank(rarity))
+	if len(candidates) == 0 {
+		// Raridade sem cartas no catálogo: cai para qualquer carta da faixa.
+		candidates = cardsInRarityRange(minRank, maxRank)
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("card catalog has no cards in the requested rarity range")
+	}
+	return candidates[r.IntN(len(candidates))], nil
 }
 
-// WeightedValue (struct inalterada)
-type weightedValue struct {
-	Value  uint8
-	Weight int
-}
+// generateRandomRarity faz o sorteio ponderado entre as raridades da faixa.
+// Se nenhuma raridade da faixa tiver peso, sorteia de forma uniforme.
+func generateRandomRarity(r *rand.Rand, weights map[string]int, minRank, maxRank int) string {
+	rarities := raritiesInRange(minRank, maxRank)
+	totalWeight := 0
+	for _, rarity := range rarities {
+		totalWeight += weights[rarity]
+	}
+	if totalWeight == 0 {
+		return rarities[r.IntN(len(rarities))]
+	}
 
-// valueDistribution agora favorece valores altos.
-// A soma dos pesos ainda é 100.
-var valueDistribution = []weightedValue{
-	{Value: 1, Weight: 14},   
-	{Value: 2, Weight: 14},   
-	{Value: 3, Weight: 14},   
-	{Value: 4, Weight: 14},   
-	{Value: 5, Weight: 12},   
-	{Value: 6, Weight: 10},  
-	{Value: 7, Weight: 7},  
-	{Value: 8, Weight: 6},  
-	{Value: 9, Weight: 5},  
-	{Value: 10, Weight: 4}, 
+	roll := r.IntN(totalWeight)
+	for _, rarity := range rarities {
+		roll -= weights[rarity]
+		if roll < 0 {
+			return rarity
+		}
+	}
+	return rarities[len(rarities)-1]
 }
 
-// totalWeight (variável inalterada)
-var totalWeight int
+// raritiesOrdered lista as raridades da mais comum para a mais rara.
+var raritiesOrdered = []string{card.RarityCommon, card.RarityUncommon, card.RarityRare, card.RarityLegendary}
 
-// A função init() recalcula o peso total automaticamente.
-// NENHUMA MUDANÇA É NECESSÁRIA AQUI.
-func init() {
-	totalWeight = 0
-	for _, wv := range valueDistribution {
-		totalWeight += wv.Weight
+func raritiesInRange(minRank, maxRank int) []string {
+	out := make([]string, 0, len(raritiesOrdered))
+	for _, rarity := range raritiesOrdered {
+		rank := card.RarityRank(rarity)
+		if rank >= minRank && rank <= maxRank {
+			out = append(out, rarity)
+		}
 	}
+	return out
 }
 
-func generateRandomCardValue(r *rand.Rand) uint8 {
-	roll := r.IntN(totalWeight)
-	for _, wv := range valueDistribution {
-		roll -= wv.Weight
-		if roll < 0 {
-			return wv.Value
+func cardsInRarityRange(minRank, maxRank int) []*card.Card {
+	out := make([]*card.Card, 0)
+	for _, c := range card.AllCards() {
+		rank := card.RarityRank(c.Rarity())
+		if rank >= minRank && rank <= maxRank {
+			out = append(out, c)
 		}
 	}
-	return valueDistribution[len(valueDistribution)-1].Value
+	return out
 }
 
-//END OF FILE jokenpo/internal/services/shop/random.go
\ No newline at end of file
+//END OF FILE jokenpo/internal/services/shop/random.go
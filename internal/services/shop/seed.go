@@ -0,0 +1,135 @@
+//START OF FILE jokenpo/internal/services/shop/seed.go
+package shop
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"jokenpo/internal/game/card"
+	"jokenpo/internal/services/blockchain"
+	mrand "math/rand/v2"
+	"strings"
+)
+
+// Seed é a semente secreta usada para derivar os pacotes.
+// O Shop publica sha256(seed) antes de usá-la e só a revela no AuditPackOpened,
+// junto com o compromisso da próxima semente (commit-reveal encadeado).
+type Seed [32]byte
+
+func newSeed() (Seed, error) {
+	var s Seed
+	if _, err := rand.Read(s[:]); err != nil {
+		return Seed{}, fmt.Errorf("failed to generate pack seed: %w", err)
+	}
+	return s, nil
+}
+
+// Commitment é o valor publicado na blockchain antes da semente ser usada.
+func (s Seed) Commitment() [32]byte {
+	return sha256.Sum256(s[:])
+}
+
+func (s Seed) String() string { return hex.EncodeToString(s[:]) }
+
+func parseSeed(h string) (Seed, error) {
+	var s Seed
+	raw, err := hex.DecodeString(h)
+	if err != nil || len(raw) != len(s) {
+		return Seed{}, fmt.Errorf("invalid pack seed '%s'", h)
+	}
+	copy(s[:], raw)
+	return s, nil
+}
+
+// PackDerivation são as entradas que determinam as cartas de uma compra.
+// Com elas (e o mesmo catálogo/template) qualquer um recalcula os pacotes.
+type PackDerivation struct {
+	Seed               Seed
+	PackageCounter     uint64 // Contador global no primeiro pacote da compra
+	Pity               int    // Pity do jogador antes da compra
+	Template           string
+	Catalog            string // Fingerprint do catálogo usado
+	NextSeedCommitment [32]byte
+}
+
+// toLedger converte para o formato registrado no evento AuditPackOpened.
+func (d PackDerivation) toLedger() blockchain.PackDerivation {
+	return blockchain.PackDerivation{
+		Seed:               d.Seed,
+		PackageCounter:     d.PackageCounter,
+		Pity:               uint64(d.Pity),
+		Template:           d.Template,
+		Catalog:            d.Catalog,
+		NextSeedCommitment: d.NextSeedCommitment,
+	}
+}
+
+// packRNG deriva o gerador de um pacote a partir de (seed, contador, jogador).
+// O contador tem tamanho fixo, então a concatenação não é ambígua.
+func packRNG(seed Seed, packageCounter uint64, playerID string) *mrand.Rand {
+	h := sha256.New()
+	h.Write(seed[:])
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], packageCounter)
+	h.Write(counter[:])
+	h.Write([]byte(playerID))
+
+	var chachaSeed [32]byte
+	copy(chachaSeed[:], h.Sum(nil))
+	return mrand.New(mrand.NewChaCha8(chachaSeed))
+}
+
+// derivePacks gera 'quantity' pacotes seguidos, cada um com o seu próprio gerador.
+// É o mesmo caminho usado na compra e na verificação, por isso não depende do Shop.
+// Retorna as cartas e o contador de pity depois do último pacote.
+func derivePacks(tmpl *PackTemplate, seed Seed, firstPackage uint64, playerID string, pity int, quantity uint64) ([]*card.Card, int, error) {
+	allCards := make([]*card.Card, 0, int(quantity)*tmpl.Size())
+	for i := uint64(0); i < quantity; i++ {
+		pack, err := openPack(packRNG(seed, firstPackage+i, playerID), tmpl, pity)
+		if err != nil {
+			return nil, pity, fmt.Errorf("failed to generate package %d: %w", i+1, err)
+		}
+		pity = nextPity(tmpl, pack, pity)
+		allCards = append(allCards, pack...)
+	}
+	return allCards, pity, nil
+}
+
+// VerifyPackAudit recalcula as cartas de um evento AuditPackOpened e confere se
+// batem com as registradas. Os tokens "cardKey#uuid" são comparados pela chave.
+// Precisa do mesmo catálogo (fingerprint) e dos mesmos templates usados na compra.
+func VerifyPackAudit(templates []PackTemplate, audit blockchain.PackAudit) error {
+	d := audit.Derivation
+	if d.Catalog != card.Fingerprint() {
+		return fmt.Errorf("catalog mismatch: pack used %s, local catalog is %s", d.Catalog, card.Fingerprint())
+	}
+	var tmpl *PackTemplate
+	for i := range templates {
+		if templates[i].Name == d.Template {
+			tmpl = &templates[i]
+		}
+	}
+	if tmpl == nil {
+		return fmt.Errorf("unknown pack template '%s'", d.Template)
+	}
+	if len(audit.CardIDs) == 0 || len(audit.CardIDs)%tmpl.Size() != 0 {
+		return fmt.Errorf("%d cards do not fit template '%s' (%d per pack)", len(audit.CardIDs), tmpl.Name, tmpl.Size())
+	}
+
+	quantity := uint64(len(audit.CardIDs) / tmpl.Size())
+	expected, _, err := derivePacks(tmpl, Seed(d.Seed), d.PackageCounter, audit.PlayerID, int(d.Pity), quantity)
+	if err != nil {
+		return err
+	}
+	for i, token := range audit.CardIDs {
+		key, _, _ := strings.Cut(token, "#")
+		if key != expected[i].Key() {
+			return fmt.Errorf("card %d: ledger has %s, derivation gives %s", i, key, expected[i].Key())
+		}
+	}
+	return nil
+}
+
+//END OF FILE jokenpo/internal/services/shop/seed.go
@@ -20,6 +20,7 @@ type actorMessage interface{ isActorMessage() }
 type purchaseRequest struct {
 	playerID string
 	quantity uint64
+	template string
 	reply    chan purchaseResponse
 }
 type purchaseResponse struct {
@@ -29,6 +30,15 @@ type purchaseResponse struct {
 
 func (purchaseRequest) isActorMessage() {}
 
+// verifyPacksRequest recalcula todos os pacotes registrados no ledger.
+type verifyPacksRequest struct{ reply chan verifyPacksResponse }
+type verifyPacksResponse struct {
+	results []PackVerification
+	err     error
+}
+
+func (verifyPacksRequest) isActorMessage() {}
+
 type healthCheckRequest struct{ reply chan error }
 
 func (healthCheckRequest) isActorMessage() {}
@@ -51,7 +61,7 @@ type ShopService struct {
 
 // NewShopService inicializa o serviço.
 // Aguarda o endereço do contrato no Consul (criado pelo Deployer).
-func NewShopService(manager *cluster.ConsulManager) *ShopService {
+func NewShopService(manager *cluster.ConsulManager, templates []PackTemplate) *ShopService {
 	var bcClient *blockchain.BlockchainClient
 	var contractAddr string
 
@@ -88,7 +98,7 @@ func NewShopService(manager *cluster.ConsulManager) *ShopService {
 	}
 
 	s := &ShopService{
-		shop:          NewShop(),
+		shop:          NewShop(templates),
 		requestCh:     make(chan actorMessage),
 		blockchain:    bcClient,
 		consulManager: manager,
@@ -99,30 +109,33 @@ func NewShopService(manager *cluster.ConsulManager) *ShopService {
 }
 
 func (s *ShopService) run() {
-	err := card.InitGlobalCatalog()
-	if err != nil {
-		log.Fatalf("Error catalog: %s", err)
-	}
 	for msg := range s.requestCh {
 		switch req := msg.(type) {
 		case purchaseRequest:
-			// 1. Tenta comprar localmente (Gera cartas e incrementa contador state.PackageCount)
-			cards, err := s.shop.purchasePackage(req.quantity)
+			// 0. Garante que a semente que vamos usar já foi comprometida na blockchain.
+			if s.blockchain != nil {
+				if err := s.ensureSeedCommitted(); err != nil {
+					log.Printf("SHOP CRÍTICO: Falha ao publicar o compromisso da semente (%v).", err)
+					req.reply <- purchaseResponse{err: fmt.Errorf("falha na auditoria blockchain: %v. Compra cancelada", err)}
+					continue
+				}
+			}
+
+			// 1. Tenta comprar localmente (Gera cartas, incrementa state.PackageCount,
+			// atualiza a pity do jogador e troca a semente). Guardamos o estado anterior para o rollback.
+			previousState := s.shop.GetState()
+			cards, derivation, err := s.shop.purchasePackage(req.playerID, req.quantity, req.template)
 
 			// 2. Se sucesso local, tenta registrar na Blockchain (Síncrono)
 			if err == nil && s.blockchain != nil {
-				if mintErr := s.mintOnBlockchain(req.playerID, cards); mintErr != nil {
+				if mintErr := s.mintOnBlockchain(req.playerID, cards, derivation); mintErr != nil {
 					log.Printf("SHOP CRÍTICO: Blockchain rejeitou transação (%v). Executando Rollback.", mintErr)
 
 					// --- ROLLBACK LÓGICO ---
-					// Como purchasePackage() já incrementou o contador, precisamos decrementar
-					// para manter a consistência entre Estado Local e Blockchain.
-					currentState := s.shop.GetState()
-					if currentState.PackageCount >= req.quantity {
-						currentState.PackageCount -= req.quantity
-						s.shop.SetState(currentState) // Restaura estado anterior
-						log.Printf("SHOP ROLLBACK: PackageCount revertido para %d", currentState.PackageCount)
-					}
+					// Como purchasePackage() já alterou o contador, a pity e a semente, restauramos o
+					// estado anterior para manter a consistência entre Estado Local e Blockchain.
+					s.shop.SetState(previousState)
+					log.Printf("SHOP ROLLBACK: PackageCount revertido para %d", previousState.PackageCount)
 
 					// Invalida a resposta para o usuário
 					cards = nil
@@ -137,6 +150,10 @@ func (s *ShopService) run() {
 
 			req.reply <- purchaseResponse{cards: cards, err: err}
 
+		case verifyPacksRequest:
+			results, err := s.verifyLedgerPacks()
+			req.reply <- verifyPacksResponse{results: results, err: err}
+
 		case healthCheckRequest:
 			req.reply <- nil
 		case setStateRequest:
@@ -147,8 +164,27 @@ func (s *ShopService) run() {
 	}
 }
 
-// mintOnBlockchain agora retorna erro para permitir controle de fluxo
-func (s *ShopService) mintOnBlockchain(playerID string, cards []*card.Card) error {
+// ensureSeedCommitted publica o compromisso da semente atual se o contrato ainda
+// não o conhece (primeira compra, estado restaurado sem blockchain, contrato novo...).
+func (s *ShopService) ensureSeedCommitted() error {
+	seed, err := s.shop.currentSeed()
+	if err != nil {
+		return err
+	}
+	onChain, err := s.blockchain.PackSeedCommitment()
+	if err != nil {
+		return err
+	}
+	if onChain == seed.Commitment() {
+		return nil
+	}
+	log.Println("SHOP: Compromisso da semente não encontrado no contrato. Publicando um novo.")
+	return s.blockchain.CommitPackSeed(seed.Commitment())
+}
+
+// mintOnBlockchain agora retorna erro para permitir controle de fluxo.
+// A derivação revela a semente usada e compromete a próxima.
+func (s *ShopService) mintOnBlockchain(playerID string, cards []*card.Card, derivation PackDerivation) error {
 	uniqueTokens := make([]string, len(cards))
 	for i, c := range cards {
 		// Gera um UUID para cada carta
@@ -156,7 +192,7 @@ func (s *ShopService) mintOnBlockchain(playerID string, cards []*card.Card) erro
 	}
 
 	// Chama a blockchain e espera a mineração (WaitMined já está no client.go)
-	if err := s.blockchain.LogPack(playerID, uniqueTokens); err != nil {
+	if err := s.blockchain.LogPack(playerID, uniqueTokens, derivation.toLedger()); err != nil {
 		return err
 	}
 
@@ -164,16 +200,60 @@ func (s *ShopService) mintOnBlockchain(playerID string, cards []*card.Card) erro
 	return nil
 }
 
-func (s *ShopService) Purchase(playerID string, quantity uint64) ([]*card.Card, error) {
+// Purchase abre 'quantity' pacotes do template indicado ("" = template padrão).
+func (s *ShopService) Purchase(playerID string, quantity uint64, template string) ([]*card.Card, error) {
 	if !s.isLeader.Load() {
 		return nil, errors.New("this node is not the leader")
 	}
 	reply := make(chan purchaseResponse)
-	s.requestCh <- purchaseRequest{playerID: playerID, quantity: quantity, reply: reply}
+	s.requestCh <- purchaseRequest{playerID: playerID, quantity: quantity, template: template, reply: reply}
 	resp := <-reply
 	return resp.cards, resp.err
 }
 
+// PackVerification é o resultado da verificação de um evento AuditPackOpened.
+type PackVerification struct {
+	PlayerID       string `json:"playerId"`
+	PackageCounter uint64 `json:"packageCounter"`
+	Cards          int    `json:"cards"`
+	Valid          bool   `json:"valid"`
+	Error          string `json:"error,omitempty"`
+}
+
+func (s *ShopService) verifyLedgerPacks() ([]PackVerification, error) {
+	if s.blockchain == nil {
+		return nil, errors.New("blockchain is not available")
+	}
+	audits, err := s.blockchain.PackAudits()
+	if err != nil {
+		return nil, err
+	}
+	results := make([]PackVerification, 0, len(audits))
+	for _, audit := range audits {
+		res := PackVerification{
+			PlayerID:       audit.PlayerID,
+			PackageCounter: audit.Derivation.PackageCounter,
+			Cards:          len(audit.CardIDs),
+			Valid:          true,
+		}
+		if err := VerifyPackAudit(s.shop.templates, audit); err != nil {
+			res.Valid = false
+			res.Error = err.Error()
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// VerifyLedgerPacks recalcula, a partir do ledger, as cartas de todos os pacotes
+// já abertos. Não altera estado, então pode rodar em qualquer nó (líder ou não).
+func (s *ShopService) VerifyLedgerPacks() ([]PackVerification, error) {
+	reply := make(chan verifyPacksResponse)
+	s.requestCh <- verifyPacksRequest{reply: reply}
+	resp := <-reply
+	return resp.results, resp.err
+}
+
 func (s *ShopService) CheckHealth() error {
 	reply := make(chan error)
 	s.requestCh <- healthCheckRequest{reply: reply}
@@ -6,7 +6,6 @@ import (
 	"jokenpo/internal/game/card"
 	"math"
 	"math/rand/v2"
-	"time"
 )
 
 // --- MUDANÇA ---
@@ -14,68 +13,180 @@ import (
 // Os campos devem ser exportados (maiúsculos) para serem serializados em JSON.
 type State struct {
 	PackageCount uint64 `json:"package_count"`
+	// PityCounters guarda, por jogador e por template, quantos pacotes seguidos
+	// foram abertos sem uma carta da raridade da pity. Chave: "playerID|template".
+	PityCounters map[string]int `json:"pity_counters,omitempty"`
+	// Seed é a semente (hex) do próximo pacote. Seu compromisso já foi (ou será)
+	// publicado na blockchain; ela só é revelada quando for usada.
+	Seed string `json:"seed,omitempty"`
+}
+
+// clone devolve uma cópia independente do estado (o mapa não é compartilhado).
+func (st State) clone() State {
+	out := State{PackageCount: st.PackageCount, Seed: st.Seed}
+	if st.PityCounters != nil {
+		out.PityCounters = make(map[string]int, len(st.PityCounters))
+		for k, v := range st.PityCounters {
+			out.PityCounters[k] = v
+		}
+	}
+	return out
+}
+
+func pityKey(playerID, template string) string {
+	return playerID + "|" + template
 }
 
 type Shop struct {
-	state State // Usamos a struct de estado em vez de um campo simples
-	rng   *rand.Rand
+	state     State // Usamos a struct de estado em vez de um campo simples
+	templates []PackTemplate
 }
 
-func NewShop() *Shop {
-	seed := uint64(time.Now().UnixNano())
+// NewShop não tem mais um gerador próprio: cada pacote é derivado da semente
+// do estado (ver seed.go), para que o resultado possa ser auditado.
+func NewShop(templates []PackTemplate) *Shop {
 	return &Shop{
-		state: State{PackageCount: 0}, // Inicializa a struct de estado
-		rng:   rand.New(rand.NewPCG(seed, 0)),
+		state:     State{PackageCount: 0, PityCounters: make(map[string]int)}, // Inicializa a struct de estado
+		templates: templates,
 	}
 }
 
 // --- MUDANÇA ---
 // GetState retorna uma cópia do estado atual.
 func (s *Shop) GetState() State {
-	return s.state
+	return s.state.clone()
 }
 
 // SetState substitui completamente o estado do Shop.
 // Usado pelo ator quando um nó se torna líder para restaurar o estado.
 func (s *Shop) SetState(newState State) {
-	s.state = newState
+	s.state = newState.clone()
+	if s.state.PityCounters == nil {
+		s.state.PityCounters = make(map[string]int)
+	}
+}
+
+// currentSeed devolve a semente do próximo pacote, criando uma se o estado
+// ainda não tiver (primeira execução ou estado antigo).
+func (s *Shop) currentSeed() (Seed, error) {
+	if s.state.Seed != "" {
+		if seed, err := parseSeed(s.state.Seed); err == nil {
+			return seed, nil
+		}
+	}
+	seed, err := newSeed()
+	if err != nil {
+		return Seed{}, err
+	}
+	s.state.Seed = seed.String()
+	return seed, nil
+}
+
+// template busca um template pelo nome. Nome vazio = primeiro template (padrão).
+func (s *Shop) template(name string) (*PackTemplate, error) {
+	if name == "" {
+		return &s.templates[0], nil
+	}
+	for i := range s.templates {
+		if s.templates[i].Name == name {
+			return &s.templates[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown pack template '%s'", name)
 }
 
 const maxPurchases = math.MaxUint64
-const packageSize = 3
 
-func (s *Shop) purchasePackage(quantity uint64) ([]*card.Card, error) {
+// purchasePackage deriva os pacotes da semente atual e já troca a semente pela
+// próxima. O PackDerivation devolvido é o que vai para o ledger.
+func (s *Shop) purchasePackage(playerID string, quantity uint64, templateName string) ([]*card.Card, PackDerivation, error) {
 	if quantity == 0 {
-		return nil, fmt.Errorf("invalid quantity: must be greater than zero")
+		return nil, PackDerivation{}, fmt.Errorf("invalid quantity: must be greater than zero")
 	}
 
 	// --- MUDANÇA ---
 	// Usa o campo dentro da struct de estado
 	if s.state.PackageCount+quantity >= maxPurchases {
-		return nil, fmt.Errorf("cannot process purchase: maximum purchase limit reached")
+		return nil, PackDerivation{}, fmt.Errorf("cannot process purchase: maximum purchase limit reached")
 	}
 
-	totalCards := int(quantity) * packageSize
-	allCards := make([]*card.Card, 0, totalCards)
+	tmpl, err := s.template(templateName)
+	if err != nil {
+		return nil, PackDerivation{}, err
+	}
+	seed, err := s.currentSeed()
+	if err != nil {
+		return nil, PackDerivation{}, err
+	}
+	next, err := newSeed()
+	if err != nil {
+		return nil, PackDerivation{}, err
+	}
 
-	for i := uint64(0); i < quantity; i++ {
-		for j := 0; j < packageSize; j++ {
-			typo := generateRandomCardTypo(s.rng)
-			value := generateRandomCardValue(s.rng)
-			color := generateRandomCardColor(s.rng)
-			key := card.CardKey(typo, value, color)
+	key := pityKey(playerID, tmpl.Name)
+	derivation := PackDerivation{
+		Seed:               seed,
+		PackageCounter:     s.state.PackageCount,
+		Pity:               s.state.PityCounters[key],
+		Template:           tmpl.Name,
+		Catalog:            card.Fingerprint(),
+		NextSeedCommitment: next.Commitment(),
+	}
+	allCards, pity, err := derivePacks(tmpl, seed, derivation.PackageCounter, playerID, derivation.Pity, quantity)
+	if err != nil {
+		return nil, PackDerivation{}, err
+	}
+
+	// --- MUDANÇA ---
+	// Atualiza o campo dentro da struct de estado
+	s.state.PackageCount += quantity
+	s.state.Seed = next.String()
+	if tmpl.Pity.After > 0 {
+		s.state.PityCounters[key] = pity
+	}
+	return allCards, derivation, nil
+}
+
+// openPack gera um pacote seguindo os slots do template. Quando o contador de pity
+// indica que este é o N-ésimo pacote seguido sem a raridade garantida, a primeira
+// carta do slot da pity é sorteada já a partir dessa raridade.
+func openPack(r *rand.Rand, tmpl *PackTemplate, pity int) ([]*card.Card, error) {
+	forcePity := tmpl.Pity.After > 0 && pity+1 >= tmpl.Pity.After
+	pitySlot := -1
+	if forcePity {
+		pitySlot = tmpl.pitySlot()
+	}
 
-			c, err := card.GetCard(key)
+	pack := make([]*card.Card, 0, tmpl.Size())
+	for slotIdx, slot := range tmpl.Slots {
+		minRank, maxRank := card.RarityRank(slot.MinRarity), card.RarityRank(slot.MaxRarity)
+		for j := 0; j < slot.Count; j++ {
+			cardMin := minRank
+			if slotIdx == pitySlot && j == 0 {
+				cardMin = max(minRank, card.RarityRank(tmpl.Pity.MinRarity))
+			}
+			c, err := generateRandomCard(r, tmpl.RarityWeights, cardMin, maxRank)
 			if err != nil {
-				return nil, fmt.Errorf("failed to generate valid card for package %d, card %d: %w", i+1, j+1, err)
+				return nil, fmt.Errorf("slot %d, card %d: %w", slotIdx+1, j+1, err)
 			}
-			allCards = append(allCards, c)
+			pack = append(pack, c)
 		}
 	}
+	return pack, nil
+}
 
-	// --- MUDANÇA ---
-	// Atualiza o campo dentro da struct de estado
-	s.state.PackageCount += quantity
-	return allCards, nil
+// nextPity zera o contador se o pacote trouxe a raridade garantida, ou o incrementa.
+func nextPity(tmpl *PackTemplate, pack []*card.Card, pity int) int {
+	if tmpl.Pity.After <= 0 {
+		return 0
+	}
+	pityRank := card.RarityRank(tmpl.Pity.MinRarity)
+	for _, c := range pack {
+		if card.RarityRank(c.Rarity()) >= pityRank {
+			return 0
+		}
+	}
+	return pity + 1
 }
-//END OF FILE jokenpo/internal/services/shop/shop.go
\ No newline at end of file
+
+//END OF FILE jokenpo/internal/services/shop/shop.go
@@ -0,0 +1,216 @@
+//START OF FILE jokenpo/internal/session/account/account.go
+package account
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DataDirEnv aponta para o diretório onde as contas são gravadas.
+// Todas as instâncias do Session devem enxergar o mesmo diretório (volume compartilhado).
+const DataDirEnv = "SESSION_DATA_DIR"
+
+const defaultDataDir = "./data/session"
+
+const (
+	minUsernameLen   = 3
+	maxUsernameLen   = 32
+	minPasswordLen   = 4
+	pbkdf2Iterations = 100_000
+)
+
+var (
+	ErrAccountExists      = errors.New("username is already taken")
+	ErrInvalidCredentials = errors.New("invalid username or password")
+)
+
+// Account é o registro durável de um jogador. O ID é estável entre conexões
+// e é o mesmo usado como playerId no resto do sistema (fila, salas, blockchain).
+type Account struct {
+	ID                  string    `json:"id"`
+	Username            string    `json:"username"`
+	PasswordHash        string    `json:"passwordHash"`
+	Salt                string    `json:"salt"`
+	StarterPacksGranted bool      `json:"starterPacksGranted"`
+	CreatedAt           time.Time `json:"createdAt"`
+}
+
+// Store guarda cada conta num arquivo JSON próprio (accounts/<username em hex>.json).
+// Um arquivo por conta evita que instâncias diferentes reescrevam os dados umas das outras.
+type Store struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewStoreFromEnv abre o store no diretório de SESSION_DATA_DIR (ou ./data/session).
+func NewStoreFromEnv() (*Store, error) {
+	return NewStore(DataDir())
+}
+
+// DataDir é o diretório base dos dados do Session (contas, inventários).
+func DataDir() string {
+	if dir := os.Getenv(DataDirEnv); dir != "" {
+		return dir
+	}
+	return defaultDataDir
+}
+
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "accounts"), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create account store at '%s': %w", dir, err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Register cria uma conta nova. Falha com ErrAccountExists se o nome já estiver em uso,
+// inclusive quando outra instância criou a conta ao mesmo tempo (O_EXCL).
+func (s *Store) Register(username, password string) (*Account, error) {
+	username = normalizeUsername(username)
+	if err := validateCredentials(username, password); err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	hash, err := hashPassword(password, salt)
+	if err != nil {
+		return nil, err
+	}
+	acc := &Account{
+		ID:           uuid.NewString(),
+		Username:     username,
+		PasswordHash: hash,
+		Salt:         hex.EncodeToString(salt),
+		CreatedAt:    time.Now().UTC(),
+	}
+
+	data, err := json.MarshalIndent(acc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.accountPath(username), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, os.ErrExist) {
+		return nil, ErrAccountExists
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create account: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to write account: %w", err)
+	}
+	return acc, nil
+}
+
+// Authenticate confere usuário e senha e devolve a conta.
+func (s *Store) Authenticate(username, password string) (*Account, error) {
+	acc, err := s.load(normalizeUsername(username))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	salt, err := hex.DecodeString(acc.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("corrupted account '%s': %w", acc.Username, err)
+	}
+	hash, err := hashPassword(password, salt)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(acc.PasswordHash)) != 1 {
+		return nil, ErrInvalidCredentials
+	}
+	return acc, nil
+}
+
+// Update regrava uma conta existente.
+func (s *Store) Update(acc *Account) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return writeFileAtomic(s.accountPath(acc.Username), acc)
+}
+
+func (s *Store) load(username string) (*Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := os.ReadFile(s.accountPath(username))
+	if err != nil {
+		return nil, err
+	}
+	var acc Account
+	if err := json.Unmarshal(data, &acc); err != nil {
+		return nil, fmt.Errorf("corrupted account file for '%s': %w", username, err)
+	}
+	return &acc, nil
+}
+
+// O nome vai em hex no nome do arquivo, então qualquer caractere é seguro.
+func (s *Store) accountPath(username string) string {
+	return filepath.Join(s.dir, "accounts", hex.EncodeToString([]byte(username))+".json")
+}
+
+func normalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+func validateCredentials(username, password string) error {
+	if len(username) < minUsernameLen || len(username) > maxUsernameLen {
+		return fmt.Errorf("username must have between %d and %d characters", minUsernameLen, maxUsernameLen)
+	}
+	if len(password) < minPasswordLen {
+		return fmt.Errorf("password must have at least %d characters", minPasswordLen)
+	}
+	return nil
+}
+
+func hashPassword(password string, salt []byte) (string, error) {
+	key, err := pbkdf2.Key(sha256.New, password, salt, pbkdf2Iterations, 32)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return hex.EncodeToString(key), nil
+}
+
+// writeFileAtomic grava v como JSON num arquivo temporário e o renomeia por cima
+// do destino, para que um leitor nunca veja um arquivo pela metade.
+func writeFileAtomic(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+//END OF FILE jokenpo/internal/session/account/account.go
@@ -5,40 +5,22 @@ import (
 	"encoding/json"
 	"jokenpo/internal/session/message"
 	"log"
-	"net/http"
 )
 
 // ============================================================================
-// Callback Genérico de Eventos de Jogo (sem mudanças)
+// Eventos de Jogo (chegam pelo stream da sala, game_stream.go)
 // ============================================================================
 
 type GameEventPayload struct {
+	Seq       uint64          `json:"seq,omitempty"` // Posição no stream de eventos da sala
 	EventType string          `json:"eventType"`
 	PlayerID  string          `json:"playerId"`
 	RoomID    string          `json:"roomId"`
 	Data      json.RawMessage `json:"data"`
 }
 
-func (h *GameHandler) CallbackGameEvent(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-	
-	var event GameEventPayload
-	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
-		http.Error(w, "Invalid event payload", http.StatusBadRequest)
-		return
-	}
-	
-	session := h.findSessionByID(event.PlayerID)
-	if session == nil {
-		w.WriteHeader(http.StatusOK)
-		return
-	}
-	
-	log.Printf("[Callback] Received game event '%s' for player %s.", event.EventType, event.PlayerID)
-
+// deliverGameEvent repassa um evento da sala ao cliente do jogador.
+func (h *GameHandler) deliverGameEvent(session *PlayerSession, event GameEventPayload) {
 	// --- LÓGICA DE CORREÇÃO FINAL ---
 
 	// PADRONIZA A MENSAGEM PARA O CLIENTE
@@ -47,12 +29,35 @@ func (h *GameHandler) CallbackGameEvent(w http.ResponseWriter, r *http.Request)
 	// Os dados para o cliente serão os dados do evento.
 	dataToClient := event.Data
 
+	if session.Fairness != nil {
+		session.Fairness.observe(event.EventType, event.Data)
+	}
+
 	if event.EventType == "GAME_OVER" {
-		session.State = state_LOBBY
-		session.CurrentGame = nil
+		h.clearResume(session.ID, event.RoomID)
+		// A revanche pode já ter começado; só a partida atual leva o jogador ao lobby.
+		if game := session.CurrentGame; game != nil && game.RoomID == event.RoomID {
+			session.LastMatch = &FinishedMatch{RoomID: game.RoomID, ServiceAddr: game.ServiceAddr}
+			if session.Fairness != nil {
+				session.LastMatch.Deck = session.Fairness.Deck
+			}
+			session.State = state_LOBBY
+			session.CurrentGame = nil
+		}
 		
 		// Para GAME_OVER, a mensagem principal é mais clara.
 		messageToClient = "The game has ended."
+
+		// Confere o embaralhamento com a semente revelada pela sala.
+		if session.Fairness != nil {
+			if err := session.Fairness.verify(session.ID, event.Data); err != nil {
+				log.Printf("[Callback] WARN: Shuffle verification failed for player %s in room %s: %v", session.ID, event.RoomID, err)
+				message.SendError(session.Client, "Shuffle verification FAILED: %v", err)
+			} else {
+				message.SendSuccess(session.Client, session.State, "Shuffle verified", "The revealed seed matches the commitment and your draws.")
+			}
+			session.Fairness = nil
+		}
 		
 		// Envia a mensagem de sucesso com o NOVO estado e o prompt.
 		message.SendSuccessAndPrompt(session.Client, session.State, messageToClient, dataToClient)
@@ -67,8 +72,6 @@ func (h *GameHandler) CallbackGameEvent(w http.ResponseWriter, r *http.Request)
 		message.SendSuccess(session.Client, session.State, messageToClient, dataToClient)
 		message.SendPromptInput(session.Client)
 	}
-
-	w.WriteHeader(http.StatusOK)
 }
 
 //END OF FILE jokenpo/internal/session/api_callbacks_match.go
\ No newline at end of file
@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"jokenpo/internal/game/deck"
 	"jokenpo/internal/session/message"
 	"log"
 	"net/http"
+	"strings"
 )
 
 // ============================================================================
@@ -19,6 +21,7 @@ type MatchCreatedPayload struct {
 	PlayerIDs   []string `json:"playerIds"`
 	RoomID      string   `json:"roomId"`
 	ServiceAddr string   `json:"serviceAddr"`
+	RematchOf   string   `json:"rematchOf,omitempty"` // Preenchido pela sala quando é uma revanche
 }
 
 // MatchFailedPayload é o DTO de FALHA que o jokenpo-session espera receber do QueueService.
@@ -45,6 +48,10 @@ func (h *GameHandler) CallbackMatchFound(w http.ResponseWriter, r *http.Request)
 	// Tenta decodificar como um payload de sucesso.
 	var successPayload MatchCreatedPayload
 	if err := json.Unmarshal(bodyBytes, &successPayload); err == nil && successPayload.RoomID != "" {
+		if successPayload.RematchOf != "" {
+			h.handleRematchFound(w, &successPayload)
+			return
+		}
 		h.handleMatchSuccess(w, &successPayload)
 		return
 	}
@@ -64,35 +71,90 @@ func (h *GameHandler) CallbackMatchFound(w http.ResponseWriter, r *http.Request)
 func (h *GameHandler) handleMatchSuccess(w http.ResponseWriter, payload *MatchCreatedPayload) {
 	log.Printf("[Callback] Match creation successful for room %s at %s.", payload.RoomID, payload.ServiceAddr)
 
-	// Cria a struct com as informações da partida remota.
-	gameInfo := &CurrentGameInfo{
-		RoomID:      payload.RoomID,
-		ServiceAddr: payload.ServiceAddr,
-	}
-
 	log.Printf("Payload de MatchCreation tem exatamente %d IDs", len(payload.PlayerIDs))
 	// Itera sobre os jogadores do par. Atualiza o estado daquele(s) jogador(es)
 	// que estiver(em) nesta instância do jokenpo-session.
 	for _, playerID := range payload.PlayerIDs {
 		session := h.findSessionByID(playerID)
 		if session != nil {
-			// --- A ATUALIZAÇÃO CRUCIAL ACONTECE AQUI ---
-			session.State = state_IN_MATCH
-			session.CurrentGame = gameInfo
-			
-			// Notifica o cliente (via WebSocket) que ele está em uma partida.
-			// O GameRoomService enviará as mensagens de início de jogo (compra de cartas, etc.).
-			message.SendSuccessAndPrompt(
-				session.Client,
-				session.State,
-				"Match found! Entering game room...",
-				gameInfo,
-			)
+			// Um líder novo do Queue pode repetir o MATCH_FOUND de uma sala em que o jogador já entrou.
+			if session.CurrentGame != nil && session.CurrentGame.RoomID == payload.RoomID {
+				continue
+			}
+			// Uma partida da fila substitui qualquer revanche ainda pendente.
+			session.pendingRematch = nil
+			h.enterMatch(session, payload)
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleRematchFound recebe o anúncio da revanche, que a sala manda pelo outbox
+// (um por jogador). Só entra na sala nova o jogador que ainda está no lobby com a
+// revanche daquela sala pendente; senão o anúncio é recusado e o outbox o descarta.
+// Se o GAME_OVER da partida anterior ainda não chegou pelo stream, responde 503
+// para a sala repetir daqui a pouco.
+func (h *GameHandler) handleRematchFound(w http.ResponseWriter, payload *MatchCreatedPayload) {
+	for _, playerID := range payload.PlayerIDs {
+		session := h.findSessionByID(playerID)
+		if session == nil {
+			http.Error(w, "Player is not on this node", http.StatusNotFound)
+			return
+		}
+		pending := session.pendingRematch
+		if pending == nil || pending.RoomID != payload.RematchOf {
+			log.Printf("[Callback] Refusing rematch room %s for %s: no rematch of %s pending.", payload.RoomID, playerID, payload.RematchOf)
+			http.Error(w, "No rematch pending for this match", http.StatusConflict)
+			return
+		}
+		if session.State == state_IN_MATCH && session.CurrentGame != nil && session.CurrentGame.RoomID == payload.RematchOf {
+			http.Error(w, "Previous match is still being closed", http.StatusServiceUnavailable)
+			return
 		}
+		if payload.RoomID != deck.RematchRoomID(payload.RematchOf) {
+			log.Printf("[Callback] Refusing rematch room %s for %s: it is not the room derived from %s.", payload.RoomID, playerID, payload.RematchOf)
+			http.Error(w, "Rematch room ID does not match the previous room", http.StatusConflict)
+			return
+		}
+		if session.State != state_LOBBY {
+			log.Printf("[Callback] Refusing rematch room %s for %s: player is %s.", payload.RoomID, playerID, session.State)
+			http.Error(w, "Player is not in the lobby", http.StatusConflict)
+			return
+		}
+		log.Printf("[Callback] Rematch of %s accepted: room %s at %s.", payload.RematchOf, payload.RoomID, payload.ServiceAddr)
+		session.pendingRematch = nil
+		// Numa revanche, a entropia do pedido é a que a sala usou.
+		session.Fairness = pending.Fairness
+		h.enterMatch(session, payload)
 	}
 	w.WriteHeader(http.StatusOK)
 }
 
+// enterMatch coloca o jogador na sala anunciada e passa a seguir o stream dela.
+func (h *GameHandler) enterMatch(session *PlayerSession, payload *MatchCreatedPayload) {
+	session.State = state_IN_MATCH
+	if session.Fairness != nil {
+		session.Fairness.RoomID = payload.RoomID
+	}
+	// Cópia por jogador: cada um acompanha o próprio seq no stream de eventos.
+	session.CurrentGame = &CurrentGameInfo{
+		RoomID:      payload.RoomID,
+		ServiceAddr: payload.ServiceAddr,
+	}
+	// Token para voltar à partida se a conexão cair (LOGIN com "resumeToken").
+	h.issueResumeToken(session, session.CurrentGame)
+
+	// Notifica o cliente (via WebSocket) que ele está em uma partida.
+	// O GameRoomService enviará as mensagens de início de jogo (compra de cartas, etc.).
+	message.SendSuccessAndPrompt(
+		session.Client,
+		session.State,
+		"Match found! Entering game room...",
+		session.CurrentGame,
+	)
+	h.followGameStream(session, session.CurrentGame)
+}
+
 // handleMatchFailure é chamado quando o QueueService informa que a criação da sala falhou.
 func (h *GameHandler) handleMatchFailure(w http.ResponseWriter, payload *MatchFailedPayload) {
 	log.Printf("[Callback] Match creation failed: %s", payload.Reason)
@@ -114,10 +176,16 @@ func (h *GameHandler) handleMatchFailure(w http.ResponseWriter, payload *MatchFa
 
 type TradeFoundPayload struct {
 	PlayerID     string `json:"playerId"`
+	EscrowID     string `json:"escrowId"`
+	State        string `json:"state"`
 	CardSent     string `json:"cardSent"`
 	CardReceived string `json:"cardReceived"`
+	Reason       string `json:"reason,omitempty"`
 }
 
+// CallbackTradeFound é avisado quando um escrow do Wonder Trade termina (troca
+// feita ou carta devolvida). A carta só é creditada pelo collect: se o jogador
+// não estiver aqui, o escrow continua no Queue e é entregue no próximo login.
 func (h *GameHandler) CallbackTradeFound(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost { /* ... */ return }
 	var payload TradeFoundPayload
@@ -125,25 +193,52 @@ func (h *GameHandler) CallbackTradeFound(w http.ResponseWriter, r *http.Request)
 
 	session := h.findSessionByID(payload.PlayerID)
 	if session == nil {
-		w.WriteHeader(http.StatusOK)
+		log.Printf("[Callback] Trade escrow %s of %s is %s, but the player is not here. Keeping it for the next login.", payload.EscrowID, payload.PlayerID, payload.State)
+		w.WriteHeader(http.StatusNotFound)
 		return
 	}
 
-	log.Printf("[Callback] Trade completed for player %s.", session.ID)
-	if err := session.Player.Inventory().Collection().AddCard(payload.CardReceived, 1); err != nil {
-		log.Printf("CRITICAL: Failed to add received card '%s' for player %s: %v", payload.CardReceived, session.ID, err)
-		message.SendErrorAndPrompt(session.Client, "A trade was found, but an internal error occurred.")
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-	session.State = state_LOBBY
-	successMsg := fmt.Sprintf("Wonder Trade successful! You sent '%s' and received '%s'.", payload.CardSent, payload.CardReceived)
-	message.SendSuccessAndPrompt(session.Client, session.State, "Trade Completed!", successMsg)
-
+	log.Printf("[Callback] Trade escrow %s of player %s is %s.", payload.EscrowID, session.ID, payload.State)
+	notes := h.deliverTradeEscrows(session)
 	w.WriteHeader(http.StatusOK)
+	if len(notes) == 0 {
+		return // Já recolhido por outro caminho (ex: LEAVE_QUEUE).
+	}
+	if session.State == state_IN_TRADE_QUEUE {
+		session.State = state_LOBBY
+	}
+	message.SendSuccessAndPrompt(session.Client, session.State, "Wonder Trade update", strings.Join(notes, "\n"))
 }
 
-
-
+// deliverTradeEscrows credita as custódias terminadas do jogador: a carta recebida
+// se a troca foi feita, a carta oferecida se foi devolvida. Retorna uma linha por
+// escrow para mostrar ao jogador.
+func (h *GameHandler) deliverTradeEscrows(session *PlayerSession) []string {
+	session.deliveryMu.Lock()
+	defer session.deliveryMu.Unlock()
+	escrows, err := h.collectTradeEscrows(session)
+	if err != nil {
+		log.Printf("WARN: Could not collect trade escrows of player %s: %v", session.ID, err)
+		return nil
+	}
+	notes := make([]string, 0, len(escrows))
+	ids := make([]string, len(escrows))
+	for i, e := range escrows {
+		ids[i] = e.ID
+	}
+	h.creditOnce(session, "/queue/trade/escrows/ack", ids, func(i int) {
+		e := escrows[i]
+		card, note := e.OfferCard, fmt.Sprintf("Your '%s' was returned to your collection (%s).", e.OfferCard, e.Reason)
+		if e.State == escrowSettled {
+			card, note = e.ReceivedCard, fmt.Sprintf("Wonder Trade successful! You sent '%s' and received '%s'.", e.OfferCard, e.ReceivedCard)
+		}
+		if err := session.Player.Inventory().Collection().AddCard(card, 1); err != nil {
+			log.Printf("CRITICAL: Failed to credit card '%s' of trade escrow %s to player %s: %v", card, e.ID, session.ID, err)
+			return
+		}
+		notes = append(notes, note)
+	})
+	return notes
+}
 
 //END OF FILE jokenpo/internal/session/api_callbacks_queue.go
\ No newline at end of file
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 
 )
 
@@ -37,6 +38,77 @@ type PlayCardRequest struct {
 	CardIndex int    `json:"cardIndex"`
 }
 
+// RoomPresenceRequest avisa a sala que a conexão do jogador caiu ou voltou.
+type RoomPresenceRequest struct {
+	PlayerID  string `json:"playerId"`
+	Connected bool   `json:"connected"`
+}
+
+// RoomSnapshot é o estado da partida que a sala devolve para um jogador.
+type RoomSnapshot struct {
+	RoomID         string          `json:"roomId"`
+	Phase          string          `json:"phase"`
+	Round          int             `json:"round"`
+	TimeLeftMs     int64           `json:"timeLeftMs"`
+	RoundPaused    bool            `json:"roundPaused"`
+	Players        []SeatSnapshot  `json:"players"`
+	Away           []string        `json:"away,omitempty"`
+	PlayerID       string          `json:"playerId,omitempty"`
+	Seq            uint64          `json:"seq,omitempty"`
+	Hand           []string        `json:"hand,omitempty"`
+	WinPile        []string        `json:"winPile,omitempty"`
+	OutPile        []string        `json:"outPile,omitempty"`
+	PlayedCard     string          `json:"playedCard,omitempty"`
+	OpponentPlayed bool            `json:"opponentPlayed,omitempty"`
+	Series         *SeriesSnapshot `json:"series,omitempty"`
+}
+
+// SeriesSnapshot é o placar de uma série melhor de N.
+type SeriesSnapshot struct {
+	BestOf       int            `json:"bestOf"`
+	Game         int            `json:"game"`
+	Score        map[string]int `json:"score"`
+	NextGameInMs int64          `json:"nextGameInMs,omitempty"`
+}
+
+// SeatSnapshot traz o tamanho das zonas de um jogador da sala.
+type SeatSnapshot struct {
+	PlayerID   string `json:"playerId"`
+	Deck       int    `json:"deck"`
+	Hand       int    `json:"hand"`
+	Win        int    `json:"win"`
+	Out        int    `json:"out"`
+	Played     bool   `json:"played"`
+	Connected  bool   `json:"connected"`
+	TimeBankMs int64  `json:"timeBankMs"`
+}
+
+// RoomActionRequest é o DTO das ações da partida que só levam o jogador
+// (surrender, offer-draw e accept-draw).
+type RoomActionRequest struct {
+	PlayerID string `json:"playerId"`
+}
+
+// SideboardRequest troca o deck do jogador para o próximo jogo da série.
+type SideboardRequest struct {
+	PlayerID string   `json:"playerId"`
+	Deck     []string `json:"deck"`
+}
+
+// RematchRequest é o DTO do pedido de revanche.
+type RematchRequest struct {
+	PlayerID string `json:"playerId"`
+	Entropy  string `json:"entropy,omitempty"`
+}
+
+// RematchResponse diz se a revanche já vai começar ou espera o oponente.
+type RematchResponse struct {
+	Status string `json:"status"`
+}
+
+// roomPhaseGameOver é a fase de uma sala cuja partida já terminou.
+const roomPhaseGameOver = "game_over"
+
 
 // ============================================================================
 // Helpers de API para o GameHandler
@@ -76,4 +148,125 @@ func (h *GameHandler) forwardPlayCardAction(session *PlayerSession, cardIndex in
 
 
 
-//END OF FILE jokenpo/internal/session/api_helpers_game.go
\ No newline at end of file
+// notifyRoomPresence avisa a sala da partida que o jogador caiu ou voltou, para
+// ela pausar a rodada e contar o período de graça.
+func (h *GameHandler) notifyRoomPresence(game *CurrentGameInfo, playerID string, connected bool) error {
+	body, err := json.Marshal(RoomPresenceRequest{PlayerID: playerID, Connected: connected})
+	if err != nil {
+		return err
+	}
+	presenceURL := fmt.Sprintf("http://%s/rooms/%s/presence", game.ServiceAddr, game.RoomID)
+	resp, err := h.httpClient.Post(presenceURL, "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		return fmt.Errorf("failed to contact game room service: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+		return errRoomGone
+	}
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("game room service returned an error status: %s", resp.Status)
+	}
+	return nil
+}
+
+// forwardRoomAction envia uma ação da partida (ex: "surrender") para a sala.
+func (h *GameHandler) forwardRoomAction(game *CurrentGameInfo, playerID, action string) error {
+	body, err := json.Marshal(RoomActionRequest{PlayerID: playerID})
+	if err != nil {
+		return err
+	}
+	actionURL := fmt.Sprintf("http://%s/rooms/%s/%s", game.ServiceAddr, game.RoomID, action)
+	resp, err := h.httpClient.Post(actionURL, "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		return fmt.Errorf("failed to forward action to game room service: %w", err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusAccepted:
+		return nil
+	case http.StatusConflict, http.StatusGone:
+		return fmt.Errorf("the match is already over")
+	case http.StatusServiceUnavailable:
+		// A sala não pegou a ação a tempo; nada foi aplicado.
+		return fmt.Errorf("the game room is busy, try again")
+	}
+	return fmt.Errorf("game room service returned an error status: %s", resp.Status)
+}
+
+// sideboardDeck manda o deck do jogador para o próximo jogo da série. A sala só
+// aceita no intervalo entre jogos e devolve o motivo quando recusa.
+func (h *GameHandler) sideboardDeck(game *CurrentGameInfo, playerID string, deckKeys []string) error {
+	body, err := json.Marshal(SideboardRequest{PlayerID: playerID, Deck: deckKeys})
+	if err != nil {
+		return err
+	}
+	sideboardURL := fmt.Sprintf("http://%s/rooms/%s/sideboard", game.ServiceAddr, game.RoomID)
+	resp, err := h.httpClient.Post(sideboardURL, "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		return fmt.Errorf("failed to contact game room service: %w", err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusConflict:
+		var out struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&out) == nil && out.Error != "" {
+			return fmt.Errorf("%s", out.Error)
+		}
+	case http.StatusNotFound, http.StatusGone:
+		return errRoomGone
+	}
+	return fmt.Errorf("game room service returned an error status: %s", resp.Status)
+}
+
+// requestRematch pede a revanche na sala roomID. A sala nova chega depois pelo /match-found.
+func (h *GameHandler) requestRematch(serviceAddr, roomID, playerID, entropy string) (string, error) {
+	body, err := json.Marshal(RematchRequest{PlayerID: playerID, Entropy: entropy})
+	if err != nil {
+		return "", err
+	}
+	rematchURL := fmt.Sprintf("http://%s/rooms/%s/rematch", serviceAddr, roomID)
+	resp, err := h.httpClient.Post(rematchURL, "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to contact game room service: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return "", errRoomGone
+	}
+	if resp.StatusCode != http.StatusAccepted {
+		return "", fmt.Errorf("game room service returned an error status: %s", resp.Status)
+	}
+	var out RematchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("invalid rematch response: %w", err)
+	}
+	return out.Status, nil
+}
+
+// fetchRoomSnapshot busca o estado da partida do ponto de vista do jogador.
+func (h *GameHandler) fetchRoomSnapshot(game *CurrentGameInfo, playerID string) (*RoomSnapshot, error) {
+	snapshotURL := fmt.Sprintf("http://%s/rooms/%s?playerId=%s", game.ServiceAddr, game.RoomID, url.QueryEscape(playerID))
+	resp, err := h.httpClient.Get(snapshotURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to contact game room service: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errRoomGone
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("game room service returned an error status: %s", resp.Status)
+	}
+	var snapshot RoomSnapshot
+	if err := json.NewDecoder(resp.Body).Decode(&snapshot); err != nil {
+		return nil, fmt.Errorf("invalid room snapshot: %w", err)
+	}
+	return &snapshot, nil
+}
+
+//END OF FILE jokenpo/internal/session/api_helpers_game.go
@@ -0,0 +1,186 @@
+//START OF FILE jokenpo/internal/session/api_helpers_market.go
+package session
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"jokenpo/internal/services/cluster"
+	"net/http"
+	"time"
+)
+
+// ============================================================================
+// DTOs para o Mercado de Trocas (jokenpo-queue, /market/*)
+// ============================================================================
+
+type CreateListingRequest struct {
+	PlayerID    string   `json:"playerId"`
+	CallbackURL string   `json:"callbackUrl"`
+	Offer       string   `json:"offer"`
+	Want        []string `json:"want,omitempty"`
+}
+
+type CreateListingResponse struct {
+	ListingID string    `json:"listingId"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type MarketPlayerRequest struct {
+	PlayerID string `json:"playerId"`
+}
+
+// MarketListingView é como o Queue devolve cada oferta no GET /market/listings.
+type MarketListingView struct {
+	ID        string    `json:"id"`
+	PlayerID  string    `json:"playerId"`
+	Offer     string    `json:"offer"`
+	Want      []string  `json:"want,omitempty"`
+	State     string    `json:"state"`
+	MatchID   string    `json:"matchId,omitempty"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// ListingOutcome é o resultado de uma oferta do jogador, como o Queue o devolve
+// em POST /market/outcomes. Credit é a carta a creditar.
+// AckDeliveryRequest confirma ao Queue os registros já creditados e gravados.
+type AckDeliveryRequest struct {
+	PlayerID string   `json:"playerId"`
+	IDs      []string `json:"ids"`
+}
+
+type ListingOutcome struct {
+	ID        string `json:"id"`
+	ListingID string `json:"listingId"`
+	EventType string `json:"eventType"`
+	Give      string `json:"give"`
+	Receive   string `json:"receive,omitempty"`
+	PartnerID string `json:"partnerId,omitempty"`
+	Credit    string `json:"credit"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// ============================================================================
+// Helpers de API
+// ============================================================================
+
+// errMarketUnavailable: nenhum líder do Queue foi encontrado e o pedido não saiu daqui.
+var errMarketUnavailable = errors.New("the trade market is currently unavailable")
+
+// MarketError é a resposta de erro do Queue a um pedido que chegou até ele.
+type MarketError struct {
+	Status  int
+	Message string
+}
+
+func (e *MarketError) Error() string { return e.Message }
+
+// marketStatus devolve o status HTTP da recusa, ou 0 se não houve resposta.
+func marketStatus(err error) int {
+	var marketErr *MarketError
+	if errors.As(err, &marketErr) {
+		return marketErr.Status
+	}
+	return 0
+}
+
+// marketRefused indica que o Queue recusou o pedido de vez (4xx, exceto 408 e 429).
+// Erros de rede, timeouts e 5xx são ambíguos: o pedido pode ter sido aplicado.
+func marketRefused(err error) bool {
+	status := marketStatus(err)
+	return status >= 400 && status < 500 && status != http.StatusRequestTimeout && status != http.StatusTooManyRequests
+}
+
+// marketNotApplied indica que o pedido com certeza não mudou nada no Queue.
+func marketNotApplied(err error) bool {
+	return errors.Is(err, errMarketUnavailable) || marketRefused(err)
+}
+
+// marketRequest faz uma chamada ao mercado no líder do Queue e decodifica a resposta em 'out' (se não for nil).
+func (h *GameHandler) marketRequest(method, path string, payload any, expectedStatus int, out any) error {
+	queueServiceAddr := h.serviceCache.Discover("jokenpo-queue", cluster.DiscoveryOptions{Mode: cluster.ModeLeader})
+	if queueServiceAddr == "" {
+		return errMarketUnavailable
+	}
+
+	var body bytes.Buffer
+	if payload != nil {
+		if err := json.NewEncoder(&body).Encode(payload); err != nil {
+			return fmt.Errorf("failed to create request payload: %w", err)
+		}
+	}
+	req, err := http.NewRequest(method, fmt.Sprintf("http://%s%s", queueServiceAddr, path), &body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	h.catalogGuard.Stamp(req)
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to contact trade market: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := h.catalogGuard.CheckResponse(resp); err != nil {
+		return err
+	}
+	if resp.StatusCode != expectedStatus {
+		var errBody struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&errBody) == nil && errBody.Error != "" {
+			return &MarketError{Status: resp.StatusCode, Message: errBody.Error}
+		}
+		return &MarketError{Status: resp.StatusCode, Message: fmt.Sprintf("trade market returned an error status: %s", resp.Status)}
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
+
+func (h *GameHandler) createListing(session *PlayerSession, offer string, want []string) (*CreateListingResponse, error) {
+	payload := CreateListingRequest{
+		PlayerID:    session.ID,
+		CallbackURL: h.buildCallbackURL(session, "/market-event"),
+		Offer:       offer,
+		Want:        want,
+	}
+	var resp CreateListingResponse
+	if err := h.marketRequest(http.MethodPost, "/market/listings", payload, http.StatusCreated, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (h *GameHandler) cancelListing(session *PlayerSession, listingID string) error {
+	return h.marketRequest(http.MethodDelete, "/market/listings/"+listingID, MarketPlayerRequest{PlayerID: session.ID}, http.StatusOK, nil)
+}
+
+func (h *GameHandler) respondListingMatch(session *PlayerSession, matchID string, accept bool) error {
+	action := "decline"
+	if accept {
+		action = "accept"
+	}
+	return h.marketRequest(http.MethodPost, fmt.Sprintf("/market/matches/%s/%s", matchID, action), MarketPlayerRequest{PlayerID: session.ID}, http.StatusOK, nil)
+}
+
+// collectListingOutcomes lista os resultados das ofertas do jogador. Eles só saem
+// do Queue com o ack em /market/outcomes/ack (creditOnce).
+func (h *GameHandler) collectListingOutcomes(session *PlayerSession) ([]ListingOutcome, error) {
+	var outcomes []ListingOutcome
+	if err := h.marketRequest(http.MethodPost, "/market/outcomes", MarketPlayerRequest{PlayerID: session.ID}, http.StatusOK, &outcomes); err != nil {
+		return nil, err
+	}
+	return outcomes, nil
+}
+
+func (h *GameHandler) browseListings() ([]MarketListingView, error) {
+	var listings []MarketListingView
+	err := h.marketRequest(http.MethodGet, "/market/listings", nil, http.StatusOK, &listings)
+	return listings, err
+}
+
+//END OF FILE jokenpo/internal/session/api_helpers_market.go
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"jokenpo/internal/game/deck"
 	"jokenpo/internal/services/cluster"
 	"log"
 	"net/http"
@@ -17,9 +18,13 @@ import (
 
 // EnqueueMatchRequest é o DTO enviado para entrar na fila de partida.
 type EnqueueMatchRequest struct {
-	PlayerID    string   `json:"playerId"`
-	CallbackURL string   `json:"callbackUrl"`
-	Deck        []string `json:"deck"`
+	PlayerID    string        `json:"playerId"`
+	CallbackURL string        `json:"callbackUrl"`
+	Deck        []string      `json:"deck"`
+	Ruleset     string        `json:"ruleset,omitempty"`
+	BestOf      int           `json:"bestOf,omitempty"`      // Formato da série; só formatos iguais são pareados
+	Entropy     string        `json:"entropy,omitempty"`     // Contribuição da sessão para o embaralhamento
+	DeckProfile *deck.Profile `json:"deckProfile,omitempty"` // Informativo; o Queue recalcula a partir do deck
 }
 
 // EnqueueTradeRequest é o DTO enviado para entrar na fila de troca.
@@ -34,6 +39,21 @@ type DequeueRequest struct {
 	PlayerID string `json:"playerId"`
 }
 
+// escrowSettled é o estado de um escrow cuja troca foi feita; "refunded" devolve a carta oferecida.
+const escrowSettled = "settled"
+
+// TradeEscrow é a custódia de uma oferta do Wonder Trade, como o Queue a devolve
+// em POST /queue/trade/escrows.
+type TradeEscrow struct {
+	ID           string `json:"id"`
+	PlayerID     string `json:"playerId"`
+	OfferCard    string `json:"offerCard"`
+	State        string `json:"state"`
+	PartnerID    string `json:"partnerId,omitempty"`
+	ReceivedCard string `json:"receivedCard,omitempty"`
+	Reason       string `json:"reason,omitempty"`
+}
+
 // ============================================================================
 // Helpers de API para o GameHandler
 // ============================================================================
@@ -41,7 +61,7 @@ type DequeueRequest struct {
 // --- Helpers da Fila de Partida ---
 
 // enterMatchQueue encapsula a chamada HTTP para entrar na fila de partida.
-func (h *GameHandler) enterMatchQueue(session *PlayerSession, deckKeys []string) error {
+func (h *GameHandler) enterMatchQueue(session *PlayerSession, deckKeys []string, ruleset string, bestOf int) error {
 	opts := cluster.DiscoveryOptions{Mode: cluster.ModeLeader}
 	log.Printf("[enterMatchQueue] Tentando descobrir o serviço 'jokenpo-queue' com options: %+v", opts)
 	queueServiceAddr := h.serviceCache.Discover("jokenpo-queue", opts)
@@ -51,13 +71,27 @@ func (h *GameHandler) enterMatchQueue(session *PlayerSession, deckKeys []string)
 		return fmt.Errorf("the matchmaking service is currently unavailable")
 	}
 
-	gameEventCallbackURL := fmt.Sprintf("http://%s:%d/game-event", h.advertisedHostname, 8080)
+	// A sessão contribui com entropia própria e guarda o que precisa para
+	// verificar o embaralhamento quando a sala revelar a semente.
+	fairness, err := newMatchFairness(deckKeys)
+	if err != nil {
+		return err
+	}
+
+	profile, err := deck.ProfileFromKeys(deckKeys)
+	if err != nil {
+		return fmt.Errorf("failed to compute deck power: %w", err)
+	}
 
 	// --- MUDANÇA: O payload agora inclui o deck ---
 	payload := EnqueueMatchRequest{
 		PlayerID:    session.ID,
-		CallbackURL: gameEventCallbackURL,
+		CallbackURL: h.buildCallbackURL(session, ""), // Nó deste Session; a sala o usa se não achar a presença
 		Deck:        deckKeys,
+		Ruleset:     ruleset,
+		BestOf:      bestOf,
+		Entropy:     fairness.Entropy,
+		DeckProfile: &profile,
 	}
 	body, err := json.Marshal(payload)
 	if err != nil {
@@ -69,16 +103,27 @@ func (h *GameHandler) enterMatchQueue(session *PlayerSession, deckKeys []string)
 	// Construímos a URL para o serviço da fila e passamos o callback como um query parameter.
 	queueURL := fmt.Sprintf("http://%s/queue/match?callback=%s", queueServiceAddr, matchFoundCallbackURL)
 
-	resp, err := h.httpClient.Post(queueURL, "application/json", bytes.NewBuffer(body))
+	req, err := http.NewRequest(http.MethodPost, queueURL, bytes.NewBuffer(body))
+	if err != nil {
+		return fmt.Errorf("failed to create POST request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	h.catalogGuard.Stamp(req)
+
+	resp, err := h.httpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to contact matchmaking service: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if err := h.catalogGuard.CheckResponse(resp); err != nil {
+		return err
+	}
 	if resp.StatusCode != http.StatusAccepted {
 		return fmt.Errorf("matchmaking service returned an error status: %s", resp.Status)
 	}
 
+	session.Fairness = fairness
 	return nil
 }
 
@@ -187,6 +232,9 @@ func (h *GameHandler) leaveTradeQueue(session *PlayerSession) error {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusConflict {
+		return fmt.Errorf("your offer was already matched and the trade is being settled")
+	}
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("trade service returned an error status: %s", resp.Status)
 	}
@@ -194,4 +242,32 @@ func (h *GameHandler) leaveTradeQueue(session *PlayerSession) error {
 	return nil
 }
 
+// CustodyReport é a resposta do POST /queue/custody: o que ainda prende cartas
+// do jogador no Queue (cartas em custódia ou registros ainda sem ack).
+type CustodyReport struct {
+	Listings    int `json:"listings"`
+	Proposals   int `json:"proposals"`
+	Escrows     int `json:"escrows"`
+	Undelivered int `json:"undelivered"`
+}
+
+// queueCustody consulta o que ainda prende cartas do jogador no Queue.
+func (h *GameHandler) queueCustody(session *PlayerSession) (*CustodyReport, error) {
+	var report CustodyReport
+	if err := h.marketRequest(http.MethodPost, "/queue/custody", MarketPlayerRequest{PlayerID: session.ID}, http.StatusOK, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// collectTradeEscrows lista as custódias terminadas do jogador. Elas só saem do
+// Queue com o ack em /queue/trade/escrows/ack (creditOnce).
+func (h *GameHandler) collectTradeEscrows(session *PlayerSession) ([]TradeEscrow, error) {
+	var escrows []TradeEscrow
+	if err := h.marketRequest(http.MethodPost, "/queue/trade/escrows", DequeueRequest{PlayerID: session.ID}, http.StatusOK, &escrows); err != nil {
+		return nil, err
+	}
+	return escrows, nil
+}
+
 //END OF FILE jokenpo/internal/session/api_helpers_queue.go
\ No newline at end of file
@@ -57,6 +57,7 @@ func (h *GameHandler) purchasePacksFromShop(playerID string, quantity uint64) ([
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
+	h.catalogGuard.Stamp(httpReq)
 
 	// 3. Executa a chamada (lógica inalterada)
 	resp, err := h.httpClient.Do(httpReq)
@@ -66,6 +67,11 @@ func (h *GameHandler) purchasePacksFromShop(playerID string, quantity uint64) ([
 	}
 	defer resp.Body.Close()
 
+	// Um shop com outro catálogo geraria chaves que este serviço não reconhece.
+	if err := h.catalogGuard.CheckResponse(resp); err != nil {
+		return nil, err
+	}
+
 	// 4. Processa a resposta (lógica inalterada)
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -0,0 +1,70 @@
+//START OF FILE jokenpo/internal/session/api_helpers_trades.go
+package session
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ============================================================================
+// DTOs para Trocas Diretas (jokenpo-queue, /trades/proposals*)
+// ============================================================================
+
+type ProposeTradeRequest struct {
+	ProposalID string   `json:"proposalId"`
+	FromID     string   `json:"fromId"`
+	ToID       string   `json:"toId"`
+	Give       []string `json:"give"`
+	Receive    []string `json:"receive"`
+}
+
+type ProposeTradeResponse struct {
+	ProposalID string    `json:"proposalId"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+}
+
+// TradeOutcome é o resultado de uma troca direta para o jogador, como o Queue o
+// devolve em POST /trades/outcomes. Credit são as cartas a creditar.
+type TradeOutcome struct {
+	ID        string               `json:"id"`
+	PlayerID  string               `json:"playerId"`
+	EventType string               `json:"eventType"`
+	Proposal  TradeProposalPayload `json:"proposal"`
+	Credit    []string             `json:"credit,omitempty"`
+	Reason    string               `json:"reason,omitempty"`
+}
+
+// ============================================================================
+// Helpers de API
+// ============================================================================
+
+// As chamadas reaproveitam o marketRequest: o endpoint também mora no líder do Queue.
+
+// proposeTrade cria a proposta com um ID escolhido aqui, para que ela possa ser
+// retirada (cancel) pelo mesmo ID se a resposta se perder.
+func (h *GameHandler) proposeTrade(session *PlayerSession, proposalID, targetID string, give, receive []string) (*ProposeTradeResponse, error) {
+	payload := ProposeTradeRequest{ProposalID: proposalID, FromID: session.ID, ToID: targetID, Give: give, Receive: receive}
+	var resp ProposeTradeResponse
+	if err := h.marketRequest(http.MethodPost, "/trades/proposals", payload, http.StatusCreated, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// respondTradeProposal envia "accept", "reject" (destinatário) ou "cancel" (proponente).
+func (h *GameHandler) respondTradeProposal(session *PlayerSession, proposalID, action string) error {
+	return h.marketRequest(http.MethodPost, fmt.Sprintf("/trades/proposals/%s/%s", proposalID, action), MarketPlayerRequest{PlayerID: session.ID}, http.StatusOK, nil)
+}
+
+// collectTradeOutcomes lista os resultados de trocas diretas do jogador. Eles só
+// saem do Queue com o ack em /trades/outcomes/ack (creditOnce).
+func (h *GameHandler) collectTradeOutcomes(session *PlayerSession) ([]TradeOutcome, error) {
+	var outcomes []TradeOutcome
+	if err := h.marketRequest(http.MethodPost, "/trades/outcomes", MarketPlayerRequest{PlayerID: session.ID}, http.StatusOK, &outcomes); err != nil {
+		return nil, err
+	}
+	return outcomes, nil
+}
+
+//END OF FILE jokenpo/internal/session/api_helpers_trades.go
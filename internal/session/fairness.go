@@ -0,0 +1,119 @@
+//START OF FILE jokenpo/internal/session/fairness.go
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"jokenpo/internal/game/deck"
+	"slices"
+)
+
+// MatchFairness guarda, durante uma partida, o que a sessão precisa para
+// verificar o embaralhamento quando a sala revelar a semente no GAME_OVER.
+// Numa série, cada jogo é conferido com o próprio fluxo da mesma semente.
+type MatchFairness struct {
+	Entropy    string   // Contribuição enviada para a fila
+	RoomID     string   // Sala anunciada no /match-found; a prova revelada tem que ser dela
+	Deck       []string // Deck na ordem enviada para a sala
+	Commitment string   // Hash da semente recebido no GAME_START
+	Drawn      []string // Cartas compradas no jogo atual, na ordem dos UPDATE_HAND
+
+	gameDeck []string    // Ordem do deck no jogo atual ou no próximo (nil = Deck)
+	past     []gameDraws // Jogos anteriores da série
+}
+
+// gameDraws é o que foi comprado num jogo já terminado de uma série.
+type gameDraws struct {
+	deck  []string
+	drawn []string
+}
+
+func newMatchFairness(deckKeys []string) (*MatchFairness, error) {
+	entropy, err := deck.NewEntropy()
+	if err != nil {
+		return nil, err
+	}
+	return &MatchFairness{
+		Entropy: entropy,
+		Deck:    append([]string(nil), deckKeys...),
+	}, nil
+}
+
+// observe acompanha os eventos da sala que importam para a verificação.
+func (f *MatchFairness) observe(eventType string, data json.RawMessage) {
+	switch eventType {
+	case "GAME_START":
+		var start struct {
+			Commitment string `json:"shuffle_commitment"`
+		}
+		// A semente é a mesma na série toda: vale o compromisso do primeiro jogo.
+		if json.Unmarshal(data, &start) == nil && f.Commitment == "" {
+			f.Commitment = start.Commitment
+		}
+	case "GAME_RESULT":
+		// Fim de um jogo da série. Vem antes das compras do jogo seguinte.
+		f.nextGame()
+	case "UPDATE_HAND":
+		var update struct {
+			Drawn []string `json:"drawn"`
+		}
+		if json.Unmarshal(data, &update) == nil {
+			f.Drawn = append(f.Drawn, update.Drawn...)
+		}
+	}
+}
+
+// nextGame guarda as compras do jogo que terminou. A sala começa cada jogo
+// seguinte com o deck em ordem de chave (deck.ResetForNextGame).
+func (f *MatchFairness) nextGame() {
+	f.past = append(f.past, gameDraws{deck: f.currentDeck(), drawn: f.Drawn})
+	f.gameDeck = slices.Sorted(slices.Values(f.currentDeck()))
+	f.Drawn = nil
+}
+
+// sideboard registra o deck aceito pela sala no intervalo entre jogos.
+func (f *MatchFairness) sideboard(deckKeys []string) {
+	f.gameDeck = slices.Sorted(slices.Values(deckKeys))
+}
+
+func (f *MatchFairness) currentDeck() []string {
+	if f.gameDeck != nil {
+		return f.gameDeck
+	}
+	return f.Deck
+}
+
+// verify confere a prova revelada no GAME_OVER. O compromisso usado é o que a
+// sessão recebeu no início, não o que veio junto da prova.
+func (f *MatchFairness) verify(playerID string, gameOverData json.RawMessage) error {
+	var over struct {
+		Shuffle *deck.ShuffleProof `json:"shuffle"`
+	}
+	if err := json.Unmarshal(gameOverData, &over); err != nil || over.Shuffle == nil {
+		return fmt.Errorf("the room did not reveal the shuffle seed")
+	}
+	if f.Commitment == "" {
+		return fmt.Errorf("no shuffle commitment was received at game start")
+	}
+	if f.RoomID != "" && over.Shuffle.RoomID != f.RoomID {
+		return fmt.Errorf("the shuffle proof is for room %s, not for this match's room %s", over.Shuffle.RoomID, f.RoomID)
+	}
+	if !slices.Contains(over.Shuffle.Entropies, f.Entropy) {
+		return fmt.Errorf("your entropy was not used in the shuffle seed")
+	}
+
+	proof := *over.Shuffle
+	proof.Commitment = f.Commitment
+	games := append(f.past, gameDraws{deck: f.currentDeck(), drawn: f.Drawn})
+	for i, g := range games {
+		if err := deck.VerifyShuffle(proof, deck.SeriesStream(playerID, i+1), g.deck, g.drawn); err != nil {
+			if len(games) > 1 {
+				return fmt.Errorf("game %d: %w", i+1, err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+//END OF FILE jokenpo/internal/session/fairness.go
@@ -0,0 +1,160 @@
+//START OF FILE jokenpo/internal/session/game_stream.go
+package session
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"jokenpo/internal/session/message"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Os eventos da partida chegam por um stream por jogador aberto no GameRoom
+// (GET /rooms/{id}/events, uma linha JSON por evento, com seq crescente). O Session
+// guarda o último seq entregue em CurrentGameInfo.LastEventSeq; se a conexão cair,
+// reconecta com after=<último seq> e descarta o que já tinha recebido.
+
+const (
+	streamRetryMin     = 200 * time.Millisecond
+	streamRetryMax     = 5 * time.Second
+	streamMaxFailures  = 10 // Reconexões seguidas sem nenhum evento antes de desistir da sala
+	streamMaxLineBytes = 1 << 20
+	streamIdleTimeout  = 45 * time.Second // 3x o heartbeat da sala (15s): sem nenhuma linha, a conexão é dada como morta
+)
+
+var errRoomGone = errors.New("the game room no longer exists")
+
+// followGameStream começa a ler os eventos da partida em background. O stream
+// termina no GAME_OVER, quando a sala some ou quando o jogador desconecta.
+func (h *GameHandler) followGameStream(session *PlayerSession, game *CurrentGameInfo) {
+	if session.gameStream != nil {
+		session.gameStream()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	session.gameStream = cancel
+	go func() {
+		defer cancel()
+		backoff, failures := streamRetryMin, 0
+		for {
+			received, done, err := h.readGameStream(ctx, session, game)
+			if done || ctx.Err() != nil {
+				return
+			}
+			if received > 0 {
+				backoff, failures = streamRetryMin, 0
+			}
+			failures++
+			if errors.Is(err, errRoomGone) || failures > streamMaxFailures {
+				h.abandonGame(session, game, err)
+				return
+			}
+			log.Printf("[GameStream] WARN: Stream of room %s for %s dropped at seq %d (%v). Reconnecting in %v.", game.RoomID, session.ID, game.LastEventSeq, err, backoff)
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return
+			}
+			backoff = min(backoff*2, streamRetryMax)
+		}
+	}()
+}
+
+// readGameStream lê uma conexão do stream até ela cair. Retorna quantos eventos
+// novos foram entregues e se a partida acabou. Se nem o heartbeat chegar em
+// streamIdleTimeout (ex: o nó da sala sumiu sem fechar a conexão), a leitura é
+// cancelada e o followGameStream reconecta.
+func (h *GameHandler) readGameStream(ctx context.Context, session *PlayerSession, game *CurrentGameInfo) (int, bool, error) {
+	streamURL := fmt.Sprintf("http://%s/rooms/%s/events?playerId=%s&after=%d", game.ServiceAddr, game.RoomID, url.QueryEscape(session.ID), game.LastEventSeq)
+	readCtx, cancelRead := context.WithCancel(ctx)
+	defer cancelRead()
+	idle := time.AfterFunc(streamIdleTimeout, cancelRead)
+	defer idle.Stop()
+
+	req, err := http.NewRequestWithContext(readCtx, http.MethodGet, streamURL, nil)
+	if err != nil {
+		return 0, false, err
+	}
+	resp, err := h.streamClient.Do(req)
+	if err != nil {
+		return 0, false, idleError(ctx, readCtx, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return 0, false, errRoomGone
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, false, fmt.Errorf("game room answered %s", resp.Status)
+	}
+
+	received := 0
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), streamMaxLineBytes)
+	for scanner.Scan() {
+		idle.Reset(streamIdleTimeout)
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue // Heartbeat
+		}
+		var event GameEventPayload
+		if err := json.Unmarshal(line, &event); err != nil {
+			return received, false, fmt.Errorf("invalid event in stream: %w", err)
+		}
+		if event.Seq <= game.LastEventSeq {
+			continue // Já entregue antes da reconexão.
+		}
+		if event.Seq != game.LastEventSeq+1 {
+			log.Printf("[GameStream] WARN: Room %s skipped from seq %d to %d for %s.", game.RoomID, game.LastEventSeq, event.Seq, session.ID)
+		}
+		if ctx.Err() != nil {
+			return received, true, nil
+		}
+		game.LastEventSeq = event.Seq
+		received++
+		h.deliverGameEvent(session, event)
+		if event.EventType == "GAME_OVER" {
+			return received, true, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return received, false, idleError(ctx, readCtx, err)
+	}
+	return received, false, errors.New("stream closed before GAME_OVER")
+}
+
+// idleError troca o erro de uma leitura cancelada pelo timeout de inatividade
+// por uma mensagem que diz isso.
+func idleError(ctx, readCtx context.Context, err error) error {
+	if ctx.Err() == nil && readCtx.Err() != nil {
+		return fmt.Errorf("no data from the game room for %v", streamIdleTimeout)
+	}
+	return err
+}
+
+// abandonGame devolve ao lobby um jogador cuja sala não responde mais.
+func (h *GameHandler) abandonGame(session *PlayerSession, game *CurrentGameInfo, cause error) {
+	log.Printf("[GameStream] ERROR: Giving up on room %s for %s: %v", game.RoomID, session.ID, cause)
+	if session.CurrentGame != game {
+		return
+	}
+	session.CurrentGame = nil
+	session.Fairness = nil
+	session.State = state_LOBBY
+	h.clearResume(session.ID, game.RoomID)
+	message.SendErrorAndPrompt(session.Client, "Lost the connection to the game room. You have been returned to the lobby.")
+}
+
+// stopGameStream encerra a leitura dos eventos da partida (ex: no disconnect).
+func (h *GameHandler) stopGameStream(session *PlayerSession) {
+	if session.gameStream != nil {
+		session.gameStream()
+		session.gameStream = nil
+	}
+}
+
+//END OF FILE jokenpo/internal/session/game_stream.go
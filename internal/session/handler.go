@@ -3,13 +3,16 @@ package session
 
 import (
 	"encoding/json"
-	"fmt"
+	"jokenpo/internal/game/card"
 	"jokenpo/internal/network"
 	"jokenpo/internal/services/cluster"
+	"jokenpo/internal/services/presence"
+	"jokenpo/internal/services/ratings"
+	"jokenpo/internal/session/account"
+	"jokenpo/internal/session/storage"
 	"jokenpo/internal/session/message"
 	"log"
 	"net/http"
-	"strings"
 	"time"
 	"jokenpo/internal/services/blockchain"
 )
@@ -20,8 +23,16 @@ type GameHandler struct {
 	sessionsByClient   map[*network.Client]*PlayerSession
 	sessionsByID       map[string]*PlayerSession
 	httpClient         *http.Client
+	streamClient       *http.Client // Sem timeout total: os streams das salas são longos (ver streamIdleTimeout)
 	serviceCache       *cluster.ServiceCacheActor
+	catalogGuard       *cluster.CatalogGuard
 	advertisedHostname string
+	accounts           *account.Store
+	inventories        storage.Repository
+	ratings            *ratings.Store
+	presence           *presence.Store
+	resumes            *presence.ResumeStore
+	authRouter         map[string]CommandHandlerFunc
 	lobbyRouter        map[string]CommandHandlerFunc
 	matchRouter        map[string]CommandHandlerFunc
 	matchQueueRouter   map[string]CommandHandlerFunc
@@ -31,6 +42,15 @@ type GameHandler struct {
 }
 
 func NewGameHandler(manager *cluster.ConsulManager, advertisedHostname string) (*GameHandler, error) {
+	accounts, err := account.NewStoreFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	inventories, err := storage.NewLogRepository(account.DataDir())
+	if err != nil {
+		return nil, err
+	}
+
     var bcClient *blockchain.BlockchainClient
     var contractAddr string
 
@@ -69,6 +89,12 @@ func NewGameHandler(manager *cluster.ConsulManager, advertisedHostname string) (
 		sessionsByClient:   make(map[*network.Client]*PlayerSession),
 		sessionsByID:       make(map[string]*PlayerSession),
 		advertisedHostname: advertisedHostname,
+		streamClient:       &http.Client{},
+		accounts:           accounts,
+		inventories:        inventories,
+		ratings:            ratings.NewStore(manager),
+		presence:           presence.NewStore(manager),
+		authRouter:         make(map[string]CommandHandlerFunc),
 		lobbyRouter:        make(map[string]CommandHandlerFunc),
 		matchRouter:        make(map[string]CommandHandlerFunc),
 		matchQueueRouter:   make(map[string]CommandHandlerFunc),
@@ -77,9 +103,14 @@ func NewGameHandler(manager *cluster.ConsulManager, advertisedHostname string) (
 		blockchain: bcClient,
 	}
 
+	h.resumes = presence.NewResumeStore(h.presence)
 	h.httpClient = &http.Client{ Timeout: 10 * time.Second }
 	h.serviceCache = cluster.NewServiceCacheActor(10*time.Second, manager)
+	h.catalogGuard = cluster.NewCatalogGuard(card.Fingerprint())
+	h.registerAuthHandlers()
 	h.registerLobbyHandlers()
+	h.registerMarketHandlers()
+	h.registerTradeHandlers()
 	h.registerQueueHandlers()
 	h.registerMatchHandlers()
 
@@ -89,46 +120,14 @@ func NewGameHandler(manager *cluster.ConsulManager, advertisedHostname string) (
 // --- Implementação da Interface network.EventHandler ---
 
 func (h *GameHandler) OnConnect(c *network.Client) {
+	// A sessão só ganha um ID (e entra em sessionsByID) depois do LOGIN/REGISTER.
 	session := NewPlayerSession(c)
 	h.sessionsByClient[c] = session
-	h.sessionsByID[session.ID] = session
 
 	log.Printf("Session created for %s. Total sessions: %d", c.Conn().RemoteAddr(), len(h.sessionsByClient))
 
-	const initialPacksToOpen = 4
-	// O helper purchasePacksFromShop já envia o ID para o Shop registrar na blockchain
-	initialCardKeys, err := h.purchasePacksFromShop(session.ID, initialPacksToOpen)
-	if err != nil {
-		log.Printf("CRITICAL: Failed to grant initial packs to player %s: %v", c.Conn().RemoteAddr(), err)
-		welcomeMsg := "Welcome to the Jokenpo Game!\n\nCould not grant initial packs due to shop error."
-		message.SendSuccessAndPrompt(c, state_LOBBY, "Connection successful!", welcomeMsg)
-		return
-	}
-
-	for _, key := range initialCardKeys {
-		if err := session.Player.AddCardToCollection(key, 1); err != nil {
-			message.SendSuccessAndPrompt(c, state_LOBBY, "Connection successful, but error adding cards", err.Error())
-			return
-		}
-	}
-
-	deckBuildMessage := fmt.Sprintf("All %d initial cards added to collection/deck.", len(initialCardKeys))
-	for i, key := range initialCardKeys {
-		if _, err := session.Player.AddCardToDeck(key); err != nil {
-			deckBuildMessage = fmt.Sprintf("Error building deck after %d cards: %v", i, err)
-			break
-		}
-	}
-
-	var sb strings.Builder
-	sb.WriteString("Welcome to the Jokenpo Game!\n")
-	sb.WriteString(fmt.Sprintf("As a bonus, you received %d card packs:\n", initialPacksToOpen))
-	for i, key := range initialCardKeys {
-		sb.WriteString(fmt.Sprintf("[%d] - %s \n", i, key))
-	}
-	sb.WriteString("\n" + deckBuildMessage)
-
-	message.SendSuccessAndPrompt(c, state_LOBBY, "Connection successful! Welcome!", sb.String())
+	message.SendSuccessAndPrompt(c, session.State, "Connection successful!",
+		"Welcome to the Jokenpo Game!\nPlease LOGIN or REGISTER to continue.")
 }
 
 func (h *GameHandler) OnDisconnect(c *network.Client) {
@@ -138,10 +137,27 @@ func (h *GameHandler) OnDisconnect(c *network.Client) {
 	if session.State == state_IN_MATCH_QUEUE {
 		h.leaveMatchQueue(session)
 	} else if session.State == state_IN_TRADE_QUEUE {
-		h.leaveTradeQueue(session)
+		// Se a oferta ainda não foi pareada, a carta volta antes de o inventário ser salvo;
+		// senão a troca segue e o resultado fica no escrow até o próximo login.
+		if h.leaveTradeQueue(session) == nil {
+			h.deliverTradeEscrows(session)
+		}
 	}
 
+	h.cancelAllListings(session)
+	h.closeAllTrades(session)
+	h.stopGameStream(session)
+	h.suspendMatch(session)
+
 	delete(h.sessionsByClient, c)
+	if session.ID == "" {
+		log.Printf("Unauthenticated session for %s removed.", c.Conn().RemoteAddr())
+		return
+	}
+	h.persistInventory(session)
+	if err := h.presence.Withdraw(session.ID, h.buildCallbackURL(session, "")); err != nil {
+		log.Printf("WARN: Failed to withdraw presence of player %s: %v", session.ID, err)
+	}
 	delete(h.sessionsByID, session.ID)
 	log.Printf("Session %s removed.", session.ID)
 }
@@ -152,6 +168,7 @@ func (h *GameHandler) OnMessage(c *network.Client, msg network.Message) {
 
 	var router map[string]CommandHandlerFunc
 	switch session.State {
+	case state_AUTH: router = h.authRouter
 	case state_LOBBY: router = h.lobbyRouter
 	case state_IN_MATCH: router = h.matchRouter
 	case state_IN_MATCH_QUEUE: router = h.matchQueueRouter
@@ -4,6 +4,7 @@ package session
 import (
 	"encoding/json"
 	"jokenpo/internal/session/message"
+	"strings"
 )
 
 // handleLeaveQueue processa o comando do jogador para sair de qualquer fila em que ele esteja.
@@ -21,13 +22,7 @@ func handleLeaveQueue(h *GameHandler, session *PlayerSession, payload json.RawMe
 	if currentState == state_IN_MATCH_QUEUE {
 		err = h.leaveMatchQueue(session)
 	} else if currentState == state_IN_TRADE_QUEUE {
-		// ROLLBACK da carta: Antes de sair da fila, a carta que o jogador
-		// ofereceu precisa ser devolvida à sua coleção.
-		// Precisamos saber qual carta era. Isso deve ser armazenado na sessão.
-		// (Vamos adicionar isso no próximo passo).
-		// Por enquanto, vamos assumir que recuperamos a carta e a devolvemos.
-		// session.Player.Inventory().Collection().AddCard(session.OfferedCardForTrade, 1)
-
+		// O Queue devolve a carta oferecida ao escrow; ela é creditada logo abaixo.
 		err = h.leaveTradeQueue(session)
 	}
 	
@@ -40,12 +35,19 @@ func handleLeaveQueue(h *GameHandler, session *PlayerSession, payload json.RawMe
 
 	// 4. Apenas se a chamada de API foi bem-sucedida, muda o estado do jogador de volta para o lobby.
 	session.State = state_LOBBY
+
+	var data any
+	if currentState == state_IN_TRADE_QUEUE {
+		if notes := h.deliverTradeEscrows(session); len(notes) > 0 {
+			data = strings.Join(notes, "\n")
+		}
+	}
 	
 	message.SendSuccessAndPrompt(
 		session.Client,
 		session.State,
 		"You have successfully left the queue and returned to the lobby.",
-		nil,
+		data,
 	)
 }
 
@@ -0,0 +1,188 @@
+//START OF FILE jokenpo/internal/session/handlers_auth.go
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"jokenpo/internal/services/presence"
+	"jokenpo/internal/session/account"
+	"jokenpo/internal/session/message"
+	"log"
+	"strings"
+)
+
+// initialPacksToOpen é o bônus de boas-vindas, concedido uma única vez por conta.
+const initialPacksToOpen = 4
+
+type credentialsRequest struct {
+	Username    string `json:"username"`
+	Password    string `json:"password"`
+	ResumeToken string `json:"resumeToken,omitempty"` // Só no LOGIN: volta à partida interrompida por uma queda
+}
+
+func parseCredentials(payload json.RawMessage) (credentialsRequest, error) {
+	var req credentialsRequest
+	if err := json.Unmarshal(payload, &req); err != nil || req.Username == "" || req.Password == "" {
+		return req, fmt.Errorf("invalid payload: 'username' and 'password' are required")
+	}
+	return req, nil
+}
+
+func handleLogin(h *GameHandler, session *PlayerSession, payload json.RawMessage) {
+	req, err := parseCredentials(payload)
+	if err != nil {
+		message.SendErrorAndPrompt(session.Client, "%v", err)
+		return
+	}
+	acc, err := h.accounts.Authenticate(req.Username, req.Password)
+	if err != nil {
+		message.SendErrorAndPrompt(session.Client, "Login failed: %v", err)
+		return
+	}
+	h.completeLogin(session, acc)
+	if req.ResumeToken != "" && session.ID == acc.ID {
+		h.resumeMatch(session, req.ResumeToken)
+	}
+}
+
+func handleRegister(h *GameHandler, session *PlayerSession, payload json.RawMessage) {
+	req, err := parseCredentials(payload)
+	if err != nil {
+		message.SendErrorAndPrompt(session.Client, "%v", err)
+		return
+	}
+	acc, err := h.accounts.Register(req.Username, req.Password)
+	if err != nil {
+		message.SendErrorAndPrompt(session.Client, "Registration failed: %v", err)
+		return
+	}
+	log.Printf("New account '%s' registered with ID %s", acc.Username, acc.ID)
+	h.completeLogin(session, acc)
+}
+
+// completeLogin associa a conta à sessão: carrega o inventário salvo, concede os
+// pacotes iniciais se a conta ainda não os recebeu e leva o jogador ao lobby.
+// A presença no Consul é a trava entre os nós: só carrega o inventário quem a adquiriu.
+func (h *GameHandler) completeLogin(session *PlayerSession, acc *account.Account) {
+	if _, online := h.sessionsByID[acc.ID]; online {
+		message.SendErrorAndPrompt(session.Client, "Account '%s' is already connected.", acc.Username)
+		return
+	}
+	nodeURL := h.buildCallbackURL(session, "")
+	if err := h.presence.Claim(acc.ID, nodeURL); err != nil {
+		if errors.Is(err, presence.ErrAlreadyConnected) {
+			message.SendErrorAndPrompt(session.Client, "Account '%s' is already connected on another server.", acc.Username)
+			return
+		}
+		log.Printf("ERROR: Failed to claim presence of player %s: %v", acc.ID, err)
+		message.SendErrorAndPrompt(session.Client, "Could not log you in right now. Please try again later.")
+		return
+	}
+
+	p, delivered, err := h.loadPlayer(acc.ID)
+	if err != nil {
+		log.Printf("ERROR: Failed to load inventory of player %s: %v", acc.ID, err)
+		if err := h.presence.Withdraw(acc.ID, nodeURL); err != nil {
+			log.Printf("WARN: Failed to withdraw presence of player %s: %v", acc.ID, err)
+		}
+		message.SendErrorAndPrompt(session.Client, "Could not load your inventory. Please try again later.")
+		return
+	}
+
+	// O bônus inicial é marcado na conta antes de o Shop vender os pacotes. Se a
+	// marca não puder ser gravada, o login falha: senão cada login daria os pacotes de novo.
+	grantStarter := !acc.StarterPacksGranted
+	if grantStarter {
+		acc.StarterPacksGranted = true
+		if err := h.accounts.Update(acc); err != nil {
+			log.Printf("ERROR: Failed to mark starter packs for player %s: %v", acc.ID, err)
+			if err := h.presence.Withdraw(acc.ID, nodeURL); err != nil {
+				log.Printf("WARN: Failed to withdraw presence of player %s: %v", acc.ID, err)
+			}
+			message.SendErrorAndPrompt(session.Client, "Could not log you in right now. Please try again later.")
+			return
+		}
+	}
+
+	session.ID = acc.ID
+	session.Account = acc
+	session.Player = p
+	for _, id := range delivered {
+		session.markDelivered(id)
+	}
+	session.State = state_LOBBY
+	h.sessionsByID[session.ID] = session
+	log.Printf("Player '%s' (%s) logged in from %s.", acc.Username, acc.ID, session.Client.Conn().RemoteAddr())
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Welcome to the Jokenpo Game, %s!\n", acc.Username))
+	sb.WriteString(fmt.Sprintf("Your player ID is %s (share it to receive trade proposals).\n", acc.ID))
+	if grantStarter {
+		sb.WriteString(h.grantStarterPacks(session))
+	}
+	// Trocas do Wonder Trade e trocas diretas que terminaram enquanto o jogador estava fora.
+	for _, note := range h.deliverTradeEscrows(session) {
+		sb.WriteString("\n" + note)
+	}
+	for _, note := range h.deliverTradeOutcomes(session) {
+		sb.WriteString("\n" + note)
+	}
+	// Ofertas do mercado que continuam abertas e as que terminaram enquanto ele estava fora.
+	h.restoreListings(session)
+	for _, note := range h.deliverMarketOutcomes(session) {
+		sb.WriteString("\n" + note)
+	}
+
+	message.SendSuccessAndPrompt(session.Client, session.State, "Login successful! Welcome!", sb.String())
+}
+
+// grantStarterPacks compra os pacotes iniciais e monta o deck com eles. A conta já
+// foi marcada no login; se o Shop falhar, a marca é desfeita e o bônus é tentado
+// no próximo login.
+func (h *GameHandler) grantStarterPacks(session *PlayerSession) string {
+	// O helper purchasePacksFromShop já envia o ID para o Shop registrar na blockchain
+	initialCardKeys, err := h.purchasePacksFromShop(session.ID, initialPacksToOpen)
+	if err != nil {
+		log.Printf("CRITICAL: Failed to grant initial packs to player %s: %v", session.ID, err)
+		session.Account.StarterPacksGranted = false
+		if err := h.accounts.Update(session.Account); err != nil {
+			log.Printf("ERROR: Failed to unmark starter packs for player %s; they will not be granted again: %v", session.ID, err)
+			return "\nCould not grant initial packs due to shop error."
+		}
+		return "\nCould not grant initial packs due to shop error. They will be granted on your next login."
+	}
+
+	// Os pacotes já foram vendidos: uma carta que não entra é registrada, mas não
+	// impede as outras de serem creditadas e gravadas.
+	for _, key := range initialCardKeys {
+		if err := session.Player.AddCardToCollection(key, 1); err != nil {
+			log.Printf("CRITICAL: Failed to credit starter card '%s' to player %s: %v", key, session.ID, err)
+		}
+	}
+
+	deckBuildMessage := fmt.Sprintf("All %d initial cards added to collection/deck.", len(initialCardKeys))
+	for i, key := range initialCardKeys {
+		if _, err := session.Player.AddCardToDeck(key); err != nil {
+			deckBuildMessage = fmt.Sprintf("Error building deck after %d cards: %v", i, err)
+			break
+		}
+	}
+
+	h.persistInventory(session)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("As a bonus, you received %d card packs:\n", initialPacksToOpen))
+	for i, key := range initialCardKeys {
+		sb.WriteString(fmt.Sprintf("[%d] - %s \n", i, key))
+	}
+	sb.WriteString("\n" + deckBuildMessage)
+	return sb.String()
+}
+
+func (h *GameHandler) registerAuthHandlers() {
+	h.authRouter["LOGIN"] = handleLogin
+	h.authRouter["REGISTER"] = handleRegister
+}
+
+//END OF FILE jokenpo/internal/session/handlers_auth.go
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"jokenpo/internal/game/deck"
+	"jokenpo/internal/game/rules"
 	"jokenpo/internal/session/message"
 	"strings"
 )
@@ -18,6 +19,29 @@ func handleFindMatch(h *GameHandler, session *PlayerSession, payload json.RawMes
 		return
 	}
 
+	// O payload é opcional: sem ele, o jogador entra na fila do ruleset padrão,
+	// em partida única.
+	var req struct {
+		Ruleset string `json:"ruleset"`
+		BestOf  int    `json:"bestOf"`
+	}
+	if len(payload) > 0 {
+		if err := json.Unmarshal(payload, &req); err != nil {
+			message.SendErrorAndPrompt(session.Client, "Invalid payload: 'ruleset' must be a string and 'bestOf' a number.")
+			return
+		}
+	}
+	ruleset, err := rules.Get(req.Ruleset)
+	if err != nil {
+		message.SendErrorAndPrompt(session.Client, "Cannot join match queue: %v", err)
+		return
+	}
+	bestOf, err := rules.NormalizeBestOf(req.BestOf)
+	if err != nil {
+		message.SendErrorAndPrompt(session.Client, "Cannot join match queue: %v", err)
+		return
+	}
+
 	deckJSON, err := session.Player.Inventory().GameDeck().ToJSON()
 	if err != nil {
 		message.SendErrorAndPrompt(session.Client, "Failed to prepare your deck for matchmaking: %v", err)
@@ -29,14 +53,20 @@ func handleFindMatch(h *GameHandler, session *PlayerSession, payload json.RawMes
 		return
 	}
 
-	err = h.enterMatchQueue(session, deckKeys)
+	err = h.enterMatchQueue(session, deckKeys, ruleset.Name(), bestOf)
 	if err != nil {
 		message.SendErrorAndPrompt(session.Client, "Failed to join match queue: %v", err)
 		return
 	}
 
+	queueName := ruleset.Name()
+	if bestOf > 1 {
+		queueName = fmt.Sprintf("%s, best of %d", ruleset.Name(), bestOf)
+	}
 	session.State = state_IN_MATCH_QUEUE
-	message.SendSuccessAndPrompt(session.Client, session.State, "You have been added to the matchmaking queue. Searching for an opponent...", nil)
+	message.SendSuccessAndPrompt(session.Client, session.State,
+		fmt.Sprintf("You have been added to the '%s' matchmaking queue. Searching for an opponent...", queueName),
+		ruleset.Description())
 }
 
 //Opção 2
@@ -63,10 +93,12 @@ func handleTradeCard(h *GameHandler, session *PlayerSession, payload json.RawMes
 		message.SendErrorAndPrompt(session.Client, "An internal error occurred while preparing the trade: %v", err)
 		return
 	}
+	h.persistInventory(session)
 
 	err := h.enterTradeQueue(session, req.CardKey)
 	if err != nil {
 		session.Player.Inventory().Collection().AddCard(req.CardKey, 1)
+		h.persistInventory(session)
 		message.SendErrorAndPrompt(session.Client, "Failed to join trade queue: %v", err)
 		return
 	}
@@ -110,11 +142,13 @@ func handlePurchasePackage(h *GameHandler, session *PlayerSession, payload json.
 	addedCards := []string{}
 	for _, key := range cardKeys {
 		if err := session.Player.Inventory().Collection().AddCard(key, 1); err != nil {
+			h.persistInventory(session)
 			message.SendErrorAndPrompt(session.Client, "Failed to add card '%s' to your collection: %v", key, err)
 			return
 		}
 		addedCards = append(addedCards, key)
 	}
+	h.persistInventory(session)
 
 	// 5. Formatação e Resposta de Sucesso
 	var sb strings.Builder
@@ -198,6 +232,7 @@ func handleAddCardToDeck(h *GameHandler, session *PlayerSession, payload json.Ra
 		session.Client.Send() <- message.CreatePromptInputMessage()
 		return
 	}
+	h.persistInventory(session)
 	session.Client.Send() <- message.CreateSuccessResponse(session.State, result, nil)
 	session.Client.Send() <- message.CreatePromptInputMessage()
 }
@@ -247,6 +282,7 @@ func handleRemoveCardFromDeck(h *GameHandler, session *PlayerSession, payload js
 		session.Client.Send() <- message.CreatePromptInputMessage()
 		return
 	}
+	h.persistInventory(session)
 	session.Client.Send() <- message.CreateSuccessResponse(session.State, result, nil)
 	session.Client.Send() <- message.CreatePromptInputMessage()
 }
@@ -296,6 +332,7 @@ func handleReplaceCardToDeck(h *GameHandler, session *PlayerSession, payload jso
 		session.Client.Send() <- message.CreatePromptInputMessage()
 		return
 	}
+	h.persistInventory(session)
 	session.Client.Send() <- message.CreateSuccessResponse(session.State, result, nil)
 	session.Client.Send() <- message.CreatePromptInputMessage()
 
@@ -327,6 +364,73 @@ func handleViewAuditLogs(h *GameHandler, session *PlayerSession, payload json.Ra
 	)
 }
 
+//Opção 11
+// handleSyncFromLedger reconstrói a coleção do jogador a partir dos tokens do contrato
+// e informa a diferença (drift) em relação à coleção que o Session tinha.
+// Cartas em custódia (ofertas, propostas, Wonder Trade) ou com resultado ainda não
+// creditado estão fora da coleção local, mas continuam do jogador no ledger: a
+// coleção do ledger as traria de volta e a devolução as creditaria de novo. Por
+// isso o sync é recusado enquanto o Queue ainda prende alguma carta do jogador.
+func handleSyncFromLedger(h *GameHandler, session *PlayerSession, payload json.RawMessage) {
+	if !checkLobbyState(session) {
+		message.SendErrorAndPrompt(session.Client, "You are not in lobby")
+		return
+	}
+
+	if h.blockchain == nil {
+		message.SendErrorAndPrompt(session.Client, "Blockchain service is currently unavailable.")
+		return
+	}
+
+	custody, err := h.queueCustody(session)
+	if err != nil {
+		message.SendErrorAndPrompt(session.Client, "Could not check your open trades, try again later: %v", err)
+		return
+	}
+	if custody.Listings+custody.Proposals+custody.Escrows+custody.Undelivered > 0 {
+		message.SendErrorAndPrompt(session.Client,
+			"Some of your cards are still in trades (%d market listings, %d trade proposals, %d Wonder Trade offers, %d results being delivered). Finish or cancel them before syncing with the ledger.",
+			custody.Listings, custody.Proposals, custody.Escrows, custody.Undelivered)
+		return
+	}
+
+	synced, drift, dropped, err := rebuildFromLedger(h.blockchain, session.ID, session.Player)
+	if err != nil {
+		message.SendErrorAndPrompt(session.Client, "Failed to rebuild your collection from the ledger: %v", err)
+		return
+	}
+	session.Player = synced
+	h.persistInventory(session)
+
+	report := drift.String()
+	if len(dropped) > 0 {
+		report += fmt.Sprintf("\nRemoved from deck (no longer in collection): %s", strings.Join(dropped, ", "))
+	}
+	message.SendSuccessAndPrompt(session.Client, session.State, "Collection synchronized with the ledger.", report)
+}
+
+//Opção 12
+// handleViewRating mostra o rating Glicko-2 do jogador. Quem o grava é o GameRoom,
+// ao fim de cada partida; o Queue usa o mesmo valor para parear jogadores parecidos.
+func handleViewRating(h *GameHandler, session *PlayerSession, payload json.RawMessage) {
+	if !checkLobbyState(session) {
+		message.SendErrorAndPrompt(session.Client, "You are not in lobby")
+		return
+	}
+
+	r, err := h.ratings.Get(session.ID)
+	if err != nil {
+		message.SendErrorAndPrompt(session.Client, "Failed to fetch your rating: %v", err)
+		return
+	}
+
+	report := fmt.Sprintf("Rating: %.0f (±%.0f)\nMatches rated: %d", r.Rating, 2*r.Deviation, r.Games)
+	if r.Games == 0 {
+		report += "\nPlay a match to get your first rating update."
+	}
+	message.SendSuccessAndPrompt(session.Client, session.State, "Your matchmaking rating:", report)
+}
+
 func (h *GameHandler) registerLobbyHandlers() {
 	h.lobbyRouter["FIND_MATCH"] = handleFindMatch
 	h.lobbyRouter["TRADE_CARD"] = handleTradeCard
@@ -337,6 +441,8 @@ func (h *GameHandler) registerLobbyHandlers() {
 	h.lobbyRouter["REMOVE_CARD_FROM_DECK"] = handleRemoveCardFromDeck
 	h.lobbyRouter["REPLACE_CARD_TO_DECK"] = handleReplaceCardToDeck
 	h.lobbyRouter["VIEW_AUDIT"] = handleViewAuditLogs
+	h.lobbyRouter["SYNC_FROM_LEDGER"] = handleSyncFromLedger
+	h.lobbyRouter["VIEW_RATING"] = handleViewRating
 }
 
 func checkLobbyState(session *PlayerSession) bool {
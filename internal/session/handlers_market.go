@@ -0,0 +1,364 @@
+//START OF FILE jokenpo/internal/session/handlers_market.go
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"jokenpo/internal/game/card"
+	"jokenpo/internal/session/message"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// MarketListing é a visão local de uma oferta do jogador no mercado de trocas.
+// A carta oferecida sai da coleção ao publicar. O que volta ou chega (troca feita,
+// cancelamento, prazo) vem sempre de um ListingOutcome recolhido no Queue, que
+// guarda as ofertas e os resultados; no login a lista local é refeita a partir dele.
+type MarketListing struct {
+	ID        string   `json:"id"`
+	Offer     string   `json:"offer"`
+	Want      []string `json:"want,omitempty"`
+	MatchID   string   `json:"matchId,omitempty"`   // Par encontrado, aguardando ACCEPT/DECLINE
+	Receive   string   `json:"receive,omitempty"`   // Carta que o par oferece
+	PartnerID string   `json:"partnerId,omitempty"` // Dono da outra oferta
+}
+
+type listingIndexRequest struct {
+	Listing *int `json:"listing"`
+}
+
+// listingFromPayload resolve o índice (como mostrado no VIEW_LISTINGS) para a oferta do jogador.
+func listingFromPayload(session *PlayerSession, payload json.RawMessage) (*MarketListing, error) {
+	var req listingIndexRequest
+	if err := json.Unmarshal(payload, &req); err != nil || req.Listing == nil {
+		return nil, fmt.Errorf("invalid payload: 'listing' (index) is required")
+	}
+	if *req.Listing < 0 || *req.Listing >= len(session.Listings) {
+		return nil, fmt.Errorf("you have no listing #%d", *req.Listing)
+	}
+	return session.Listings[*req.Listing], nil
+}
+
+func (s *PlayerSession) listingByID(id string) (int, *MarketListing) {
+	for i, l := range s.Listings {
+		if l.ID == id {
+			return i, l
+		}
+	}
+	return -1, nil
+}
+
+func (s *PlayerSession) removeListing(id string) {
+	if i, _ := s.listingByID(id); i >= 0 {
+		s.Listings = append(s.Listings[:i], s.Listings[i+1:]...)
+	}
+}
+
+// Opção 13
+// handleListTrade publica uma oferta "dou 'offer', aceito qualquer uma de 'want'".
+func handleListTrade(h *GameHandler, session *PlayerSession, payload json.RawMessage) {
+	if !checkLobbyState(session) {
+		message.SendErrorAndPrompt(session.Client, "You must be in the lobby to list a trade.")
+		return
+	}
+	var req struct {
+		Offer string   `json:"offer"`
+		Want  []string `json:"want"`
+	}
+	if err := json.Unmarshal(payload, &req); err != nil || req.Offer == "" {
+		message.SendErrorAndPrompt(session.Client, "Invalid payload: 'offer' is required and 'want' must be a list of card keys.")
+		return
+	}
+	for _, key := range req.Want {
+		if _, err := card.GetCard(key); err != nil {
+			message.SendErrorAndPrompt(session.Client, "Cannot list trade: %v", err)
+			return
+		}
+	}
+	if err := session.Player.Inventory().HasCardInCollection(req.Offer, 1); err != nil {
+		message.SendErrorAndPrompt(session.Client, "Cannot list trade: %v", err)
+		return
+	}
+
+	// A carta fica reservada enquanto a oferta existir.
+	if err := session.Player.Inventory().Collection().RemoveCard(req.Offer, 1); err != nil {
+		message.SendErrorAndPrompt(session.Client, "An internal error occurred while preparing the listing: %v", err)
+		return
+	}
+	h.persistInventory(session)
+
+	resp, err := h.createListing(session, req.Offer, req.Want)
+	if err != nil {
+		if !marketNotApplied(err) {
+			// A oferta pode ter sido criada: a carta volta pelo resultado dela.
+			message.SendErrorAndPrompt(session.Client, "The trade market did not answer (%v). If the listing was published, '%s' comes back when it ends; it shows up on your next login.", err, req.Offer)
+			return
+		}
+		session.Player.Inventory().Collection().AddCard(req.Offer, 1)
+		h.persistInventory(session)
+		message.SendErrorAndPrompt(session.Client, "Failed to publish listing: %v", err)
+		return
+	}
+	session.Listings = append(session.Listings, &MarketListing{ID: resp.ListingID, Offer: req.Offer, Want: req.Want})
+
+	want := "any card"
+	if len(req.Want) > 0 {
+		want = strings.Join(req.Want, " or ")
+	}
+	message.SendSuccessAndPrompt(session.Client, session.State,
+		fmt.Sprintf("Listing #%d published: offering '%s' for %s.", len(session.Listings)-1, req.Offer, want),
+		fmt.Sprintf("It expires at %s. You will be asked to accept when a compatible offer is found.", resp.ExpiresAt.Format("15:04:05")))
+}
+
+// Opção 14
+// handleViewListings mostra as ofertas do jogador e o que está aberto no mercado.
+func handleViewListings(h *GameHandler, session *PlayerSession, payload json.RawMessage) {
+	if !checkLobbyState(session) {
+		message.SendErrorAndPrompt(session.Client, "You are not in lobby")
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("--- Your listings ---\n")
+	if len(session.Listings) == 0 {
+		sb.WriteString("(none)\n")
+	}
+	for i, l := range session.Listings {
+		sb.WriteString(fmt.Sprintf("[%d] give %s, want %v", i, l.Offer, l.Want))
+		switch {
+		case l.MatchID != "" && l.PartnerID != "":
+			sb.WriteString(fmt.Sprintf(" -> MATCHED: receive %s from %s (ACCEPT or DECLINE)", l.Receive, l.PartnerID))
+		case l.MatchID != "":
+			sb.WriteString(" -> MATCHED (ACCEPT or DECLINE)")
+		}
+		sb.WriteString("\n")
+	}
+
+	listings, err := h.browseListings()
+	if err != nil {
+		sb.WriteString(fmt.Sprintf("\nCould not load the market: %v\n", err))
+	} else {
+		sb.WriteString("\n--- Open listings ---\n")
+		for _, l := range listings {
+			if l.PlayerID == session.ID || l.State != "open" {
+				continue
+			}
+			want := "any"
+			if len(l.Want) > 0 {
+				want = strings.Join(l.Want, ", ")
+			}
+			sb.WriteString(fmt.Sprintf("%s offers %s, wants %s\n", l.PlayerID, l.Offer, want))
+		}
+	}
+	message.SendSuccessAndPrompt(session.Client, session.State, "Trade market:", sb.String())
+}
+
+// Opção 15
+func handleCancelListing(h *GameHandler, session *PlayerSession, payload json.RawMessage) {
+	if !checkLobbyState(session) {
+		message.SendErrorAndPrompt(session.Client, "You are not in lobby")
+		return
+	}
+	listing, err := listingFromPayload(session, payload)
+	if err != nil {
+		message.SendErrorAndPrompt(session.Client, "%v", err)
+		return
+	}
+	err = h.cancelListing(session, listing.ID)
+	switch {
+	case marketStatus(err) == http.StatusConflict:
+		message.SendErrorAndPrompt(session.Client, "Cannot cancel listing: %v", err)
+		return
+	case err != nil && marketStatus(err) != http.StatusNotFound:
+		// O cancelamento pode ter entrado; a carta volta pelo resultado da oferta.
+		message.SendErrorAndPrompt(session.Client, "Failed to cancel listing: %v", err)
+		return
+	}
+	// Cancelada agora ou já encerrada no Queue: o resultado traz a carta.
+	notes := h.deliverMarketOutcomes(session)
+	if len(notes) == 0 {
+		notes = []string{"The listing is closed. Its result will be delivered as soon as the market has it."}
+	}
+	message.SendSuccessAndPrompt(session.Client, session.State, "Listing cancelled.", strings.Join(notes, "\n"))
+}
+
+// Opções 16 e 17
+func handleAcceptListing(h *GameHandler, session *PlayerSession, payload json.RawMessage) {
+	h.respondToListingMatch(session, payload, true)
+}
+
+func handleDeclineListing(h *GameHandler, session *PlayerSession, payload json.RawMessage) {
+	h.respondToListingMatch(session, payload, false)
+}
+
+func (h *GameHandler) respondToListingMatch(session *PlayerSession, payload json.RawMessage, accept bool) {
+	if !checkLobbyState(session) {
+		message.SendErrorAndPrompt(session.Client, "You are not in lobby")
+		return
+	}
+	listing, err := listingFromPayload(session, payload)
+	if err != nil {
+		message.SendErrorAndPrompt(session.Client, "%v", err)
+		return
+	}
+	if listing.MatchID == "" {
+		message.SendErrorAndPrompt(session.Client, "Listing for '%s' has no trade waiting for an answer.", listing.Offer)
+		return
+	}
+	if err := h.respondListingMatch(session, listing.MatchID, accept); err != nil {
+		message.SendErrorAndPrompt(session.Client, "Failed to answer the trade: %v", err)
+		return
+	}
+	if accept {
+		message.SendSuccessAndPrompt(session.Client, session.State, "Trade accepted.",
+			fmt.Sprintf("Waiting for %s to accept: your '%s' for their '%s'.", listing.PartnerID, listing.Offer, listing.Receive))
+		return
+	}
+	message.SendSuccessAndPrompt(session.Client, session.State, "Trade declined.", "Your listing is open again.")
+}
+
+// deliverMarketOutcomes recolhe os resultados das ofertas do jogador e credita a
+// carta de cada um. Retorna uma linha por resultado para mostrar ao jogador.
+func (h *GameHandler) deliverMarketOutcomes(session *PlayerSession) []string {
+	session.deliveryMu.Lock()
+	defer session.deliveryMu.Unlock()
+	outcomes, err := h.collectListingOutcomes(session)
+	if err != nil {
+		log.Printf("WARN: Could not collect market outcomes of player %s: %v", session.ID, err)
+		return nil
+	}
+	notes := make([]string, 0, len(outcomes))
+	ids := make([]string, len(outcomes))
+	for i, o := range outcomes {
+		ids[i] = o.ID
+		session.removeListing(o.ListingID)
+	}
+	h.creditOnce(session, "/market/outcomes/ack", ids, func(i int) {
+		o := outcomes[i]
+		if err := session.Player.Inventory().Collection().AddCard(o.Credit, 1); err != nil {
+			log.Printf("CRITICAL: Failed to credit card '%s' of listing %s to player %s: %v", o.Credit, o.ListingID, session.ID, err)
+			return
+		}
+		switch o.EventType {
+		case "LISTING_SETTLED":
+			notes = append(notes, fmt.Sprintf("Trade Completed! You sent '%s' to %s and received '%s'.", o.Give, o.PartnerID, o.Receive))
+		case "LISTING_EXPIRED":
+			notes = append(notes, fmt.Sprintf("Listing for '%s' expired without a trade. The card is back in your collection.", o.Give))
+		case "LISTING_CANCELLED":
+			notes = append(notes, fmt.Sprintf("Listing for '%s' was cancelled. The card is back in your collection.", o.Give))
+		default:
+			notes = append(notes, fmt.Sprintf("Trade of '%s' failed: %s. The card is back in your collection.", o.Give, o.Reason))
+		}
+	})
+	return notes
+}
+
+// restoreListings refaz a lista local com as ofertas que o jogador ainda tem no
+// Queue (ex: um cancelamento que ficou sem resposta antes de ele sair).
+func (h *GameHandler) restoreListings(session *PlayerSession) {
+	listings, err := h.browseListings()
+	if err != nil {
+		log.Printf("WARN: Could not restore market listings of player %s: %v", session.ID, err)
+		return
+	}
+	for _, l := range listings {
+		if l.PlayerID == session.ID {
+			session.Listings = append(session.Listings, &MarketListing{ID: l.ID, Offer: l.Offer, Want: l.Want, MatchID: l.MatchID})
+		}
+	}
+}
+
+// cancelAllListings retira as ofertas de um jogador que está saindo. As cartas das
+// que foram canceladas voltam já; as outras, pelo resultado, no próximo login.
+func (h *GameHandler) cancelAllListings(session *PlayerSession) {
+	if len(session.Listings) == 0 {
+		return
+	}
+	for _, listing := range session.Listings {
+		if err := h.cancelListing(session, listing.ID); err != nil {
+			log.Printf("WARN: Failed to cancel listing %s of player %s on disconnect: %v", listing.ID, session.ID, err)
+		}
+	}
+	h.deliverMarketOutcomes(session)
+}
+
+// ============================================================================
+// Callback do Mercado (/market-event)
+// ============================================================================
+
+type MarketEventPayload struct {
+	EventType string `json:"eventType"`
+	PlayerID  string `json:"playerId"`
+	ListingID string `json:"listingId"`
+	MatchID   string `json:"matchId,omitempty"`
+	Give      string `json:"give"`
+	Receive   string `json:"receive,omitempty"`
+	PartnerID string `json:"partnerId,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	OutcomeID string `json:"outcomeId,omitempty"`
+}
+
+// CallbackMarketEvent recebe os eventos do mercado. Os resultados que mexem em
+// cartas (OutcomeID preenchido) só avisam: a carta vem do collect.
+func (h *GameHandler) CallbackMarketEvent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var event MarketEventPayload
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		http.Error(w, "Invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	session := h.findSessionByID(event.PlayerID)
+	if session == nil {
+		// 404: o resultado (se houver) continua no Queue até o próximo login.
+		log.Printf("[Callback] Market event %s for listing %s, but player %s is not connected here.", event.EventType, event.ListingID, event.PlayerID)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if event.OutcomeID != "" {
+		// A carta só é creditada pelo collect, como no /trade-found.
+		notes := h.deliverMarketOutcomes(session)
+		w.WriteHeader(http.StatusOK)
+		if len(notes) > 0 {
+			message.SendSuccessAndPrompt(session.Client, session.State, "Trade market update", strings.Join(notes, "\n"))
+		}
+		return
+	}
+	index, listing := session.listingByID(event.ListingID)
+	if listing == nil {
+		log.Printf("WARN: Market event %s for unknown listing %s of player %s.", event.EventType, event.ListingID, event.PlayerID)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	switch event.EventType {
+	case "LISTING_MATCHED":
+		listing.MatchID, listing.Receive, listing.PartnerID = event.MatchID, event.Receive, event.PartnerID
+		message.SendSuccessAndPrompt(session.Client, session.State,
+			fmt.Sprintf("Trade found for listing #%d!", index),
+			fmt.Sprintf("%s will give you '%s' for your '%s'. Use ACCEPT or DECLINE on listing #%d.", event.PartnerID, event.Receive, event.Give, index))
+
+	case "LISTING_REOPENED":
+		listing.MatchID, listing.Receive, listing.PartnerID = "", "", ""
+		message.SendSuccessAndPrompt(session.Client, session.State,
+			fmt.Sprintf("Trade for listing #%d was called off: %s.", index, event.Reason), "Your listing is open again.")
+
+	default:
+		log.Printf("WARN: Unknown market event type '%s'", event.EventType)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *GameHandler) registerMarketHandlers() {
+	h.lobbyRouter["LIST_TRADE"] = handleListTrade
+	h.lobbyRouter["VIEW_LISTINGS"] = handleViewListings
+	h.lobbyRouter["CANCEL_LISTING"] = handleCancelListing
+	h.lobbyRouter["ACCEPT_LISTING"] = handleAcceptListing
+	h.lobbyRouter["DECLINE_LISTING"] = handleDeclineListing
+}
+
+//END OF FILE jokenpo/internal/session/handlers_market.go
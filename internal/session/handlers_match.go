@@ -3,7 +3,10 @@ package session
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"jokenpo/internal/session/message"
+	"strings"
 )
 
 // handlePlayCard processa o comando 'PLAY_CARD' de um jogador.
@@ -36,10 +39,187 @@ func handlePlayCard(h *GameHandler, session *PlayerSession, payload json.RawMess
 	// pois a confirmação virá via callback do GameRoomService.
 }
 
+// handleViewMatch processa o comando 'VIEW_MATCH': mostra o estado atual da
+// partida a partir do snapshot da sala.
+func handleViewMatch(h *GameHandler, session *PlayerSession, payload json.RawMessage) {
+	if session.State != state_IN_MATCH || session.CurrentGame == nil {
+		message.SendErrorAndPrompt(session.Client, "You are not currently in a match.")
+		return
+	}
+	snapshot, err := h.fetchRoomSnapshot(session.CurrentGame, session.ID)
+	if err != nil {
+		message.SendErrorAndPrompt(session.Client, "Failed to fetch the match state: %v", err)
+		return
+	}
+	message.SendSuccessAndPrompt(session.Client, session.State, "Current match state:", formatRoomSnapshot(session.ID, snapshot))
+}
+
+// formatRoomSnapshot monta o relatório do VIEW_MATCH.
+func formatRoomSnapshot(playerID string, snapshot *RoomSnapshot) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Round %d (%s)", snapshot.Round, snapshot.Phase))
+	switch {
+	case snapshot.RoundPaused:
+		sb.WriteString(" - paused, waiting for a player to reconnect\n")
+	case snapshot.TimeLeftMs > 0:
+		sb.WriteString(fmt.Sprintf(" - %.1fs left to play\n", float64(snapshot.TimeLeftMs)/1000))
+	default:
+		sb.WriteString("\n")
+	}
+	if series := snapshot.Series; series != nil {
+		you, opponent := 0, 0
+		for id, wins := range series.Score {
+			if id == playerID {
+				you = wins
+			} else {
+				opponent = wins
+			}
+		}
+		sb.WriteString(fmt.Sprintf("Game %d of a best of %d - series score: you %d x %d opponent\n", series.Game, series.BestOf, you, opponent))
+		if series.NextGameInMs > 0 {
+			sb.WriteString(fmt.Sprintf("Next game in %.0fs. Use SIDEBOARD to change your deck for it.\n", float64(series.NextGameInMs)/1000))
+		}
+	}
+
+	sb.WriteString("\nYour hand:\n")
+	for i, key := range snapshot.Hand {
+		sb.WriteString(fmt.Sprintf("[%d] - %s\n", i, key))
+	}
+	if snapshot.PlayedCard != "" {
+		sb.WriteString(fmt.Sprintf("You played %s this round.\n", snapshot.PlayedCard))
+	}
+	sb.WriteString(fmt.Sprintf("WIN pile: %s\n", strings.Join(snapshot.WinPile, ", ")))
+	sb.WriteString(fmt.Sprintf("OUT pile: %s\n", strings.Join(snapshot.OutPile, ", ")))
+
+	sb.WriteString("\nZones (deck/hand/win/out):\n")
+	for _, seat := range snapshot.Players {
+		name := "Opponent"
+		if seat.PlayerID == playerID {
+			name = "You"
+		}
+		status := ""
+		if seat.Played {
+			status += " [played]"
+		}
+		if !seat.Connected {
+			status += " [disconnected]"
+		}
+		if seat.TimeBankMs > 0 {
+			status += fmt.Sprintf(" [time bank %.1fs]", float64(seat.TimeBankMs)/1000)
+		}
+		sb.WriteString(fmt.Sprintf("%-8s %d/%d/%d/%d%s\n", name, seat.Deck, seat.Hand, seat.Win, seat.Out, status))
+	}
+	return sb.String()
+}
+
+// handleRoomCommand monta os comandos da partida que viram uma ação simples na sala.
+func handleRoomCommand(action, confirmation string) CommandHandlerFunc {
+	return func(h *GameHandler, session *PlayerSession, payload json.RawMessage) {
+		if session.State != state_IN_MATCH || session.CurrentGame == nil {
+			message.SendErrorAndPrompt(session.Client, "You are not currently in a match.")
+			return
+		}
+		if err := h.forwardRoomAction(session.CurrentGame, session.ID, action); err != nil {
+			message.SendErrorAndPrompt(session.Client, "Failed to send your action to the game server: %v", err)
+			return
+		}
+		// O resultado (GAME_OVER, DRAW_OFFERED...) chega pelo stream da sala.
+		message.SendSuccessAndPrompt(session.Client, session.State, confirmation, nil)
+	}
+}
+
+// handleSideboard processa o comando 'SIDEBOARD': troca o deck para o próximo
+// jogo da série. O deck passa pelas mesmas regras da montagem no lobby.
+func handleSideboard(h *GameHandler, session *PlayerSession, payload json.RawMessage) {
+	if session.State != state_IN_MATCH || session.CurrentGame == nil {
+		message.SendErrorAndPrompt(session.Client, "You are not currently in a match.")
+		return
+	}
+	var req struct {
+		Deck []string `json:"deck"`
+	}
+	if err := json.Unmarshal(payload, &req); err != nil || len(req.Deck) == 0 {
+		message.SendErrorAndPrompt(session.Client, "Invalid payload: 'deck' must be a non-empty list of card keys.")
+		return
+	}
+	if err := session.Player.Inventory().ValidateDeck(req.Deck); err != nil {
+		message.SendErrorAndPrompt(session.Client, "Invalid deck: %v", err)
+		return
+	}
+	if err := h.sideboardDeck(session.CurrentGame, session.ID, req.Deck); err != nil {
+		message.SendErrorAndPrompt(session.Client, "Sideboard rejected: %v", err)
+		return
+	}
+	if session.Fairness != nil {
+		session.Fairness.sideboard(req.Deck)
+	}
+	message.SendSuccessAndPrompt(session.Client, session.State, "Deck updated for the next game of the series.", nil)
+}
+
+// handleRequestRematch processa o comando 'REQUEST_REMATCH'. Na partida, o pedido
+// fica registrado para o fim dela; no lobby, vale para a última partida jogada.
+func handleRequestRematch(h *GameHandler, session *PlayerSession, payload json.RawMessage) {
+	var roomID, serviceAddr string
+	var deckKeys []string
+	switch {
+	case session.State == state_IN_MATCH && session.CurrentGame != nil:
+		roomID, serviceAddr = session.CurrentGame.RoomID, session.CurrentGame.ServiceAddr
+		if session.Fairness != nil {
+			deckKeys = session.Fairness.Deck
+		}
+	case session.State == state_LOBBY && session.LastMatch != nil:
+		roomID, serviceAddr, deckKeys = session.LastMatch.RoomID, session.LastMatch.ServiceAddr, session.LastMatch.Deck
+	default:
+		message.SendErrorAndPrompt(session.Client, "There is no match to request a rematch for.")
+		return
+	}
+
+	// A revanche usa o mesmo deck; a sessão manda entropia nova para o embaralhamento.
+	var fairness *MatchFairness
+	if deckKeys != nil {
+		f, err := newMatchFairness(deckKeys)
+		if err != nil {
+			message.SendErrorAndPrompt(session.Client, "Failed to prepare the rematch: %v", err)
+			return
+		}
+		fairness = f
+	}
+	entropy := ""
+	if fairness != nil {
+		entropy = fairness.Entropy
+	}
+
+	status, err := h.requestRematch(serviceAddr, roomID, session.ID, entropy)
+	if errors.Is(err, errRoomGone) {
+		session.LastMatch = nil
+		session.pendingRematch = nil
+		message.SendErrorAndPrompt(session.Client, "The rematch window for that match has closed.")
+		return
+	}
+	if err != nil {
+		message.SendErrorAndPrompt(session.Client, "Failed to request a rematch: %v", err)
+		return
+	}
+	session.pendingRematch = &PendingRematch{RoomID: roomID, Fairness: fairness}
+	if status == "starting" {
+		message.SendSuccessAndPrompt(session.Client, session.State, "Rematch accepted! Entering game room...", nil)
+		return
+	}
+	message.SendSuccessAndPrompt(session.Client, session.State, "Rematch requested. It starts when your opponent also requests it and the match is over.", nil)
+}
+
 func (h *GameHandler) registerMatchHandlers() {
 	if h.matchRouter == nil {
 		h.matchRouter = make(map[string]CommandHandlerFunc)
 	}
 	h.matchRouter["PLAY_CARD"] = handlePlayCard
+	h.matchRouter["VIEW_MATCH"] = handleViewMatch
+	h.matchRouter["SURRENDER"] = handleRoomCommand("surrender", "Surrender sent.")
+	h.matchRouter["OFFER_DRAW"] = handleRoomCommand("offer-draw", "Draw offer sent.")
+	h.matchRouter["ACCEPT_DRAW"] = handleRoomCommand("accept-draw", "Draw acceptance sent.")
+	h.matchRouter["REQUEST_REMATCH"] = handleRequestRematch
+	h.matchRouter["SIDEBOARD"] = handleSideboard
+	// Depois do GAME_OVER o jogador está no lobby, de onde também pode pedir a revanche.
+	h.lobbyRouter["REQUEST_REMATCH"] = handleRequestRematch
 }
 //END OF FILE jokenpo/internal/session/handler_match.go
\ No newline at end of file
@@ -0,0 +1,424 @@
+//START OF FILE jokenpo/internal/session/handlers_trades.go
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"jokenpo/internal/game/card"
+	"jokenpo/internal/session/message"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Sem resposta do Queue, o pedido (retirar a própria proposta ou aceitar uma) é
+// repetido com o mesmo ID até ele responder de vez.
+const (
+	tradeRetryInterval = 5 * time.Second
+	tradeRetryAttempts = 120
+)
+
+// DirectTrade é a visão local de uma troca direta (proposta a um jogador específico).
+// As cartas de Give (do proponente) ficam fora da coleção desde a proposta; as de
+// Receive (do destinatário) desde o aceite. O Queue devolve ou entrega via /trade-event.
+type DirectTrade struct {
+	ID        string
+	FromID    string
+	ToID      string
+	Give      []string
+	Receive   []string
+	ExpiresAt time.Time
+	Accepted  bool // Destinatário aceitou; a troca está sendo registrada no ledger
+}
+
+type tradeIndexRequest struct {
+	Proposal *int `json:"proposal"`
+}
+
+// tradeFromPayload resolve o índice (como mostrado no VIEW_TRADES) para uma troca do jogador.
+func tradeFromPayload(session *PlayerSession, payload json.RawMessage) (*DirectTrade, error) {
+	var req tradeIndexRequest
+	if err := json.Unmarshal(payload, &req); err != nil || req.Proposal == nil {
+		return nil, fmt.Errorf("invalid payload: 'proposal' (index) is required")
+	}
+	if *req.Proposal < 0 || *req.Proposal >= len(session.Trades) {
+		return nil, fmt.Errorf("you have no trade proposal #%d", *req.Proposal)
+	}
+	return session.Trades[*req.Proposal], nil
+}
+
+func (s *PlayerSession) tradeByID(id string) (int, *DirectTrade) {
+	for i, t := range s.Trades {
+		if t.ID == id {
+			return i, t
+		}
+	}
+	return -1, nil
+}
+
+func (s *PlayerSession) removeTrade(id string) {
+	if i, _ := s.tradeByID(id); i >= 0 {
+		s.Trades = append(s.Trades[:i], s.Trades[i+1:]...)
+	}
+}
+
+// escrowCards tira as cartas da coleção (e grava o inventário). Confere todas antes
+// de tirar qualquer uma, então um pacote inválido não mexe na coleção.
+func (h *GameHandler) escrowCards(session *PlayerSession, keys []string) error {
+	counts := make(map[string]uint)
+	for _, key := range keys {
+		if _, err := card.GetCard(key); err != nil {
+			return err
+		}
+		counts[key]++
+	}
+	for key, n := range counts {
+		if err := session.Player.Inventory().HasCardInCollection(key, n); err != nil {
+			return err
+		}
+	}
+	for key, n := range counts {
+		if err := session.Player.Inventory().Collection().RemoveCard(key, n); err != nil {
+			return err
+		}
+	}
+	h.persistInventory(session)
+	return nil
+}
+
+// creditCards devolve (ou entrega) cartas à coleção e grava o inventário.
+func (h *GameHandler) creditCards(session *PlayerSession, keys []string) {
+	h.addCards(session, keys)
+	h.persistInventory(session)
+}
+
+// addCards é o creditCards sem gravar o inventário (quem chama grava depois).
+func (h *GameHandler) addCards(session *PlayerSession, keys []string) {
+	for _, key := range keys {
+		if err := session.Player.Inventory().Collection().AddCard(key, 1); err != nil {
+			log.Printf("CRITICAL: Failed to credit card '%s' to player %s: %v", key, session.ID, err)
+		}
+	}
+}
+
+// Opção 18
+// handleProposeTrade propõe a um jogador específico: "dou 'give', quero 'receive'".
+func handleProposeTrade(h *GameHandler, session *PlayerSession, payload json.RawMessage) {
+	if !checkLobbyState(session) {
+		message.SendErrorAndPrompt(session.Client, "You must be in the lobby to propose a trade.")
+		return
+	}
+	var req struct {
+		TargetPlayerID string   `json:"targetPlayerId"`
+		Give           []string `json:"give"`
+		Receive        []string `json:"receive"`
+	}
+	if err := json.Unmarshal(payload, &req); err != nil || req.TargetPlayerID == "" || len(req.Give) == 0 || len(req.Receive) == 0 {
+		message.SendErrorAndPrompt(session.Client, "Invalid payload: 'targetPlayerId', 'give' and 'receive' (lists of card keys) are required.")
+		return
+	}
+	if req.TargetPlayerID == session.ID {
+		message.SendErrorAndPrompt(session.Client, "You cannot trade with yourself.")
+		return
+	}
+	for _, key := range req.Receive {
+		if _, err := card.GetCard(key); err != nil {
+			message.SendErrorAndPrompt(session.Client, "Cannot propose trade: %v", err)
+			return
+		}
+	}
+
+	// As cartas oferecidas ficam em custódia enquanto a proposta existir.
+	if err := h.escrowCards(session, req.Give); err != nil {
+		message.SendErrorAndPrompt(session.Client, "Cannot propose trade: %v", err)
+		return
+	}
+
+	proposalID := uuid.NewString()
+	resp, err := h.proposeTrade(session, proposalID, req.TargetPlayerID, req.Give, req.Receive)
+	if err != nil {
+		if marketNotApplied(err) {
+			h.creditCards(session, req.Give)
+			message.SendErrorAndPrompt(session.Client, "Failed to propose trade: %v", err)
+			return
+		}
+		// A proposta pode ter sido criada: as cartas só voltam quando o Queue
+		// confirmar a retirada, pelo TradeOutcome ou pela recusa do cancel.
+		h.reconcileTrade(session, proposalID, "cancel", req.Give)
+		message.SendErrorAndPrompt(session.Client, "The trade market did not answer (%v). The proposal is being withdrawn; your cards will be returned.", err)
+		return
+	}
+	session.Trades = append(session.Trades, &DirectTrade{
+		ID:        resp.ProposalID,
+		FromID:    session.ID,
+		ToID:      req.TargetPlayerID,
+		Give:      req.Give,
+		Receive:   req.Receive,
+		ExpiresAt: resp.ExpiresAt,
+	})
+	message.SendSuccessAndPrompt(session.Client, session.State,
+		fmt.Sprintf("Trade proposal #%d sent to %s.", len(session.Trades)-1, req.TargetPlayerID),
+		fmt.Sprintf("Offering %v for %v. Your cards are held until %s.", req.Give, req.Receive, resp.ExpiresAt.Format("15:04:05")))
+}
+
+// Opção 19
+func handleViewTrades(h *GameHandler, session *PlayerSession, payload json.RawMessage) {
+	if !checkLobbyState(session) {
+		message.SendErrorAndPrompt(session.Client, "You are not in lobby")
+		return
+	}
+	var sb strings.Builder
+	if len(session.Trades) == 0 {
+		sb.WriteString("(none)\n")
+	}
+	for i, t := range session.Trades {
+		if t.FromID == session.ID {
+			sb.WriteString(fmt.Sprintf("[%d] TO %s: you give %v, you receive %v", i, t.ToID, t.Give, t.Receive))
+		} else {
+			sb.WriteString(fmt.Sprintf("[%d] FROM %s: you give %v, you receive %v", i, t.FromID, t.Receive, t.Give))
+		}
+		switch {
+		case t.Accepted:
+			sb.WriteString(" (settling)")
+		case t.FromID != session.ID:
+			sb.WriteString(fmt.Sprintf(" (ACCEPT or REJECT before %s)", t.ExpiresAt.Format("15:04:05")))
+		default:
+			sb.WriteString(fmt.Sprintf(" (waiting, expires %s)", t.ExpiresAt.Format("15:04:05")))
+		}
+		sb.WriteString("\n")
+	}
+	message.SendSuccessAndPrompt(session.Client, session.State, "Your trade proposals:", sb.String())
+}
+
+// Opção 20
+// handleAcceptTrade põe as cartas pedidas em custódia e confirma a troca no Queue.
+func handleAcceptTrade(h *GameHandler, session *PlayerSession, payload json.RawMessage) {
+	trade, ok := incomingTradeFromPayload(session, payload)
+	if !ok {
+		return
+	}
+	if err := h.escrowCards(session, trade.Receive); err != nil {
+		message.SendErrorAndPrompt(session.Client, "Cannot accept trade: %v", err)
+		return
+	}
+	if err := h.respondTradeProposal(session, trade.ID, "accept"); err != nil {
+		if marketNotApplied(err) {
+			h.creditCards(session, trade.Receive)
+			message.SendErrorAndPrompt(session.Client, "Failed to accept trade: %v", err)
+			return
+		}
+		// O aceite pode ter entrado: as cartas ficam em custódia até o Queue dizer.
+		trade.Accepted = true
+		h.reconcileTrade(session, trade.ID, "accept", trade.Receive)
+		message.SendErrorAndPrompt(session.Client, "The trade market did not answer (%v). Your acceptance is being confirmed; you will be notified.", err)
+		return
+	}
+	trade.Accepted = true
+	message.SendSuccessAndPrompt(session.Client, session.State, "Trade accepted.",
+		"Recording the trade on the ledger. You will be notified when it is done.")
+}
+
+// Opção 21
+func handleRejectTrade(h *GameHandler, session *PlayerSession, payload json.RawMessage) {
+	trade, ok := incomingTradeFromPayload(session, payload)
+	if !ok {
+		return
+	}
+	if err := h.respondTradeProposal(session, trade.ID, "reject"); err != nil {
+		message.SendErrorAndPrompt(session.Client, "Failed to reject trade: %v", err)
+		return
+	}
+	session.removeTrade(trade.ID)
+	message.SendSuccessAndPrompt(session.Client, session.State, "Trade rejected.", fmt.Sprintf("%s has been notified.", trade.FromID))
+}
+
+// incomingTradeFromPayload resolve uma proposta recebida que ainda espera resposta.
+func incomingTradeFromPayload(session *PlayerSession, payload json.RawMessage) (*DirectTrade, bool) {
+	if !checkLobbyState(session) {
+		message.SendErrorAndPrompt(session.Client, "You are not in lobby")
+		return nil, false
+	}
+	trade, err := tradeFromPayload(session, payload)
+	if err != nil {
+		message.SendErrorAndPrompt(session.Client, "%v", err)
+		return nil, false
+	}
+	if trade.FromID == session.ID {
+		message.SendErrorAndPrompt(session.Client, "This proposal was made by you; only %s can answer it.", trade.ToID)
+		return nil, false
+	}
+	if trade.Accepted {
+		message.SendErrorAndPrompt(session.Client, "You already accepted this trade.")
+		return nil, false
+	}
+	return trade, true
+}
+
+// closeAllTrades encerra as trocas de um jogador que está saindo: cancela as que ele
+// propôs e recusa as que recebeu e ainda não aceitou. As cartas do proponente voltam
+// pelo TradeOutcome do cancelamento, recolhido no próximo login; as trocas já
+// aceitas seguem no ledger e o resultado também fica esperando o login.
+func (h *GameHandler) closeAllTrades(session *PlayerSession) {
+	for _, trade := range append([]*DirectTrade(nil), session.Trades...) {
+		if trade.Accepted {
+			continue
+		}
+		if trade.FromID == session.ID {
+			if !h.settleTradeAttempt(session, trade.ID, "cancel", trade.Give) {
+				h.reconcileTrade(session, trade.ID, "cancel", trade.Give)
+			}
+		} else if err := h.respondTradeProposal(session, trade.ID, "reject"); err != nil {
+			log.Printf("WARN: Failed to reject trade %s for player %s on disconnect: %v", trade.ID, session.ID, err)
+		}
+		session.removeTrade(trade.ID)
+	}
+}
+
+// reconcileTrade repete em segundo plano um "cancel" (proponente) ou "accept"
+// (destinatário) cuja resposta se perdeu, até o Queue responder de vez.
+func (h *GameHandler) reconcileTrade(session *PlayerSession, proposalID, action string, held []string) {
+	go func() {
+		for attempt := 1; attempt <= tradeRetryAttempts; attempt++ {
+			time.Sleep(tradeRetryInterval)
+			if h.settleTradeAttempt(session, proposalID, action, held) {
+				return
+			}
+		}
+		log.Printf("CRITICAL: Trade %s of player %s is still unresolved after %d attempts to %s it; %v stay in escrow.", proposalID, session.ID, tradeRetryAttempts, action, held)
+	}()
+}
+
+// settleTradeAttempt manda a ação uma vez e diz se a resposta foi definitiva.
+// Só um 404 devolve as cartas aqui: a proposta não existe (ou o aceite não entrou)
+// e elas nunca saíram do Session. Com 200 ou 409 o resultado vem num TradeOutcome.
+func (h *GameHandler) settleTradeAttempt(session *PlayerSession, proposalID, action string, held []string) bool {
+	err := h.respondTradeProposal(session, proposalID, action)
+	switch {
+	case err == nil:
+		return true
+	case marketStatus(err) == http.StatusNotFound:
+		session.removeTrade(proposalID)
+		h.creditCards(session, held)
+		log.Printf("Trade %s of player %s did not go through (%s refused: %v). Returned %v.", proposalID, session.ID, action, err, held)
+		if h.findSessionByID(session.ID) == session {
+			message.SendSuccessAndPrompt(session.Client, session.State, "The trade did not go through.", fmt.Sprintf("%v are back in your collection.", held))
+		}
+		return true
+	case marketRefused(err):
+		return true
+	}
+	log.Printf("WARN: Trade %s of player %s: %s not confirmed yet: %v", proposalID, session.ID, action, err)
+	return false
+}
+
+// ============================================================================
+// Callback de Trocas Diretas (/trade-event)
+// ============================================================================
+
+type TradeProposalPayload struct {
+	ID        string    `json:"id"`
+	FromID    string    `json:"fromId"`
+	ToID      string    `json:"toId"`
+	Give      []string  `json:"give"`
+	Receive   []string  `json:"receive"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type TradeEventPayload struct {
+	EventType string               `json:"eventType"`
+	PlayerID  string               `json:"playerId"`
+	Proposal  TradeProposalPayload `json:"proposal"`
+	Reason    string               `json:"reason,omitempty"`
+	OutcomeID string               `json:"outcomeId,omitempty"`
+}
+
+// CallbackTradeEvent recebe os eventos roteados pelo líder do Queue. Responde 404
+// quando o jogador não está conectado neste nó, para o Queue saber que não entregou.
+// Os resultados (recusa, expiração, troca feita...) só avisam: as cartas vêm do
+// TradeOutcome recolhido no Queue, que continua lá se o aviso não chegar e é
+// entregue no próximo login.
+func (h *GameHandler) CallbackTradeEvent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var event TradeEventPayload
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		http.Error(w, "Invalid payload", http.StatusBadRequest)
+		return
+	}
+	session := h.findSessionByID(event.PlayerID)
+	if session == nil {
+		http.Error(w, "Player not connected here", http.StatusNotFound)
+		return
+	}
+
+	if event.EventType != "TRADE_PROPOSED" {
+		// Resultado: as cartas só mudam de mão pelo collect, como no /trade-found.
+		notes := h.deliverTradeOutcomes(session)
+		w.WriteHeader(http.StatusOK)
+		if len(notes) > 0 {
+			message.SendSuccessAndPrompt(session.Client, session.State, "Trade update", strings.Join(notes, "\n"))
+		}
+		return
+	}
+	p := event.Proposal
+	session.Trades = append(session.Trades, &DirectTrade{ID: p.ID, FromID: p.FromID, ToID: p.ToID, Give: p.Give, Receive: p.Receive, ExpiresAt: p.ExpiresAt})
+	message.SendSuccessAndPrompt(session.Client, session.State,
+		fmt.Sprintf("Trade proposal #%d from %s!", len(session.Trades)-1, p.FromID),
+		fmt.Sprintf("They give you %v for your %v. Use ACCEPT_TRADE or REJECT_TRADE before %s.", p.Give, p.Receive, p.ExpiresAt.Format("15:04:05")))
+	w.WriteHeader(http.StatusOK)
+}
+
+// deliverTradeOutcomes recolhe os resultados de trocas diretas do jogador e credita
+// as cartas de cada um. Retorna uma linha por resultado para mostrar ao jogador.
+func (h *GameHandler) deliverTradeOutcomes(session *PlayerSession) []string {
+	session.deliveryMu.Lock()
+	defer session.deliveryMu.Unlock()
+	outcomes, err := h.collectTradeOutcomes(session)
+	if err != nil {
+		log.Printf("WARN: Could not collect trade outcomes of player %s: %v", session.ID, err)
+		return nil
+	}
+	notes := make([]string, 0, len(outcomes))
+	ids := make([]string, len(outcomes))
+	for i, o := range outcomes {
+		ids[i] = o.ID
+		session.removeTrade(o.Proposal.ID)
+	}
+	h.creditOnce(session, "/trades/outcomes/ack", ids, func(i int) {
+		o := outcomes[i]
+		p := o.Proposal
+		h.addCards(session, o.Credit)
+		proposer := p.FromID == session.ID
+		switch o.EventType {
+		case "TRADE_SETTLED":
+			sent, partner := p.Receive, p.FromID
+			if proposer {
+				sent, partner = p.Give, p.ToID
+			}
+			notes = append(notes, fmt.Sprintf("Trade Completed! You sent %v to %s and received %v.", sent, partner, o.Credit))
+		case "TRADE_FAILED":
+			notes = append(notes, fmt.Sprintf("Trade failed: %s. %v are back in your collection.", o.Reason, o.Credit))
+		default:
+			if proposer {
+				notes = append(notes, fmt.Sprintf("Trade with %s did not happen: %s. %v are back in your collection.", p.ToID, o.Reason, o.Credit))
+			} else {
+				notes = append(notes, fmt.Sprintf("Trade proposal from %s is gone: %s. Nothing left your collection.", p.FromID, o.Reason))
+			}
+		}
+	})
+	return notes
+}
+
+func (h *GameHandler) registerTradeHandlers() {
+	h.lobbyRouter["PROPOSE_TRADE"] = handleProposeTrade
+	h.lobbyRouter["VIEW_TRADES"] = handleViewTrades
+	h.lobbyRouter["ACCEPT_TRADE"] = handleAcceptTrade
+	h.lobbyRouter["REJECT_TRADE"] = handleRejectTrade
+}
+
+//END OF FILE jokenpo/internal/session/handlers_trades.go
@@ -0,0 +1,107 @@
+//START OF FILE jokenpo/internal/session/inventory.go
+package session
+
+import (
+	"encoding/json"
+	"jokenpo/internal/game/player"
+	"net/http"
+	"jokenpo/internal/session/storage"
+	"log"
+)
+
+// loadPlayer monta um jogador com a coleção e o deck salvos da conta, e devolve
+// os registros do Queue já creditados e ainda sem ack.
+// Uma conta sem inventário salvo (recém-criada) começa vazia.
+func (h *GameHandler) loadPlayer(playerID string) (*player.Player, []string, error) {
+	inv, found, err := h.inventories.Load(playerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !found {
+		return player.NewPlayer(), nil, nil
+	}
+	p, dropped, err := playerFromInventory(inv)
+	for _, key := range dropped {
+		log.Printf("WARN: Dropping card '%s' from saved deck of player %s", key, playerID)
+	}
+	return p, inv.Delivered, err
+}
+
+// playerFromInventory monta um jogador a partir de um inventário. Cartas do deck
+// que a coleção não comporta mais são descartadas e devolvidas em 'dropped'.
+func playerFromInventory(inv storage.Inventory) (p *player.Player, dropped []string, err error) {
+	p = player.NewPlayer()
+	for key, count := range inv.Collection {
+		if err := p.AddCardToCollection(key, int(count)); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, key := range inv.Deck {
+		if _, err := p.AddCardToDeck(key); err != nil {
+			dropped = append(dropped, key)
+		}
+	}
+	return p, dropped, nil
+}
+
+// deckKeys devolve as chaves das cartas do deck do jogador, na ordem.
+func deckKeys(p *player.Player) ([]string, error) {
+	deckJSON, err := p.Inventory().GameDeck().ToJSON()
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	err = json.Unmarshal(deckJSON, &keys)
+	return keys, err
+}
+
+// persistInventory grava a coleção e o deck atuais do jogador no repositório.
+// Os handlers chamam após cada mutação da coleção ou do deck; quem precisa saber
+// se a gravação deu certo (ex: antes de um ack ao Queue) olha o erro.
+func (h *GameHandler) persistInventory(session *PlayerSession) error {
+	if session.ID == "" {
+		return nil
+	}
+	snap := storage.Inventory{
+		Collection: session.Player.Inventory().Collection().Counts(),
+		Delivered:  session.deliveredIDs(),
+	}
+	var err error
+	snap.Deck, err = deckKeys(session.Player)
+	if err == nil {
+		err = h.inventories.Save(session.ID, snap)
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to save inventory of player %s: %v", session.ID, err)
+	}
+	return err
+}
+
+// creditOnce credita os registros que o Queue listou para o jogador (escrows ou
+// resultados) e confirma a entrega em ackPath. Cada registro é marcado antes do
+// crédito e a marca vai junto no inventário, então um registro listado de novo
+// (o ack anterior não chegou) não é creditado duas vezes. O ack só sai depois de
+// o inventário ser gravado; se a gravação falhar, o Queue continua com os
+// registros. credit(i) credita o registro ids[i] e só é chamado para os novos.
+// Quem chama segura session.deliveryMu desde o list.
+func (h *GameHandler) creditOnce(session *PlayerSession, ackPath string, ids []string, credit func(i int)) {
+	if len(ids) == 0 {
+		return
+	}
+	for i, id := range ids {
+		if session.markDelivered(id) {
+			credit(i)
+		}
+	}
+	if err := h.persistInventory(session); err != nil {
+		return
+	}
+	if err := h.marketRequest(http.MethodPost, ackPath, AckDeliveryRequest{PlayerID: session.ID, IDs: ids}, http.StatusOK, nil); err != nil {
+		log.Printf("WARN: Could not ack %d records at %s for player %s (they will be listed again): %v", len(ids), ackPath, session.ID, err)
+		return
+	}
+	session.forgetDelivered(ids)
+	h.persistInventory(session)
+}
+
+//END OF FILE jokenpo/internal/session/inventory.go
@@ -0,0 +1,54 @@
+//START OF FILE jokenpo/internal/session/ledger_sync.go
+package session
+
+import (
+	"jokenpo/internal/game/player"
+	"jokenpo/internal/services/blockchain"
+	"jokenpo/internal/session/storage"
+)
+
+// rebuildFromLedger monta um novo jogador com a coleção registrada no contrato,
+// mantendo o deck atual no que a nova coleção ainda comportar.
+func rebuildFromLedger(bc *blockchain.BlockchainClient, playerID string, p *player.Player) (*player.Player, *blockchain.CollectionDrift, []string, error) {
+	rebuilt, drift, err := bc.Reconcile(playerID, p.Inventory().Collection())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	currentDeck, err := deckKeys(p)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	synced, dropped, err := playerFromInventory(storage.Inventory{Collection: rebuilt.Counts(), Deck: currentDeck})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return synced, drift, dropped, nil
+}
+
+// SyncStoredInventory é a versão em lote (operador) do SYNC_FROM_LEDGER: compara o
+// inventário salvo de um jogador com o ledger e, com apply, grava a coleção do ledger.
+// Devolve o drift e as cartas que saíram do deck. Com apply, quem chama precisa
+// segurar a presença do jogador (presence.Store.Claim), senão um Session com o
+// jogador em memória sobrescreve a gravação.
+func SyncStoredInventory(bc *blockchain.BlockchainClient, repo storage.Repository, playerID string, apply bool) (*blockchain.CollectionDrift, []string, error) {
+	inv, _, err := repo.Load(playerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	p, _, err := playerFromInventory(inv)
+	if err != nil {
+		return nil, nil, err
+	}
+	synced, drift, dropped, err := rebuildFromLedger(bc, playerID, p)
+	if err != nil || !apply || drift.InSync() {
+		return drift, dropped, err
+	}
+
+	out := storage.Inventory{Collection: synced.Inventory().Collection().Counts(), Delivered: inv.Delivered}
+	if out.Deck, err = deckKeys(synced); err != nil {
+		return drift, dropped, err
+	}
+	return drift, dropped, repo.Save(playerID, out)
+}
+
+//END OF FILE jokenpo/internal/session/ledger_sync.go
@@ -2,14 +2,17 @@
 package session
 
 import (
+	"context"
 	"jokenpo/internal/game/player"
+	"slices"
+	"sync"
 	"jokenpo/internal/network"
-
-	"github.com/google/uuid"
+	"jokenpo/internal/session/account"
 )
 
 // Constantes de estado da sessão para evitar erros de digitação.
 const (
+	state_AUTH = "awaiting-login" // Conectado, mas ainda sem LOGIN/REGISTER.
 	state_LOBBY = "lobby"  // Jogador está online, no menu, pode usar o chat, etc.
 	state_IN_MATCH = "in-match" // Jogador está em uma partida ativa.
 	state_IN_MATCH_QUEUE = "in-match-queue"
@@ -18,22 +21,34 @@ const (
 
 // PlayerSession representa um jogador único e conectado ao servidor.
 type PlayerSession struct {
-	ID string
+	ID string // ID estável da conta; vazio até o login.
+	Account *account.Account
 	Client *network.Client
 	Player *player.Player
 
 	State  string // Usará as constantes StateLobby ou StateInMatch.
 	CurrentGame *CurrentGameInfo
+	Fairness    *MatchFairness // Dados para verificar o embaralhamento da partida atual
+	Listings    []*MarketListing // Ofertas do jogador no mercado de trocas; a carta oferecida fica fora da coleção
+	Trades      []*DirectTrade   // Trocas diretas propostas ou recebidas; cartas em custódia ficam fora da coleção
+
+	LastMatch   *FinishedMatch   // Última partida terminada; alvo do REQUEST_REMATCH no lobby
+
+	gameStream     context.CancelFunc // Encerra a leitura do stream de eventos da partida atual
+	pendingRematch *PendingRematch    // Revanche pedida e ainda não anunciada pela sala
+
+	deliveryMu  sync.Mutex      // Serializa list -> crédito -> ack dos registros do Queue (inventory.go)
+	deliveredMu sync.Mutex      // Protege delivered
+	delivered   map[string]bool // Registros do Queue já creditados e ainda sem ack; vai junto no inventário
 }
 
 // NewPlayerSession cria e inicializa uma nova sessão de jogador.
 func NewPlayerSession(client *network.Client) *PlayerSession {
 
 	return &PlayerSession{
-		ID: uuid.NewString(),
 		Client: client,
 		Player: player.NewPlayer(),
-		State:  state_LOBBY, // Todo jogador começa no lobby.
+		State:  state_AUTH, // O primeiro comando precisa ser LOGIN ou REGISTER.
 		CurrentGame: nil,
 	}
 }
@@ -42,6 +57,58 @@ func NewPlayerSession(client *network.Client) *PlayerSession {
 type CurrentGameInfo struct {
 	RoomID     string `json:"roomId"`     // O UUID da sala de jogo
 	ServiceAddr string `json:"serviceAddr"` // O endereço de rede (host:port) do GameRoomService onde a sala está.
+	LastEventSeq uint64 `json:"lastEventSeq"` // Último evento do stream da sala entregue ao cliente
+	ResumeToken string `json:"resumeToken,omitempty"` // Apresentado no LOGIN para voltar à partida depois de uma queda
+}
+
+// FinishedMatch lembra a sala da última partida, para pedir a revanche depois do GAME_OVER.
+type FinishedMatch struct {
+	RoomID      string
+	ServiceAddr string
+	Deck        []string // Deck na ordem enviada para a sala; nil se o embaralhamento não era verificável
+}
+
+// markDelivered registra que o registro id do Queue foi creditado. Retorna false
+// se ele já tinha sido (ex: o ack anterior não chegou ao Queue).
+func (s *PlayerSession) markDelivered(id string) bool {
+	s.deliveredMu.Lock()
+	defer s.deliveredMu.Unlock()
+	if s.delivered[id] {
+		return false
+	}
+	if s.delivered == nil {
+		s.delivered = make(map[string]bool)
+	}
+	s.delivered[id] = true
+	return true
+}
+
+// forgetDelivered esquece os registros que o Queue confirmou ter apagado.
+func (s *PlayerSession) forgetDelivered(ids []string) {
+	s.deliveredMu.Lock()
+	defer s.deliveredMu.Unlock()
+	for _, id := range ids {
+		delete(s.delivered, id)
+	}
+}
+
+// deliveredIDs devolve os registros creditados e ainda sem ack, em ordem.
+func (s *PlayerSession) deliveredIDs() []string {
+	s.deliveredMu.Lock()
+	defer s.deliveredMu.Unlock()
+	ids := make([]string, 0, len(s.delivered))
+	for id := range s.delivered {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// PendingRematch é o pedido de revanche feito pelo jogador. O /match-found da
+// revanche só é aceito se vier da mesma sala.
+type PendingRematch struct {
+	RoomID   string         // Sala da partida cuja revanche foi pedida
+	Fairness *MatchFairness // Entropia enviada no pedido; vira Fairness quando a revanche começa
 }
 
 //END OF FILE jokenpo/internal/session/player.go
\ No newline at end of file
@@ -0,0 +1,104 @@
+//START OF FILE jokenpo/internal/session/resume.go
+package session
+
+import (
+	"errors"
+	"jokenpo/internal/services/presence"
+	"jokenpo/internal/session/message"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Retomada de partida: quando a partida começa, a sessão grava no Consul um
+// registro com a sala e um token de retomada (o token vai para o cliente junto com
+// o "Match found"). Se o WebSocket cair, a sala é avisada e segura a partida pelo
+// período de graça; o jogador volta fazendo LOGIN com o campo "resumeToken",
+// em qualquer nó do Session.
+
+// issueResumeToken cria o token da partida e grava o registro de retomada.
+func (h *GameHandler) issueResumeToken(session *PlayerSession, game *CurrentGameInfo) {
+	game.ResumeToken = uuid.NewString()
+	record := presence.ResumeRecord{
+		Token:       game.ResumeToken,
+		RoomID:      game.RoomID,
+		ServiceAddr: game.ServiceAddr,
+		IssuedAt:    time.Now(),
+	}
+	if err := h.resumes.Save(session.ID, record); err != nil {
+		log.Printf("WARN: Failed to save resume record of player %s: %v", session.ID, err)
+	}
+}
+
+// clearResume apaga o registro de retomada quando a partida termina.
+func (h *GameHandler) clearResume(playerID, roomID string) {
+	if err := h.resumes.Clear(playerID, roomID); err != nil {
+		log.Printf("WARN: Failed to clear resume record of player %s: %v", playerID, err)
+	}
+}
+
+// suspendMatch é chamado no disconnect: avisa a sala para ela começar a contar
+// o período de graça. O registro de retomada fica no Consul.
+func (h *GameHandler) suspendMatch(session *PlayerSession) {
+	game := session.CurrentGame
+	if session.State != state_IN_MATCH || game == nil {
+		return
+	}
+	if err := h.notifyRoomPresence(game, session.ID, false); err != nil {
+		log.Printf("WARN: Failed to tell room %s that player %s disconnected: %v", game.RoomID, session.ID, err)
+		if errors.Is(err, errRoomGone) {
+			h.clearResume(session.ID, game.RoomID)
+		}
+	}
+}
+
+// resumeMatch leva o jogador recém-logado de volta à partida do registro, se o
+// token bater. O cliente recebe um snapshot da partida e o stream de eventos
+// continua a partir dele.
+func (h *GameHandler) resumeMatch(session *PlayerSession, token string) {
+	record, err := h.resumes.Load(session.ID)
+	if err != nil {
+		log.Printf("ERROR: Failed to load resume record of player %s: %v", session.ID, err)
+		message.SendErrorAndPrompt(session.Client, "Could not look up your match. Please try again later.")
+		return
+	}
+	if record == nil || record.Token != token {
+		message.SendErrorAndPrompt(session.Client, "There is no match to resume with this token.")
+		return
+	}
+
+	game := &CurrentGameInfo{
+		RoomID:      record.RoomID,
+		ServiceAddr: record.ServiceAddr,
+		ResumeToken: record.Token,
+	}
+	snapshot, err := h.fetchRoomSnapshot(game, session.ID)
+	if errors.Is(err, errRoomGone) || (err == nil && snapshot.Phase == roomPhaseGameOver) {
+		h.clearResume(session.ID, record.RoomID)
+		message.SendErrorAndPrompt(session.Client, "Your match has already ended.")
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to fetch snapshot of room %s for %s: %v", record.RoomID, session.ID, err)
+		message.SendErrorAndPrompt(session.Client, "Could not reach your match: %v", err)
+		return
+	}
+	if err := h.notifyRoomPresence(game, session.ID, true); err != nil {
+		log.Printf("WARN: Failed to tell room %s that player %s is back: %v", game.RoomID, session.ID, err)
+	}
+
+	// Os eventos até o snapshot já estão refletidos nele; o stream segue do próximo.
+	// Os UPDATE_HAND perdidos na queda não voltam, então o embaralhamento desta
+	// partida não é mais verificado.
+	game.LastEventSeq = snapshot.Seq
+	session.CurrentGame = game
+	session.Fairness = nil
+	session.State = state_IN_MATCH
+	log.Printf("Player %s resumed match in room %s at seq %d.", session.ID, game.RoomID, snapshot.Seq)
+
+	message.SendSuccessAndPrompt(session.Client, session.State, "Reconnected to your match.", snapshot)
+	h.followGameStream(session, game)
+}
+
+//END OF FILE jokenpo/internal/session/resume.go
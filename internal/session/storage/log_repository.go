@@ -0,0 +1,160 @@
+//START OF FILE jokenpo/internal/session/storage/log_repository.go
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// compactEvery é o número de registros que um log acumula antes de ser compactado.
+const compactEvery = 64
+
+// logRecord é uma linha do log de um jogador: o inventário completo após uma mutação.
+type logRecord struct {
+	SavedAt   time.Time `json:"savedAt"`
+	Inventory Inventory `json:"inventory"`
+}
+
+// LogRepository é a implementação embutida de Repository: um log append-only de
+// JSON por jogador (inventories/<playerID>.log). Cada Save acrescenta uma linha e
+// faz fsync; o Load usa a última linha íntegra, então uma escrita interrompida no
+// meio por uma queda só perde aquela mutação. De tempos em tempos o log é
+// reescrito só com o último registro.
+type LogRepository struct {
+	dir     string
+	mu      sync.Mutex
+	appends map[string]int // registros escritos por jogador desde a última compactação
+}
+
+func NewLogRepository(dataDir string) (*LogRepository, error) {
+	dir := filepath.Join(dataDir, "inventories")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create inventory store at '%s': %w", dir, err)
+	}
+	return &LogRepository{dir: dir, appends: make(map[string]int)}, nil
+}
+
+func (r *LogRepository) Load(playerID string) (Inventory, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := os.ReadFile(r.path(playerID))
+	if errors.Is(err, os.ErrNotExist) {
+		return Inventory{}, false, nil
+	}
+	if err != nil {
+		return Inventory{}, false, err
+	}
+
+	var last *logRecord
+	lines := 0
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		lines++
+		var rec logRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			log.Printf("WARN: Ignoring corrupted line %d in inventory log of player %s: %v", lines, playerID, err)
+			continue
+		}
+		last = &rec
+	}
+	if err := scanner.Err(); err != nil {
+		return Inventory{}, false, err
+	}
+	if last == nil {
+		return Inventory{}, false, fmt.Errorf("inventory log of player %s has no valid record", playerID)
+	}
+	r.appends[playerID] = lines
+	if !bytes.HasSuffix(data, []byte("\n")) {
+		// Última escrita interrompida: compacta no próximo Save, senão a linha
+		// nova seria colada na linha quebrada.
+		r.appends[playerID] = compactEvery
+	}
+	return last.Inventory, true, nil
+}
+
+func (r *LogRepository) Save(playerID string, inv Inventory) error {
+	line, err := json.Marshal(logRecord{SavedAt: time.Now().UTC(), Inventory: inv})
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.appends[playerID] >= compactEvery {
+		if err := r.compact(playerID, line); err != nil {
+			return err
+		}
+		r.appends[playerID] = 1
+		return nil
+	}
+
+	f, err := os.OpenFile(r.path(playerID), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open inventory log: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(line); err != nil {
+		return fmt.Errorf("failed to append to inventory log: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync inventory log: %w", err)
+	}
+	r.appends[playerID]++
+	return nil
+}
+
+func (r *LogRepository) PlayerIDs() ([]string, error) {
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, e := range entries {
+		if id, ok := strings.CutSuffix(e.Name(), ".log"); ok && !e.IsDir() {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// compact substitui o log inteiro por uma única linha (o registro mais recente).
+// A troca é feita por rename, então um leitor vê o log antigo ou o novo, nunca um meio-termo.
+func (r *LogRepository) compact(playerID string, line []byte) error {
+	tmp, err := os.CreateTemp(r.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(line); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), r.path(playerID))
+}
+
+// filepath.Base impede que um ID malformado escape do diretório.
+func (r *LogRepository) path(playerID string) string {
+	return filepath.Join(r.dir, filepath.Base(playerID)+".log")
+}
+
+//END OF FILE jokenpo/internal/session/storage/log_repository.go
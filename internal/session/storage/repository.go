@@ -0,0 +1,22 @@
+//START OF FILE jokenpo/internal/session/storage/repository.go
+package storage
+
+// Inventory é a forma persistida da coleção e do deck de um jogador.
+type Inventory struct {
+	Collection map[string]uint `json:"collection"` // chave da carta -> cópias
+	Deck       []string        `json:"deck"`       // chaves na ordem do deck
+	Delivered  []string        `json:"delivered,omitempty"` // registros do Queue já creditados e ainda sem ack
+}
+
+// Repository guarda os inventários dos jogadores fora da memória do Session,
+// para que a queda de um nó não apague as cartas de quem estava conectado.
+type Repository interface {
+	// Load devolve o último inventário salvo. found = false se o jogador ainda não tiver um.
+	Load(playerID string) (inv Inventory, found bool, err error)
+	// Save grava o inventário atual do jogador. Deve ser chamado após cada mutação.
+	Save(playerID string, inv Inventory) error
+	// PlayerIDs lista os jogadores com inventário salvo (usado por jobs em lote).
+	PlayerIDs() ([]string, error)
+}
+
+//END OF FILE jokenpo/internal/session/storage/repository.go